@@ -0,0 +1,107 @@
+package accountnumber
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// the account numbers follow the IBAN layout (ISO 13616): a two letter prefix, two mod-97 check
+// digits and the basic account number. The basic account number is random so that the numbers
+// don't leak how many accounts have been opened
+const (
+	// Prefix : takes the place of the country code of an IBAN
+	Prefix = "BK"
+
+	bbanLength = 16
+	length     = len(Prefix) + 2 + bbanLength
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid account number format")
+
+	ErrInvalidChecksum = errors.New("invalid account number check digits")
+)
+
+// Generate : returns a new random account number, e.g: BK3712345678901234567890
+func Generate() (string, error) {
+	return GenerateFrom(rand.Reader)
+}
+
+// GenerateFrom : returns a new account number with the digits read from the source of randomness,
+// e.g: a seeded math/rand source so that the same numbers are generated again
+func GenerateFrom(random io.Reader) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < bbanLength; i++ {
+		digit, err := rand.Int(random, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(digit.String())
+	}
+
+	bban := sb.String()
+	return Prefix + checkDigits(Prefix, bban) + bban, nil
+}
+
+// Normalize : strips the spaces used for grouping the account numbers and uppercases the letters
+func Normalize(accountNumber string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(accountNumber), ""))
+}
+
+// Validate : checks the format and the check digits of the normalized account number
+func Validate(accountNumber string) error {
+	if len(accountNumber) != length || !strings.HasPrefix(accountNumber, Prefix) {
+		return ErrInvalidFormat
+	}
+
+	for _, r := range accountNumber[len(Prefix):] {
+		if r < '0' || r > '9' {
+			return ErrInvalidFormat
+		}
+	}
+
+	// the rearranged number, i.e the check digits and the prefix moved to the end, must leave a remainder of 1
+	if mod97(accountNumber[4:]+accountNumber[:4]) != 1 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// Format : groups the account number in blocks of four characters for display
+func Format(accountNumber string) string {
+	var sb strings.Builder
+	for i, r := range accountNumber {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// checkDigits : computes the two check digits of the basic account number
+func checkDigits(prefix, bban string) string {
+	checksum := 98 - mod97(bban+prefix+"00")
+	if checksum < 10 {
+		return "0" + strconv.Itoa(checksum)
+	}
+	return strconv.Itoa(checksum)
+}
+
+// mod97 : computes the remainder piecewise, letters count as two digit numbers (A = 10, ..., Z = 35)
+func mod97(value string) int {
+	remainder := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
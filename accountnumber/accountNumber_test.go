@@ -0,0 +1,97 @@
+package accountnumber
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		accountNumber, err := Generate()
+		require.NoError(t, err)
+		require.Len(t, accountNumber, length)
+		require.NoError(t, Validate(accountNumber))
+
+		require.False(t, seen[accountNumber])
+		seen[accountNumber] = true
+	}
+}
+
+func TestGenerateFrom(t *testing.T) {
+	first, err := GenerateFrom(rand.New(rand.NewSource(42)))
+	require.NoError(t, err)
+	require.NoError(t, Validate(first))
+
+	second, err := GenerateFrom(rand.New(rand.NewSource(42)))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	other, err := GenerateFrom(rand.New(rand.NewSource(43)))
+	require.NoError(t, err)
+	require.NotEqual(t, first, other)
+}
+
+func TestValidate(t *testing.T) {
+	// the example IBAN of the ISO 13616 standard shares the algorithm
+	require.Equal(t, 1, mod97("WEST12345698765432GB82"))
+
+	accountNumber, err := Generate()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		accountNumber string
+		err           error
+	}{
+		{name: "Happy Case - Generated", accountNumber: accountNumber},
+		{name: "Failure Case - Wrong Prefix", accountNumber: "XX" + accountNumber[2:], err: ErrInvalidFormat},
+		{name: "Failure Case - Too Short", accountNumber: accountNumber[:length-1], err: ErrInvalidFormat},
+		{name: "Failure Case - Letter In Basic Account Number", accountNumber: accountNumber[:length-1] + "A", err: ErrInvalidFormat},
+		{name: "Failure Case - Typo", accountNumber: typo(accountNumber, 10), err: ErrInvalidChecksum},
+		{name: "Failure Case - Swapped Digits", accountNumber: swap(accountNumber), err: ErrInvalidChecksum},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.accountNumber)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestNormalizeAndFormat(t *testing.T) {
+	accountNumber, err := Generate()
+	require.NoError(t, err)
+
+	formatted := Format(accountNumber)
+	require.Len(t, formatted, length+length/4-1)
+	require.Equal(t, accountNumber, Normalize(" "+formatted+" "))
+	require.Equal(t, accountNumber, Normalize("bk"+accountNumber[2:]))
+}
+
+// typo : replaces the digit at the index with another digit
+func typo(accountNumber string, index int) string {
+	digit := accountNumber[index]
+	replacement := byte('0' + (digit-'0'+1)%10)
+	return accountNumber[:index] + string(replacement) + accountNumber[index+1:]
+}
+
+// swap : swaps the first two adjacent digits of the basic account number which differ
+func swap(accountNumber string) string {
+	b := []byte(accountNumber)
+	for i := 4; i < len(b)-1; i++ {
+		if b[i] != b[i+1] {
+			b[i], b[i+1] = b[i+1], b[i]
+			break
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skamranahmed/banking-system/accountnumber"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+const adminUsage = `usage: main admin user create -username NAME -full-name NAME -email EMAIL -reason REASON
+       main admin user disable|enable|reset-password -username NAME -reason REASON
+       main admin account freeze|unfreeze (-account-id ID | -account NUMBER) -reason REASON
+       main admin account adjust (-account-id ID | -account NUMBER) -amount AMOUNT -reason REASON
+       main admin audit [-username NAME] [-account-id ID | -account NUMBER] [-limit N]
+every change takes -actor NAME as well, defaulting to the OS user`
+
+// adminFlags : the flags shared by the admin commands which make a change
+type adminFlags struct {
+	*flag.FlagSet
+	actor  string
+	reason string
+}
+
+func newAdminFlags(name string) *adminFlags {
+	flags := &adminFlags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError)}
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&flags.actor, "actor", defaultActor(), "operator making the change")
+	flags.StringVar(&flags.reason, "reason", "", "why the change is made, recorded in the audit trail")
+	return flags
+}
+
+// parse : parses the flags, the reason is mandatory for every change
+func (flags *adminFlags) parse(args []string) (db.Audit, error) {
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 {
+		return db.Audit{}, errors.New(adminUsage)
+	}
+
+	audit := db.Audit{Actor: strings.TrimSpace(flags.actor), Reason: strings.TrimSpace(flags.reason)}
+	if audit.Reason == "" {
+		return audit, fmt.Errorf("-reason is required, %s", adminUsage)
+	}
+	if audit.Actor == "" {
+		return audit, fmt.Errorf("-actor is required, %s", adminUsage)
+	}
+	return audit, nil
+}
+
+// defaultActor : the OS user running the command
+func defaultActor() string {
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return current.Username
+}
+
+// runAdminCommand : handles `admin user|account|audit`, the support changes which are made
+// against the store directly and recorded in the audit trail along with their reason
+func runAdminCommand(conn *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(adminUsage)
+	}
+
+	ctx := context.Background()
+	store := db.NewStore(conn)
+
+	switch args[0] {
+	case "user":
+		if len(args) < 2 {
+			return errors.New(adminUsage)
+		}
+		return runAdminUserCommand(ctx, store, args[1], args[2:])
+	case "account":
+		if len(args) < 2 {
+			return errors.New(adminUsage)
+		}
+		return runAdminAccountCommand(ctx, store, args[1], args[2:])
+	case "audit":
+		return runAdminAuditCommand(ctx, store, args[1:])
+	}
+
+	return fmt.Errorf("unknown admin command %q, %s", args[0], adminUsage)
+}
+
+// runAdminUserCommand : handles `admin user create|disable|enable|reset-password`
+func runAdminUserCommand(ctx context.Context, store db.Store, name string, args []string) error {
+	flags := newAdminFlags("admin user " + name)
+	username := flags.String("username", "", "")
+
+	switch name {
+	case "create":
+		fullName := flags.String("full-name", "", "")
+		email := flags.String("email", "", "")
+		audit, err := flags.parse(args)
+		if err != nil {
+			return err
+		}
+		if *username == "" || *fullName == "" || *email == "" {
+			return errors.New(adminUsage)
+		}
+
+		password, hashedPassword, err := temporaryPassword()
+		if err != nil {
+			return err
+		}
+
+		user, err := store.AdminCreateUserTxn(ctx, db.AdminCreateUserTxnParams{
+			CreateUserParams: db.CreateUserParams{
+				Username: *username,
+				Password: hashedPassword,
+				FullName: *fullName,
+				Email:    *email,
+			},
+			Audit: audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created user %s (id %d)\n", user.Username, user.ID)
+		fmt.Printf("temporary password: %s\n", password)
+		return nil
+
+	case "disable", "enable":
+		audit, err := flags.parse(args)
+		if err != nil {
+			return err
+		}
+
+		user, err := adminUser(ctx, store, *username)
+		if err != nil {
+			return err
+		}
+
+		user, err = store.AdminSetUserDisabledTxn(ctx, db.AdminSetUserDisabledTxnParams{
+			UserID:     user.ID,
+			Disabled:   name == "disable",
+			DisabledAt: time.Now(),
+			Audit:      audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		if user.IsDisabled {
+			fmt.Printf("disabled user %s, the tokens have been revoked\n", user.Username)
+		} else {
+			fmt.Printf("enabled user %s\n", user.Username)
+		}
+		return nil
+
+	case "reset-password":
+		audit, err := flags.parse(args)
+		if err != nil {
+			return err
+		}
+
+		user, err := adminUser(ctx, store, *username)
+		if err != nil {
+			return err
+		}
+
+		password, hashedPassword, err := temporaryPassword()
+		if err != nil {
+			return err
+		}
+
+		user, err = store.AdminResetPasswordTxn(ctx, db.AdminResetPasswordTxnParams{
+			UserID:            user.ID,
+			Password:          hashedPassword,
+			PasswordChangedAt: time.Now(),
+			Audit:             audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("reset the password of user %s, the lockout has been lifted and the tokens revoked\n", user.Username)
+		fmt.Printf("temporary password: %s\n", password)
+		return nil
+	}
+
+	return fmt.Errorf("unknown admin user command %q, %s", name, adminUsage)
+}
+
+// runAdminAccountCommand : handles `admin account freeze|unfreeze|adjust`
+func runAdminAccountCommand(ctx context.Context, store db.Store, name string, args []string) error {
+	flags := newAdminFlags("admin account " + name)
+	accountID := flags.Int64("account-id", 0, "")
+	number := flags.String("account", "", "account number")
+
+	switch name {
+	case "freeze", "unfreeze":
+		audit, err := flags.parse(args)
+		if err != nil {
+			return err
+		}
+
+		account, err := adminAccount(ctx, store, *accountID, *number)
+		if err != nil {
+			return err
+		}
+
+		account, err = store.AdminSetAccountFrozenTxn(ctx, db.AdminSetAccountFrozenTxnParams{
+			AccountID: account.ID,
+			Frozen:    name == "freeze",
+			Audit:     audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		if account.IsFrozen {
+			fmt.Printf("froze account %s (id %d)\n", account.AccountNumber, account.ID)
+		} else {
+			fmt.Printf("unfroze account %s (id %d)\n", account.AccountNumber, account.ID)
+		}
+		return nil
+
+	case "adjust":
+		amount := flags.String("amount", "", "decimal amount, credited if positive and debited if negative, e.g: -12.50")
+		audit, err := flags.parse(args)
+		if err != nil {
+			return err
+		}
+		if *amount == "" {
+			return errors.New(adminUsage)
+		}
+
+		account, err := adminAccount(ctx, store, *accountID, *number)
+		if err != nil {
+			return err
+		}
+
+		currencies, err := money.LoadRegistry(config.CurrenciesFile)
+		if err != nil {
+			return err
+		}
+
+		currency, ok := currencies.Lookup(account.Currency)
+		if !ok {
+			return fmt.Errorf("currency %s of the account is not supported", account.Currency)
+		}
+
+		adjustment, err := money.Parse(*amount, currency)
+		if err != nil {
+			return err
+		}
+
+		accountNumber, err := accountnumber.Generate()
+		if err != nil {
+			return err
+		}
+
+		adjustmentAccount, err := store.SystemAccountTxn(ctx, db.SystemAccountTxnParams{
+			Purpose:       db.SystemAccountAdjustment,
+			Currency:      account.Currency,
+			AccountNumber: accountNumber,
+		})
+		if err != nil {
+			return err
+		}
+
+		result, err := store.AdminAdjustAccountTxn(ctx, db.AdminAdjustAccountTxnParams{
+			AccountID:           account.ID,
+			AdjustmentAccountID: adjustmentAccount.ID,
+			Amount:              adjustment.Amount,
+			Audit:               audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		balance := result.ToAccount
+		if adjustment.IsNegative() {
+			balance = result.FromAccount
+		}
+
+		fmt.Printf("adjusted account %s by %s with transfer %d\n", account.AccountNumber, adjustment, result.Transfer.ID)
+		fmt.Printf("balance: %s\n", money.New(balance.Balance, currency))
+		return nil
+	}
+
+	return fmt.Errorf("unknown admin account command %q, %s", name, adminUsage)
+}
+
+// runAdminAuditCommand : lists the audit trail, latest change first
+func runAdminAuditCommand(ctx context.Context, store db.Store, args []string) error {
+	flags := flag.NewFlagSet("admin audit", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	username := flags.String("username", "", "")
+	accountID := flags.Int64("account-id", 0, "")
+	number := flags.String("account", "", "account number")
+	limit := flags.Int("limit", 20, "")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *limit < 1 {
+		return errors.New(adminUsage)
+	}
+
+	arg := db.ListAdminAuditLogsParams{Limit: int32(*limit)}
+
+	if *username != "" {
+		user, err := adminUser(ctx, store, *username)
+		if err != nil {
+			return err
+		}
+		arg.UserID = sql.NullInt64{Int64: user.ID, Valid: true}
+	}
+
+	if *accountID != 0 || *number != "" {
+		account, err := adminAccount(ctx, store, *accountID, *number)
+		if err != nil {
+			return err
+		}
+		arg.AccountID = sql.NullInt64{Int64: account.ID, Valid: true}
+	}
+
+	logs, err := store.ListAdminAuditLogs(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tACTOR\tACTION\tUSER\tACCOUNT\tREASON\tDETAILS")
+	for _, auditLog := range logs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			auditLog.ID,
+			auditLog.CreatedAt.UTC().Format(time.RFC3339),
+			auditLog.Actor,
+			auditLog.Action,
+			nullID(auditLog.UserID),
+			nullID(auditLog.AccountID),
+			auditLog.Reason,
+			auditLog.Details,
+		)
+	}
+	return w.Flush()
+}
+
+func nullID(id sql.NullInt64) string {
+	if !id.Valid {
+		return "-"
+	}
+	return fmt.Sprint(id.Int64)
+}
+
+// adminUser : looks up the user the command is about
+func adminUser(ctx context.Context, store db.Store, username string) (db.User, error) {
+	if username == "" {
+		return db.User{}, fmt.Errorf("-username is required, %s", adminUsage)
+	}
+
+	user, err := store.GetUserByUsername(ctx, username)
+	if err == sql.ErrNoRows {
+		return user, fmt.Errorf("user %s not found", username)
+	}
+	return user, err
+}
+
+// adminAccount : looks up the account the command is about, by its ID or by its number
+func adminAccount(ctx context.Context, store db.Store, id int64, number string) (db.Account, error) {
+	if (id == 0) == (number == "") {
+		return db.Account{}, fmt.Errorf("exactly one of -account-id or -account is required, %s", adminUsage)
+	}
+
+	var account db.Account
+	var err error
+	if id != 0 {
+		account, err = store.GetAccount(ctx, id)
+	} else {
+		account, err = store.GetAccountByNumber(ctx, accountnumber.Normalize(number))
+	}
+
+	if err == sql.ErrNoRows {
+		return account, errors.New("account not found")
+	}
+	return account, err
+}
+
+// temporaryPassword : returns a random password along with its hash, the user is meant to change it
+// after logging in. It is generated rather than typed so that it never ends up in the shell history
+func temporaryPassword() (string, string, error) {
+	password, err := token.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	hasher, err := newPasswordHasher()
+	if err != nil {
+		return "", "", err
+	}
+
+	hashedPassword, err := hasher.Hash(password)
+	return password, hashedPassword, err
+}
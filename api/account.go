@@ -1,18 +1,54 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"github.com/skamranahmed/banking-system/accountnumber"
+	"github.com/skamranahmed/banking-system/config"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
 	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/utils"
 )
 
+// accountNumberAttempts : number of account numbers tried before giving up on creating an account
+const accountNumberAttempts = 3
+
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	Type     string `json:"type" binding:"omitempty,account_type"` // defaults to checking
+	Nickname string `json:"nickname" binding:"max=64"`
+}
+
+type accountResponse struct {
+	ID            int64       `json:"id"`
+	UserID        int64       `json:"user_id"`
+	Balance       money.Money `json:"balance"`
+	Currency      string      `json:"currency"`
+	Type          string      `json:"type"`
+	Nickname      string      `json:"nickname"`
+	AccountNumber string      `json:"account_number"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+func (server *Server) newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		ID:            account.ID,
+		UserID:        account.UserID,
+		Balance:       money.New(account.Balance, server.currency(account.Currency)),
+		Currency:      account.Currency,
+		Type:          account.Type,
+		Nickname:      account.Nickname,
+		AccountNumber: account.AccountNumber,
+		CreatedAt:     account.CreatedAt,
+	}
 }
 
 func (server *Server) createAccount(c *gin.Context) {
@@ -26,34 +62,79 @@ func (server *Server) createAccount(c *gin.Context) {
 	// extract the authPayload from the request context
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	arg := db.CreateAccountParams{
-		UserID:   int64(authPayload.UserID),
-		Currency: req.Currency,
-		Balance:  0,
+	response, err := server.openAccount(c, int64(authPayload.UserID), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+	return
+}
+
+// openAccount : creates the account of a validated request for the user, shared by the HTTP and the gRPC APIs
+func (server *Server) openAccount(ctx context.Context, userID int64, req createAccountRequest) (accountResponse, error) {
+	if req.Type == "" {
+		req.Type = utils.AccountTypeChecking
+	}
+
+	arg := db.CreateAccountTxnParams{
+		CreateAccountParams: db.CreateAccountParams{
+			UserID:   userID,
+			Currency: req.Currency,
+			Balance:  0,
+			Type:     req.Type,
+			Nickname: strings.TrimSpace(req.Nickname),
+		},
+		MaxAccountsPerCurrency: int64(config.MaxAccountsPerCurrency),
+	}
+
+	// the account numbers are random, a collision with an existing number is retried with a new one
+	var account db.Account
+	var err error
+	for attempt := 0; attempt < accountNumberAttempts; attempt++ {
+		arg.AccountNumber, err = accountnumber.Generate()
+		if err != nil {
+			return accountResponse{}, err
+		}
+
+		account, err = server.store.CreateAccountTxn(ctx, arg)
+		pqErr, ok := err.(*pq.Error)
+		if !ok || pqErr.Code.Name() != "unique_violation" {
+			break
+		}
 	}
 
-	account, err := server.store.CreateAccount(c, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrAccountLimitReached) || errors.Is(err, sql.ErrNoRows) {
+			return accountResponse{}, newRequestError(http.StatusForbidden, err)
+		}
+
 		pqErr, ok := err.(*pq.Error)
 		if ok {
 			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
-				return
+			case "foreign_key_violation":
+				return accountResponse{}, newRequestError(http.StatusForbidden, err)
 			}
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return accountResponse{}, err
 	}
 
-	c.JSON(http.StatusCreated, account)
-	return
+	return server.newAccountResponse(account), nil
 }
 
 type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// getAccountResponse : the balance is the ledger balance, the funds reserved by the active holds
+// are part of it but can't be transferred
+type getAccountResponse struct {
+	accountResponse
+	LedgerBalance    money.Money `json:"ledger_balance"`
+	AvailableBalance money.Money `json:"available_balance"`
+}
+
 func (server *Server) getAccount(c *gin.Context) {
 	var req getAccountRequest
 	err := c.ShouldBindUri(&req)
@@ -65,7 +146,64 @@ func (server *Server) getAccount(c *gin.Context) {
 	// extract the authPayload from the request context
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	account, err := server.store.GetAccount(c, req.ID)
+	response, err := server.accountBalances(c, int64(authPayload.UserID), req.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// accountBalances : returns the account of the user along with its balances, shared by the HTTP and the gRPC APIs
+func (server *Server) accountBalances(ctx context.Context, userID int64, accountID int64) (getAccountResponse, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return getAccountResponse{}, accountLookupError(err)
+	}
+
+	if userID != account.UserID {
+		err := errors.New("account does not belong to the authenticated user")
+		return getAccountResponse{}, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	held, err := server.store.GetHeldAmount(ctx, account.ID)
+	if err != nil {
+		return getAccountResponse{}, err
+	}
+
+	currency := server.currency(account.Currency)
+	return getAccountResponse{
+		accountResponse:  server.newAccountResponse(account),
+		LedgerBalance:    money.New(account.Balance, currency),
+		AvailableBalance: money.New(account.Balance-held, currency),
+	}, nil
+}
+
+type updateAccountRequest struct {
+	Nickname *string `json:"nickname" binding:"required,max=64"` // empty removes the nickname
+}
+
+func (server *Server) updateAccount(c *gin.Context) {
+	var uri getAccountRequest
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateAccountRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := server.store.GetAccount(c, uri.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
@@ -81,13 +219,24 @@ func (server *Server) getAccount(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	account, err = server.store.UpdateAccountNickname(c, db.UpdateAccountNicknameParams{
+		ID:       account.ID,
+		Nickname: strings.TrimSpace(*req.Nickname),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, server.newAccountResponse(account))
 	return
 }
 
 type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Currency string `form:"currency" binding:"omitempty,currency"`
+	Type     string `form:"type" binding:"omitempty,account_type"`
 }
 
 func (server *Server) listAccounts(c *gin.Context) {
@@ -101,18 +250,156 @@ func (server *Server) listAccounts(c *gin.Context) {
 	// extract the authPayload from the request context
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	response, err := server.userAccounts(c, int64(authPayload.UserID), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// userAccounts : returns a page of the accounts of the user, shared by the HTTP and the gRPC APIs
+func (server *Server) userAccounts(ctx context.Context, userID int64, req listAccountsRequest) ([]accountResponse, error) {
 	arg := db.ListAccountsParams{
-		UserID: int64(authPayload.UserID),
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		UserID:   userID,
+		Currency: sql.NullString{String: req.Currency, Valid: req.Currency != ""},
+		Type:     sql.NullString{String: req.Type, Valid: req.Type != ""},
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		response = append(response, server.newAccountResponse(account))
+	}
+
+	return response, nil
+}
+
+type listAccountEntriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// listAccountEntries : the statement of an account, the entries are listed oldest first
+func (server *Server) listAccountEntries(c *gin.Context) {
+	var uri getAccountRequest
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := server.store.GetAccount(c, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if authPayload.UserID != uint(account.UserID) {
+		err := errors.New("account does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	entries, err := server.store.ListEntries(c, db.ListEntriesParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	currency := server.currency(account.Currency)
+	response := make([]entryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, entryResponse{
+			ID:        entry.ID,
+			AccountID: entry.AccountID,
+			Amount:    money.New(entry.Amount, currency),
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+type lookupAccountRequest struct {
+	AccountNumber string `uri:"account_number" binding:"required"`
+}
+
+type lookupAccountResponse struct {
+	AccountNumber string `json:"account_number"`
+	Currency      string `json:"currency"`
+	HolderName    string `json:"holder_name"` // masked, only meant for confirming the recipient
+}
+
+// lookupAccount : lets the sender confirm the recipient of a transfer before sending the money
+func (server *Server) lookupAccount(c *gin.Context) {
+	var req lookupAccountRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	accountNumber := accountnumber.Normalize(req.AccountNumber)
+	err = accountnumber.Validate(accountNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(c, arg)
+	account, err := server.store.GetAccountByNumber(c, accountNumber)
+	if err != nil {
+		handleAccountLookupError(c, err)
+		return
+	}
+
+	user, err := server.store.GetUser(c, account.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, accounts)
+	c.JSON(http.StatusOK, lookupAccountResponse{
+		AccountNumber: account.AccountNumber,
+		Currency:      account.Currency,
+		HolderName:    maskName(user.FullName),
+	})
 	return
 }
+
+// maskName : keeps the first letter of each part of the name, e.g: John Smith -> J*** S****
+func maskName(name string) string {
+	parts := strings.Fields(name)
+	for i, part := range parts {
+		runes := []rune(part)
+		parts[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+	return strings.Join(parts, " ")
+}
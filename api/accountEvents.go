@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/stream"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+// accountEventsKeepAlive : an idle stream gets a comment this often, so that the proxies don't close it
+const accountEventsKeepAlive = time.Second * 15
+
+// sent as an `entry` event
+type accountEntryEvent struct {
+	ID        int64       `json:"id"`
+	Amount    money.Money `json:"amount"` // negative for a debit
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// sent as a `balance` event, once when the stream opens and then on every change
+type accountBalanceEvent struct {
+	LedgerBalance    money.Money `json:"ledger_balance"`
+	AvailableBalance money.Money `json:"available_balance"`
+}
+
+// streamAccountEvents : streams the committed changes of the account as server-sent events. The stream ends
+// if the client falls behind or the server loses track of the changes, the client is expected to reconnect
+// and gets the current balance again
+func (server *Server) streamAccountEvents(c *gin.Context) {
+	var req getAccountRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// subscribe before reading the balance, so that no change is missed in between
+	events, unsubscribe := server.accountEvents.Subscribe(req.ID)
+	defer unsubscribe()
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := server.store.GetAccount(c, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if authPayload.UserID != uint(account.UserID) {
+		err := errors.New("account does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	currency := server.currency(account.Currency)
+	balance, err := server.accountBalanceEvent(c, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent(stream.EventBalance, balance)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(accountEventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			switch event.Type {
+			case stream.EventEntry:
+				c.SSEvent(stream.EventEntry, accountEntryEvent{
+					ID:        event.EntryID,
+					Amount:    money.New(event.Amount, currency),
+					CreatedAt: event.CreatedAt,
+				})
+
+			case stream.EventBalance:
+				// the notification only says that the balance has changed, the database has the latest one
+				updated, err := server.store.GetAccount(c, account.ID)
+				if err == nil {
+					balance, err = server.accountBalanceEvent(c, updated)
+				}
+				if err != nil {
+					return
+				}
+				c.SSEvent(stream.EventBalance, balance)
+
+			default:
+				continue
+			}
+			c.Writer.Flush()
+
+		case <-ticker.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// accountBalanceEvent : returns the ledger and the available balance of the account
+func (server *Server) accountBalanceEvent(ctx context.Context, account db.Account) (accountBalanceEvent, error) {
+	held, err := server.store.GetHeldAmount(ctx, account.ID)
+	if err != nil {
+		return accountBalanceEvent{}, err
+	}
+
+	currency := server.currency(account.Currency)
+	return accountBalanceEvent{
+		LedgerBalance:    money.New(account.Balance, currency),
+		AvailableBalance: money.New(account.Balance-held, currency),
+	}, nil
+}
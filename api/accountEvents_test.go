@@ -0,0 +1,178 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/stream"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent : an event read back from the body of a stream
+type sseEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+func readSSEvents(t *testing.T, body string) []sseEvent {
+	events := make([]sseEvent, 0)
+	for _, block := range strings.Split(body, "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event.name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event.data)
+				require.NoError(t, err)
+			}
+		}
+
+		if event.name != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	account := randomAccount(uint(user.ID))
+	account.Currency = utils.USD
+	account.Balance = 10000
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	updated := account
+	updated.Balance = 7500
+	gomock.InOrder(
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil),
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(updated, nil),
+	)
+	store.EXPECT().GetHeldAmount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(int64(1000), nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/events", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		server.router.ServeHTTP(recorder, request)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return server.accountEvents.Subscribers(account.ID) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the events of other accounts are not streamed
+	server.accountEvents.Publish(stream.Event{Type: stream.EventEntry, AccountID: account.ID + 1, EntryID: 1, Amount: 5})
+	server.accountEvents.Publish(stream.Event{Type: stream.EventEntry, AccountID: account.ID, EntryID: 2, Amount: -2500})
+	server.accountEvents.Publish(stream.Event{Type: stream.EventBalance, AccountID: account.ID})
+
+	// ends the stream once the published events have been sent
+	server.accountEvents.Reset()
+	<-done
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+	events := readSSEvents(t, recorder.Body.String())
+	require.Len(t, events, 3)
+
+	require.Equal(t, stream.EventBalance, events[0].name)
+	require.Equal(t, "100.00", events[0].data["ledger_balance"].(map[string]interface{})["amount"])
+	require.Equal(t, "90.00", events[0].data["available_balance"].(map[string]interface{})["amount"])
+
+	require.Equal(t, stream.EventEntry, events[1].name)
+	require.Equal(t, float64(2), events[1].data["id"])
+	require.Equal(t, "-25.00", events[1].data["amount"].(map[string]interface{})["amount"])
+
+	require.Equal(t, stream.EventBalance, events[2].name)
+	require.Equal(t, "75.00", events[2].data["ledger_balance"].(map[string]interface{})["amount"])
+	require.Equal(t, "65.00", events[2].data["available_balance"].(map[string]interface{})["amount"])
+}
+
+func TestStreamAccountEventsAPIFailures(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+	account := randomAccount(uint(user.ID))
+
+	testCases := []struct {
+		name         string
+		userID       int64
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name:   "Failure Case - Account Of Another User",
+			userID: user.ID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Failure Case - Not Found",
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Failure Case - Internal Server Error",
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			expectStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(tc.userID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+
+			// the subscription ends along with the request
+			require.Zero(t, server.accountEvents.Subscribers(account.ID))
+		})
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/skamranahmed/banking-system/accountnumber"
 	mockdb "github.com/skamranahmed/banking-system/db/mock"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
 	"github.com/skamranahmed/banking-system/token"
@@ -115,6 +118,54 @@ func TestGetAccountAPI(t *testing.T) {
 	}
 }
 
+func TestGetAccountBalanceFormatAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(uint(user.ID))
+	account.Balance = 1234
+	account.Currency = utils.USD
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().GetHeldAmount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(int64(234), nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	type balance struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}
+
+	var gotAccount struct {
+		ID               int64   `json:"id"`
+		Balance          balance `json:"balance"`
+		LedgerBalance    balance `json:"ledger_balance"`
+		AvailableBalance balance `json:"available_balance"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &gotAccount)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, gotAccount.ID)
+	require.Equal(t, "12.34", gotAccount.Balance.Amount)
+	require.Equal(t, utils.USD, gotAccount.Balance.Currency)
+
+	// the funds reserved by the holds are part of the ledger balance only
+	require.Equal(t, "12.34", gotAccount.LedgerBalance.Amount)
+	require.Equal(t, "10.00", gotAccount.AvailableBalance.Amount)
+	require.Equal(t, utils.USD, gotAccount.AvailableBalance.Currency)
+}
+
 func TestCreateAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	account := randomAccount(uint(user.ID))
@@ -135,19 +186,84 @@ func TestCreateAccountAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					UserID:   account.UserID,
-					Currency: account.Currency,
-					Balance:  0,
+				arg := db.CreateAccountTxnParams{
+					CreateAccountParams: db.CreateAccountParams{
+						UserID:   account.UserID,
+						Currency: account.Currency,
+						Balance:  0,
+						Type:     utils.AccountTypeChecking,
+					},
+					MaxAccountsPerCurrency: 1,
 				}
 
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateAccountTxn(gomock.Any(), eqCreateAccountTxnParams(arg)).
 					Times(1).
 					Return(account, nil)
 			},
 			expectStatus: http.StatusCreated,
 		},
+		{
+			name: "Happy Case - All OK : Savings Account With Nickname Created",
+			body: gin.H{
+				"currency": account.Currency,
+				"type":     utils.AccountTypeSavings,
+				"nickname": "  Holidays ",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateAccountTxnParams{
+					CreateAccountParams: db.CreateAccountParams{
+						UserID:   account.UserID,
+						Currency: account.Currency,
+						Balance:  0,
+						Type:     utils.AccountTypeSavings,
+						Nickname: "Holidays",
+					},
+					MaxAccountsPerCurrency: 1,
+				}
+
+				store.EXPECT().
+					CreateAccountTxn(gomock.Any(), eqCreateAccountTxnParams(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			expectStatus: http.StatusCreated,
+		},
+		{
+			name: "Failure Case - Invalid Account Type",
+			body: gin.H{
+				"currency": account.Currency,
+				"type":     "brokerage",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccountTxn(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Account Limit Of The Currency Reached",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccountTxn(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrAccountLimitReached)
+			},
+			expectStatus: http.StatusForbidden,
+		},
 		{
 			name: "Failure Case - Invalid Currency Value",
 			body: gin.H{
@@ -158,7 +274,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTxn(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			expectStatus: http.StatusBadRequest,
@@ -173,7 +289,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTxn(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Account{}, sql.ErrConnDone)
 			},
@@ -221,8 +337,10 @@ func TestListAccountAPI(t *testing.T) {
 	}
 
 	type Query struct {
-		pageID   int
-		pageSize int
+		pageID      int
+		pageSize    int
+		currency    string
+		accountType string
 	}
 
 	testCases := []struct {
@@ -254,6 +372,49 @@ func TestListAccountAPI(t *testing.T) {
 			},
 			expectStatus: http.StatusOK,
 		},
+		{
+			name: "Happy Case - All OK : Filtered By Currency And Type",
+			query: Query{
+				pageID:      1,
+				pageSize:    n,
+				currency:    utils.USD,
+				accountType: utils.AccountTypeWallet,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Currency: sql.NullString{String: utils.USD, Valid: true},
+					Type:     sql.NullString{String: utils.AccountTypeWallet, Valid: true},
+					Limit:    int32(n),
+					Offset:   0,
+				}
+
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Invalid Type Filter",
+			query: Query{
+				pageID:      1,
+				pageSize:    n,
+				accountType: "brokerage",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
 		{
 			name: "Failure Case - Internal Server Error",
 			query: Query{
@@ -330,6 +491,12 @@ func TestListAccountAPI(t *testing.T) {
 			q := request.URL.Query()
 			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			if tc.query.currency != "" {
+				q.Add("currency", tc.query.currency)
+			}
+			if tc.query.accountType != "" {
+				q.Add("type", tc.query.accountType)
+			}
 			request.URL.RawQuery = q.Encode()
 
 			server.router.ServeHTTP(recorder, request)
@@ -338,12 +505,378 @@ func TestListAccountAPI(t *testing.T) {
 	}
 }
 
+func TestUpdateAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(uint(user.ID))
+
+	renamedAccount := account
+	renamedAccount.Nickname = "Rent"
+
+	testCases := []struct {
+		name         string
+		body         gin.H
+		setupAuth    func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - All OK : Nickname Updated",
+			body: gin.H{
+				"nickname": " Rent ",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountNicknameParams{
+					ID:       account.ID,
+					Nickname: "Rent",
+				}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Eq(arg)).Times(1).Return(renamedAccount, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Missing Nickname",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Account Of Another User",
+			body: gin.H{
+				"nickname": "Rent",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID+1), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d", account.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(uint(user.ID))
+	account.Currency = utils.USD
+
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 1050, CreatedAt: time.Now()},
+		{ID: 2, AccountID: account.ID, Amount: -300, CreatedAt: time.Now()},
+	}
+
+	type Query struct {
+		pageID   int
+		pageSize int
+	}
+
+	testCases := []struct {
+		name          string
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK : Entries Listed",
+			query: Query{
+				pageID:   2,
+				pageSize: 20,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListEntriesParams{
+					AccountID: account.ID,
+					Limit:     20,
+					Offset:    20,
+				}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response []struct {
+					ID     int64 `json:"id"`
+					Amount struct {
+						Amount   string `json:"amount"`
+						Currency string `json:"currency"`
+					} `json:"amount"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response, 2)
+				require.Equal(t, int64(1), response[0].ID)
+				require.Equal(t, "10.50", response[0].Amount.Amount)
+				require.Equal(t, utils.USD, response[0].Amount.Currency)
+				require.Equal(t, "-3.00", response[1].Amount.Amount)
+			},
+		},
+		{
+			name: "Failure Case - Invalid Page Size",
+			query: Query{
+				pageID:   1,
+				pageSize: 100,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Account Not Found",
+			query: Query{
+				pageID:   1,
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Account Of Another User",
+			query: Query{
+				pageID:   1,
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID+1), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Internal Server Error",
+			query: Query{
+				pageID:   1,
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries?page_id=%d&page_size=%d", account.ID, tc.query.pageID, tc.query.pageSize)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLookupAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+	user.FullName = "John Smith"
+	account := randomAccount(uint(user.ID))
+
+	testCases := []struct {
+		name          string
+		accountNumber string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:          "Happy Case - All OK : Masked Holder Name",
+			accountNumber: accountnumber.Format(account.AccountNumber),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got lookupAccountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, account.AccountNumber, got.AccountNumber)
+				require.Equal(t, account.Currency, got.Currency)
+				require.Equal(t, "J*** S****", got.HolderName)
+				require.NotContains(t, recorder.Body.String(), `"id"`)
+			},
+		},
+		{
+			name:          "Failure Case - Invalid Check Digits",
+			accountNumber: mistypedAccountNumber(account.AccountNumber),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:          "Failure Case - Account Not Found",
+			accountNumber: account.AccountNumber,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/account-numbers/%s", url.PathEscape(tc.accountNumber))
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(utils.RandomInt(1, 1000)), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+type eqCreateAccountTxnParamsMatcher struct {
+	arg db.CreateAccountTxnParams
+}
+
+// Matches : the account number is random, it only has to be valid
+func (e eqCreateAccountTxnParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateAccountTxnParams)
+	if !ok {
+		return false
+	}
+
+	if accountnumber.Validate(arg.AccountNumber) != nil {
+		return false
+	}
+
+	arg.AccountNumber = e.arg.AccountNumber
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqCreateAccountTxnParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v with a valid account number", e.arg)
+}
+
+func eqCreateAccountTxnParams(arg db.CreateAccountTxnParams) gomock.Matcher {
+	return eqCreateAccountTxnParamsMatcher{arg}
+}
+
+// mistypedAccountNumber : changes the last digit, mod-97 check digits catch every single digit error
+func mistypedAccountNumber(accountNumber string) string {
+	last := accountNumber[len(accountNumber)-1]
+	return accountNumber[:len(accountNumber)-1] + string('0'+(last-'0'+1)%10)
+}
+
 func randomAccount(userID uint) db.Account {
 	return db.Account{
-		ID:        utils.RandomInt(1, 1000),
-		UserID:    int64(userID),
-		Balance:   utils.RandomMoney(),
-		Currency:  utils.RandomCurrency(),
-		CreatedAt: time.Now(),
+		ID:            utils.RandomInt(1, 1000),
+		UserID:        int64(userID),
+		Balance:       utils.RandomMoney(),
+		Currency:      utils.RandomCurrency(),
+		Type:          utils.RandomAccountType(),
+		Nickname:      utils.RandomString(6),
+		CreatedAt:     time.Now(),
+		AccountNumber: randomAccountNumber(),
+	}
+}
+
+func randomAccountNumber() string {
+	accountNumber, err := accountnumber.Generate()
+	if err != nil {
+		panic(err)
 	}
+	return accountNumber
 }
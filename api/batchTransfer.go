@@ -0,0 +1,341 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/accountnumber"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+// modes of a batch transfer
+const (
+	batchModeAtomic     = "atomic"      // all the transfers succeed or none of them
+	batchModeBestEffort = "best_effort" // every transfer succeeds or fails on its own
+)
+
+const maxBatchReferenceLength = 140
+
+// the transfers of a batch are sent from a single account, the items are either in the JSON body or, for a
+// multipart form, in a CSV `file` with a `to_account_number,amount[,reference]` header
+type batchTransferRequest struct {
+	FromAccountID int64               `json:"from_account_id" form:"from_account_id" binding:"required,min=1"`
+	Currency      string              `json:"currency" form:"currency" binding:"required,currency"`
+	Mode          string              `json:"mode" form:"mode" binding:"required,oneof=atomic best_effort"`
+	OTPCode       string              `json:"otp_code" form:"otp_code" binding:"omitempty,len=6,numeric"` // required if the total is over the step up threshold
+	Items         []batchTransferItem `json:"items" form:"-"`
+}
+
+type batchTransferItem struct {
+	ToAccountNumber string `json:"to_account_number"`
+	Amount          string `json:"amount"`    // decimal string in the major unit of the currency, e.g. "12.50"
+	Reference       string `json:"reference"` // optional, echoed back in the result, e.g: an employee ID
+}
+
+type batchItemErrorResponse struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type batchTransferItemResponse struct {
+	Index     int               `json:"index"`
+	Reference string            `json:"reference"`
+	Status    string            `json:"status"` // succeeded or failed
+	Error     string            `json:"error,omitempty"`
+	Transfer  *transferResponse `json:"transfer,omitempty"`
+}
+
+type batchTransferResponse struct {
+	Mode      string                      `json:"mode"`
+	Total     money.Money                 `json:"total"` // gross amount of the succeeded transfers
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+	Results   []batchTransferItemResponse `json:"results"`
+}
+
+func (server *Server) createBatchTransfer(c *gin.Context) {
+	var req batchTransferRequest
+	err := c.ShouldBind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		req.Items, err = readBatchTransferCSV(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > config.BatchTransferMaxItems {
+		err := fmt.Errorf("a batch must contain between 1 and %d transfers", config.BatchTransferMaxItems)
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// every item is validated before any money moves, all the problems are reported at once
+	currency := server.currency(req.Currency)
+	amounts := make([]money.Money, len(req.Items))
+	accountNumbers := make([]string, len(req.Items))
+	itemErrors := make([]batchItemErrorResponse, 0)
+	addItemError := func(index int, err error) {
+		itemErrors = append(itemErrors, batchItemErrorResponse{Index: index, Error: err.Error()})
+	}
+
+	total := money.New(0, currency)
+	for i, item := range req.Items {
+		amounts[i], err = money.Parse(strings.TrimSpace(item.Amount), currency)
+		if err == nil && !amounts[i].IsPositive() {
+			err = errors.New("amount must be positive")
+		}
+		if err == nil {
+			total, err = total.Add(amounts[i])
+		}
+		if err != nil {
+			addItemError(i, err)
+			continue
+		}
+
+		accountNumbers[i] = accountnumber.Normalize(item.ToAccountNumber)
+		err = accountnumber.Validate(accountNumbers[i])
+		if err != nil {
+			addItemError(i, err)
+			continue
+		}
+
+		if len(item.Reference) > maxBatchReferenceLength {
+			addItemError(i, fmt.Errorf("reference must be at most %d characters", maxBatchReferenceLength))
+		}
+	}
+
+	if len(itemErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfers in the batch", "items": itemErrors})
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if config.RequireVerifiedEmailForTransfers {
+		err = server.requireVerifiedEmail(c, int64(authPayload.UserID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	fromAccount, err := server.validAccount(c, req.FromAccountID, req.Currency)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if fromAccount.UserID != int64(authPayload.UserID) {
+		err := errors.New("fromAccount does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// the recipients are looked up once per account number
+	recipients := make(map[string]db.Account)
+	transfers := make([]db.TransferTxnParams, len(req.Items))
+	var feeAccountID int64
+	for i := range req.Items {
+		toAccount, ok := recipients[accountNumbers[i]]
+		if !ok {
+			toAccount, err = server.store.GetAccountByNumber(c, accountNumbers[i])
+			if err != nil {
+				if err != sql.ErrNoRows {
+					c.JSON(http.StatusInternalServerError, errorResponse(err))
+					return
+				}
+				addItemError(i, fmt.Errorf("account %s not found", accountNumbers[i]))
+				continue
+			}
+			recipients[accountNumbers[i]] = toAccount
+		}
+
+		if toAccount.Currency != req.Currency {
+			addItemError(i, fmt.Errorf("account:%s currency mismatch. Account Currency:%s, got currency:%s", accountNumbers[i], toAccount.Currency, req.Currency))
+			continue
+		}
+
+		if toAccount.IsFrozen {
+			addItemError(i, fmt.Errorf("account %s is frozen", accountNumbers[i]))
+			continue
+		}
+
+		if toAccount.ID == fromAccount.ID {
+			addItemError(i, errors.New("can't transfer to the from account"))
+			continue
+		}
+
+		fee := server.fees.Fee(amounts[i])
+		if fee.Amount >= amounts[i].Amount {
+			addItemError(i, fmt.Errorf("amount %s doesn't cover the transfer fee of %s", amounts[i], fee))
+			continue
+		}
+
+		if fee.IsPositive() && feeAccountID == 0 {
+			feeAccountID, err = server.feeAccount(c, req.Currency)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+
+		transfers[i] = db.TransferTxnParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amounts[i].Amount,
+			Fee:           fee.Amount,
+		}
+		if fee.IsPositive() {
+			transfers[i].FeeAccountID = feeAccountID
+		}
+	}
+
+	if len(itemErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfers in the batch", "items": itemErrors})
+		return
+	}
+
+	// the whole batch has to be covered by the available balance, whichever the mode
+	err = server.coversAmount(c, fromAccount, total)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if server.currencies.RequiresStepUp(total) {
+		err = server.authorizeStepUp(c, fromAccount.UserID, req.OTPCode)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	result, err := server.store.BatchTransferTxn(c, db.BatchTransferTxnParams{
+		Transfers: transfers,
+		Atomic:    req.Mode == batchModeAtomic,
+	})
+	if err != nil {
+		var itemErr *db.BatchItemError
+		if errors.As(err, &itemErr) && errors.Is(itemErr.Err, db.ErrInsufficientFunds) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "the batch has been rolled back",
+				"items": []batchItemErrorResponse{{Index: itemErr.Index, Error: itemErr.Err.Error()}},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := batchTransferResponse{
+		Mode:    req.Mode,
+		Total:   money.New(0, currency),
+		Results: make([]batchTransferItemResponse, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		itemResponse := batchTransferItemResponse{
+			Index:     i,
+			Reference: item.Reference,
+		}
+
+		if result.Errors[i] != nil {
+			itemResponse.Status = "failed"
+			itemResponse.Error = result.Errors[i].Error()
+			response.Failed++
+		} else {
+			transfer := newTransferResponse(result.Results[i].Transfer, currency)
+			itemResponse.Status = "succeeded"
+			itemResponse.Transfer = &transfer
+			response.Total.Amount += transfer.Gross.Amount
+			response.Succeeded++
+		}
+
+		response.Results[i] = itemResponse
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// readBatchTransferCSV : reads the items of a batch from the uploaded CSV file, the
+// `to_account_number` and `amount` columns are required and `reference` is optional
+func readBatchTransferCSV(c *gin.Context) ([]batchTransferItem, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("the CSV file is required, err: %v", err)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CSV header, err: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"to_account_number", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV header must contain the %s column", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	items := make([]batchTransferItem, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CSV file, err: %v", err)
+		}
+
+		// stop early instead of reading an oversized file to the end
+		if len(items) == config.BatchTransferMaxItems {
+			return nil, fmt.Errorf("a batch must contain between 1 and %d transfers", config.BatchTransferMaxItems)
+		}
+
+		items = append(items, batchTransferItem{
+			ToAccountNumber: field(record, "to_account_number"),
+			Amount:          field(record, "amount"),
+			Reference:       field(record, "reference"),
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user1.ID = utils.RandomInt(1, 1000)
+	user2, _ := randomUser(t)
+	user2.ID = user1.ID + 1
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account3 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.USD
+	account1.Balance = 1000
+	account2.Currency = utils.USD
+	account3.Currency = utils.USD
+	account3.ID = account2.ID + 1
+
+	items := []gin.H{
+		{"to_account_number": account2.AccountNumber, "amount": "1.00", "reference": "emp-1"},
+		{"to_account_number": account3.AccountNumber, "amount": "2.50", "reference": "emp-2"},
+	}
+
+	transfers := []db.TransferTxnParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 100},
+		{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 250},
+	}
+
+	succeeded := db.BatchTransferTxnResult{
+		Results: []db.TransferTxnResult{
+			{Transfer: db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 100}},
+			{Transfer: db.Transfer{ID: 2, FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 250}},
+		},
+		Errors: []error{nil, nil},
+	}
+
+	lookupRecipients := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+		store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+		store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account3.AccountNumber)).Times(1).Return(account3, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - Atomic",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				lookupRecipients(store)
+				store.EXPECT().
+					BatchTransferTxn(gomock.Any(), gomock.Eq(db.BatchTransferTxnParams{Transfers: transfers, Atomic: true})).
+					Times(1).
+					Return(succeeded, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response batchTransferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, 2, response.Succeeded)
+				require.Zero(t, response.Failed)
+				require.Equal(t, "3.50", response.Total.Decimal())
+				require.Equal(t, "emp-2", response.Results[1].Reference)
+				require.Equal(t, "2.50", response.Results[1].Transfer.Net.Decimal())
+			},
+		},
+		{
+			name: "Happy Case - Best Effort With A Failed Transfer",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeBestEffort, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				lookupRecipients(store)
+				store.EXPECT().
+					BatchTransferTxn(gomock.Any(), gomock.Eq(db.BatchTransferTxnParams{Transfers: transfers, Atomic: false})).
+					Times(1).
+					Return(db.BatchTransferTxnResult{
+						Results: []db.TransferTxnResult{succeeded.Results[0], {}},
+						Errors:  []error{nil, db.ErrInsufficientFunds},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response batchTransferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, 1, response.Succeeded)
+				require.Equal(t, 1, response.Failed)
+				require.Equal(t, "1.00", response.Total.Decimal())
+				require.Equal(t, "failed", response.Results[1].Status)
+				require.Nil(t, response.Results[1].Transfer)
+			},
+		},
+		{
+			name: "Failure Case - Atomic Batch Rolled Back",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				lookupRecipients(store)
+				store.EXPECT().
+					BatchTransferTxn(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BatchTransferTxnResult{}, &db.BatchItemError{Index: 1, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBatchItemErrors(t, recorder, 1)
+			},
+		},
+		{
+			name: "Failure Case - Invalid Items Reported Upfront",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": []gin.H{
+				{"to_account_number": account2.AccountNumber, "amount": "0"},
+				{"to_account_number": account3.AccountNumber, "amount": "1.00"},
+				{"to_account_number": mistypedAccountNumber(account3.AccountNumber), "amount": "1.00"},
+			}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBatchItemErrors(t, recorder, 0, 2)
+			},
+		},
+		{
+			name: "Failure Case - Unknown Recipient",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account3.AccountNumber)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBatchItemErrors(t, recorder, 1)
+			},
+		},
+		{
+			name: "Failure Case - Total Over Available Balance",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeBestEffort, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				lookupRecipients(store)
+				store.EXPECT().GetHeldAmount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(int64(700), nil)
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Account Of Another User",
+			body: gin.H{"from_account_id": account2.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Unknown Mode",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": "eventually", "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Empty Batch",
+			body: gin.H{"from_account_id": account1.ID, "currency": utils.USD, "mode": batchModeAtomic, "items": []gin.H{}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestBatchTransferCSVAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user1.ID = utils.RandomInt(1, 1000)
+	user2, _ := randomUser(t)
+	user2.ID = user1.ID + 1
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.USD
+	account1.Balance = 1000
+	account2.Currency = utils.USD
+
+	testCases := []struct {
+		name         string
+		csv          string
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - Columns In Any Order",
+			csv:  "reference,amount,to_account_number\nemp-1,1.00," + account2.AccountNumber + "\n,\"2.00\"," + account2.AccountNumber + "\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				// the recipient is looked up once for the whole batch
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					BatchTransferTxn(gomock.Any(), gomock.Eq(db.BatchTransferTxnParams{
+						Transfers: []db.TransferTxnParams{
+							{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 100},
+							{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 200},
+						},
+						Atomic: true,
+					})).
+					Times(1).
+					Return(db.BatchTransferTxnResult{
+						Results: make([]db.TransferTxnResult, 2),
+						Errors:  make([]error, 2),
+					}, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Missing Column",
+			csv:  "to_account_number\n" + account2.AccountNumber + "\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Malformed CSV",
+			csv:  "to_account_number,amount\n\"" + account2.AccountNumber + ",1.00\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			require.NoError(t, writer.WriteField("from_account_id", strconv.FormatInt(account1.ID, 10)))
+			require.NoError(t, writer.WriteField("currency", utils.USD))
+			require.NoError(t, writer.WriteField("mode", batchModeAtomic))
+			file, err := writer.CreateFormFile("file", "payroll.csv")
+			require.NoError(t, err)
+			_, err = file.Write([]byte(tc.csv))
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", &body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", writer.FormDataContentType())
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+// requireBatchItemErrors : checks the indexes of the transfers reported as invalid
+func requireBatchItemErrors(t *testing.T, recorder *httptest.ResponseRecorder, indexes ...int) {
+	var response struct {
+		Items []batchItemErrorResponse `json:"items"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Items, len(indexes))
+	for i, index := range indexes {
+		require.Equal(t, index, response.Items[i].Index)
+		require.NotEmpty(t, response.Items[i].Error)
+	}
+}
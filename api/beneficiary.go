@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/skamranahmed/banking-system/accountnumber"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+type beneficiaryResponse struct {
+	ID            int64     `json:"id"`
+	Label         string    `json:"label"`
+	AccountNumber string    `json:"account_number"`
+	Currency      string    `json:"currency"`
+	HolderName    string    `json:"holder_name"` // masked
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newBeneficiaryResponse(beneficiary db.GetBeneficiaryRow) beneficiaryResponse {
+	return beneficiaryResponse{
+		ID:            beneficiary.ID,
+		Label:         beneficiary.Label,
+		AccountNumber: beneficiary.AccountNumber,
+		Currency:      beneficiary.Currency,
+		HolderName:    maskName(beneficiary.HolderName),
+		CreatedAt:     beneficiary.CreatedAt,
+	}
+}
+
+// the payee is addressed either by the account number or by the username and the currency
+type createBeneficiaryRequest struct {
+	Label         string `json:"label" binding:"required,max=64"`
+	AccountNumber string `json:"account_number" binding:"required_without=Username"`
+	Username      string `json:"username" binding:"required_without=AccountNumber,excluded_with=AccountNumber,omitempty,alphanum"`
+	Currency      string `json:"currency" binding:"required_with=Username,omitempty,currency"`
+}
+
+func (server *Server) createBeneficiary(c *gin.Context) {
+	var req createBeneficiaryRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var account db.Account
+	if req.Username != "" {
+		account, err = server.usernameAccount(c, req.Username, req.Currency)
+	} else {
+		account, err = server.accountByNumber(c, req.AccountNumber)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	beneficiary, err := server.store.CreateBeneficiary(c, db.CreateBeneficiaryParams{
+		UserID:    int64(authPayload.UserID),
+		Label:     strings.TrimSpace(req.Label),
+		AccountID: account.ID,
+	})
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				err := errors.New("a beneficiary with the same label or account already exists")
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	row, err := server.store.GetBeneficiary(c, beneficiary.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, newBeneficiaryResponse(row))
+	return
+}
+
+// accountByNumber : looks up the account without any currency restriction
+func (server *Server) accountByNumber(ctx context.Context, number string) (db.Account, error) {
+	accountNumber := accountnumber.Normalize(number)
+	err := accountnumber.Validate(accountNumber)
+	if err != nil {
+		return db.Account{}, newRequestError(http.StatusBadRequest, err)
+	}
+
+	account, err := server.store.GetAccountByNumber(ctx, accountNumber)
+	if err != nil {
+		return account, accountLookupError(err)
+	}
+
+	return account, nil
+}
+
+type getBeneficiaryRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ownBeneficiary : fetches the beneficiary and checks that it belongs to the authenticated user
+func (server *Server) ownBeneficiary(c *gin.Context) (db.GetBeneficiaryRow, bool) {
+	var req getBeneficiaryRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.GetBeneficiaryRow{}, false
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	beneficiary, err := server.store.GetBeneficiary(c, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+			return beneficiary, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return beneficiary, false
+	}
+
+	if authPayload.UserID != uint(beneficiary.UserID) {
+		err := errors.New("beneficiary does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return beneficiary, false
+	}
+
+	return beneficiary, true
+}
+
+func (server *Server) getBeneficiary(c *gin.Context) {
+	beneficiary, ok := server.ownBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newBeneficiaryResponse(beneficiary))
+	return
+}
+
+type listBeneficiariesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listBeneficiaries(c *gin.Context) {
+	var req listBeneficiariesRequest
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	beneficiaries, err := server.store.ListBeneficiaries(c, db.ListBeneficiariesParams{
+		UserID: int64(authPayload.UserID),
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]beneficiaryResponse, 0, len(beneficiaries))
+	for _, beneficiary := range beneficiaries {
+		response = append(response, newBeneficiaryResponse(db.GetBeneficiaryRow(beneficiary)))
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+type updateBeneficiaryRequest struct {
+	Label string `json:"label" binding:"required,max=64"`
+}
+
+func (server *Server) updateBeneficiary(c *gin.Context) {
+	var req updateBeneficiaryRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beneficiary, ok := server.ownBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	updated, err := server.store.UpdateBeneficiaryLabel(c, db.UpdateBeneficiaryLabelParams{
+		ID:    beneficiary.ID,
+		Label: strings.TrimSpace(req.Label),
+	})
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				err := errors.New("a beneficiary with the same label already exists")
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	beneficiary.Label = updated.Label
+	c.JSON(http.StatusOK, newBeneficiaryResponse(beneficiary))
+	return
+}
+
+func (server *Server) deleteBeneficiary(c *gin.Context) {
+	beneficiary, ok := server.ownBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	err := server.store.DeleteBeneficiary(c, beneficiary.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+	return
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBeneficiaryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	payee, _ := randomUser(t)
+	payee.ID = user.ID + 1
+	payee.FullName = "Jane Doe"
+
+	account := randomAccount(uint(payee.ID))
+	account.Currency = utils.INR
+	beneficiary := randomBeneficiary(user.ID, account, payee.FullName)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK : Addressed By Account Number",
+			body: gin.H{
+				"label":          beneficiary.Label,
+				"account_number": account.AccountNumber,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateBeneficiaryParams{
+					UserID:    user.ID,
+					Label:     beneficiary.Label,
+					AccountID: account.ID,
+				}
+
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Beneficiary{ID: beneficiary.ID}, nil)
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got beneficiaryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, beneficiary.ID, got.ID)
+				require.Equal(t, beneficiary.Label, got.Label)
+				require.Equal(t, account.AccountNumber, got.AccountNumber)
+				require.Equal(t, "J*** D**", got.HolderName)
+			},
+		},
+		{
+			name: "Happy Case - All OK : Addressed By Username",
+			body: gin.H{
+				"label":    beneficiary.Label,
+				"username": payee.Username,
+				"currency": utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				listArg := db.ListAccountsParams{
+					UserID:   payee.ID,
+					Currency: sql.NullString{String: utils.INR, Valid: true},
+					Type:     sql.NullString{String: utils.AccountTypeChecking, Valid: true},
+					Limit:    1,
+				}
+
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(payee.Username)).Times(1).Return(payee, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(listArg)).Times(1).Return([]db.Account{account}, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(1).Return(db.Beneficiary{ID: beneficiary.ID}, nil)
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Username Without Currency",
+			body: gin.H{
+				"label":    beneficiary.Label,
+				"username": payee.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Both Account Number And Username",
+			body: gin.H{
+				"label":          beneficiary.Label,
+				"account_number": account.AccountNumber,
+				"username":       payee.Username,
+				"currency":       utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Unknown Username",
+			body: gin.H{
+				"label":    beneficiary.Label,
+				"username": payee.Username,
+				"currency": utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(payee.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Duplicate Label",
+			body: gin.H{
+				"label":          beneficiary.Label,
+				"account_number": account.AccountNumber,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(1).Return(db.Beneficiary{}, &pq.Error{Code: "23505"})
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/beneficiaries", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestManageBeneficiaryAPI(t *testing.T) {
+	userID := utils.RandomInt(1, 1000)
+	account := randomAccount(uint(userID + 1))
+	beneficiary := randomBeneficiary(userID, account, utils.RandomName())
+
+	testCases := []struct {
+		name         string
+		method       string
+		body         gin.H
+		userID       int64
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name:   "Happy Case - Get",
+			method: http.MethodGet,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:   "Failure Case - Get Beneficiary Of Another User",
+			method: http.MethodGet,
+			userID: userID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Failure Case - Get Not Found",
+			method: http.MethodGet,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(db.GetBeneficiaryRow{}, sql.ErrNoRows)
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Happy Case - Update Label",
+			method: http.MethodPatch,
+			body:   gin.H{"label": "Landlord"},
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateBeneficiaryLabelParams{
+					ID:    beneficiary.ID,
+					Label: "Landlord",
+				}
+
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+				store.EXPECT().UpdateBeneficiaryLabel(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Beneficiary{ID: beneficiary.ID, Label: "Landlord"}, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:   "Failure Case - Update Without Label",
+			method: http.MethodPatch,
+			body:   gin.H{},
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateBeneficiaryLabel(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Happy Case - Delete",
+			method: http.MethodDelete,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(nil)
+			},
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Failure Case - Delete Beneficiary Of Another User",
+			method: http.MethodDelete,
+			userID: userID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body *bytes.Reader
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			} else {
+				body = bytes.NewReader(nil)
+			}
+
+			url := fmt.Sprintf("/beneficiaries/%d", beneficiary.ID)
+			request, err := http.NewRequest(tc.method, url, body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(tc.userID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestListBeneficiariesAPI(t *testing.T) {
+	userID := utils.RandomInt(1, 1000)
+
+	n := 5
+	beneficiaries := make([]db.ListBeneficiariesRow, n)
+	for i := 0; i < n; i++ {
+		beneficiary := randomBeneficiary(userID, randomAccount(uint(userID+1)), utils.RandomName())
+		beneficiaries[i] = db.ListBeneficiariesRow(beneficiary)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.ListBeneficiariesParams{
+		UserID: userID,
+		Limit:  int32(n),
+		Offset: 0,
+	}
+	store.EXPECT().ListBeneficiaries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(beneficiaries, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/beneficiaries?page_id=1&page_size=%d", n)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(userID), time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []beneficiaryResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Len(t, got, n)
+	for i, beneficiary := range got {
+		require.Equal(t, beneficiaries[i].ID, beneficiary.ID)
+		require.Equal(t, maskName(beneficiaries[i].HolderName), beneficiary.HolderName)
+	}
+}
+
+func randomBeneficiary(userID int64, account db.Account, holderName string) db.GetBeneficiaryRow {
+	return db.GetBeneficiaryRow{
+		ID:            utils.RandomInt(1, 1000),
+		CreatedAt:     time.Now(),
+		UserID:        userID,
+		Label:         utils.RandomName(),
+		AccountID:     account.ID,
+		AccountNumber: account.AccountNumber,
+		Currency:      account.Currency,
+		HolderName:    holderName,
+	}
+}
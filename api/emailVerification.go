@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/mail"
+	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/utils"
+)
+
+const (
+	emailVerificationSubject  = "Verify your email"
+	emailVerificationTemplate = "Hi %s,\n\nUse the link below to verify your email, it expires in %d minutes:\n%s"
+)
+
+var (
+	errInvalidVerificationLink = errors.New("invalid verification link")
+	errExpiredVerificationLink = errors.New("verification link has expired")
+	errEmailAlreadyVerified    = errors.New("email is already verified")
+)
+
+// emailVerificationMessage : returns the message signed in the verification links, the link is only valid
+// for the email it was sent to so that it stops working once the user changes the email
+func emailVerificationMessage(userID int64, email string, expires int64) string {
+	return fmt.Sprintf("email_verification:%d:%s:%d", userID, email, expires)
+}
+
+// sendVerificationEmail : emails a signed link to the user which verifies the current email of the user
+func (server *Server) sendVerificationEmail(ctx context.Context, user db.User) error {
+	expires := time.Now().Add(time.Minute * time.Duration(config.EmailVerificationLinkDuration)).Unix()
+	signature := utils.Sign([]byte(config.TokenSigningKey), emailVerificationMessage(user.ID, user.Email, expires))
+
+	query := url.Values{}
+	query.Set("user_id", strconv.FormatInt(user.ID, 10))
+	query.Set("email", user.Email)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signature)
+
+	verificationLink := fmt.Sprintf("%s/users/verify-email?%s", config.AppBaseURL, query.Encode())
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: emailVerificationSubject,
+		Body:    fmt.Sprintf(emailVerificationTemplate, user.FullName, config.EmailVerificationLinkDuration, verificationLink),
+	}
+
+	return server.mailer.Send(ctx, msg)
+}
+
+// resendVerificationEmail : emails a new verification link to the authenticated user
+func (server *Server) resendVerificationEmail(c *gin.Context) {
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(c, int64(authPayload.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no user found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		c.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	err = server.sendVerificationEmail(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, &messageResponse{Message: "verification link has been sent"})
+	return
+}
+
+type verifyEmailRequest struct {
+	UserID    int64  `form:"user_id" binding:"required,min=1"`
+	Email     string `form:"email" binding:"required,email"`
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required,hexadecimal"`
+}
+
+// verifyEmail : handles the verification link that was emailed to the user
+func (server *Server) verifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	message := emailVerificationMessage(req.UserID, req.Email, req.Expires)
+	if !utils.VerifySignature([]byte(config.TokenSigningKey), message, req.Signature) {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationLink))
+		return
+	}
+
+	if time.Now().Unix() > req.Expires {
+		c.JSON(http.StatusBadRequest, errorResponse(errExpiredVerificationLink))
+		return
+	}
+
+	arg := db.MarkUserEmailVerifiedParams{
+		ID:    req.UserID,
+		Email: req.Email,
+	}
+
+	// no rows are updated if the user has changed the email since the link was sent
+	rowsAffected, err := server.store.MarkUserEmailVerified(c, arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if rowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationLink))
+		return
+	}
+
+	c.JSON(http.StatusOK, &messageResponse{Message: "email has been verified"})
+	return
+}
+
+// requireVerifiedEmail : returns an error if the user hasn't verified the email
+func (server *Server) requireVerifiedEmail(ctx context.Context, userID int64) error {
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsEmailVerified {
+		err := errors.New("email must be verified for this operation")
+		return newRequestError(http.StatusForbidden, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/skamranahmed/banking-system/config"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	signedQuery := func(expires int64) url.Values {
+		query := url.Values{}
+		query.Set("user_id", strconv.FormatInt(user.ID, 10))
+		query.Set("email", user.Email)
+		query.Set("expires", strconv.FormatInt(expires, 10))
+		query.Set("signature", utils.Sign([]byte(config.TokenSigningKey), emailVerificationMessage(user.ID, user.Email, expires)))
+		return query
+	}
+
+	testCases := []struct {
+		name          string
+		query         func() url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK",
+			query: func() url.Values {
+				return signedQuery(time.Now().Add(time.Minute).Unix())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.MarkUserEmailVerifiedParams{ID: user.ID, Email: user.Email}
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Email Changed Since The Link Was Sent",
+			query: func() url.Values {
+				return signedQuery(time.Now().Add(time.Minute).Unix())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Expired Link",
+			query: func() url.Values {
+				return signedQuery(time.Now().Add(-time.Minute).Unix())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Tampered Email",
+			query: func() url.Values {
+				query := signedQuery(time.Now().Add(time.Minute).Unix())
+				query.Set("email", utils.RandomEmail())
+				return query
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Missing Signature",
+			query: func() url.Values {
+				query := signedQuery(time.Now().Add(time.Minute).Unix())
+				query.Del("signature")
+				return query
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Internal Server Error",
+			query: func() url.Values {
+				return signedQuery(time.Now().Add(time.Minute).Unix())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/users/verify-email?" + tc.query().Encode()
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestResendVerificationEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+
+	arg := db.MarkUserEmailVerifiedParams{ID: user.ID, Email: user.Email}
+	store.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+
+	server := newTestServer(t, store)
+	readEmails := useFileMailer(t, server)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/me/email/verify", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	emails := readEmails()
+	require.Len(t, emails, 1)
+	require.Contains(t, emails[0], "To: "+user.Email)
+
+	// the emailed link verifies the email
+	match := regexp.MustCompile(`/users/verify-email\?\S+`).FindString(emails[0])
+	require.NotEmpty(t, match)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, match, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestResendVerificationEmailAlreadyVerified(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+
+	server := newTestServer(t, store)
+	readEmails := useFileMailer(t, server)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/me/email/verify", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Empty(t, readEmails())
+}
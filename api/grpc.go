@@ -0,0 +1,398 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/pb"
+	"github.com/skamranahmed/banking-system/token"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcServer : serves the users, accounts and transfers over gRPC. Every call validates its request with the
+// binding tags of the route of the HTTP API which does the same and shares its logic, so both the APIs
+// return the same errors, the messages of the proto files mirror the JSON of the routes
+type grpcServer struct {
+	pb.UnimplementedUserServiceServer
+	pb.UnimplementedAccountServiceServer
+	pb.UnimplementedTransferServiceServer
+
+	server *Server
+}
+
+// newGRPCServer : returns a gRPC server with the services registered on top of the server
+func newGRPCServer(server *Server) *grpc.Server {
+	service := &grpcServer{server: server}
+
+	registrar := grpc.NewServer()
+	pb.RegisterUserServiceServer(registrar, service)
+	pb.RegisterAccountServiceServer(registrar, service)
+	pb.RegisterTransferServiceServer(registrar, service)
+
+	return registrar
+}
+
+// StartGRPC runs the gRPC server on the provided port, the HTTP server is started separately by Start
+func (server *Server) StartGRPC(port string) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		return err
+	}
+
+	return newGRPCServer(server).Serve(listener)
+}
+
+// grpcCode : maps the HTTP status of a route to the gRPC status code of the call
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcError : returns the status of a failed call, the code is mapped from the HTTP status of the error and
+// the violations of the password policy are attached as the details of the status
+func grpcError(ctx context.Context, err error) error {
+	reqErr := asRequestError(err)
+
+	if reqErr.retryAfter > 0 {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprintf("%d", reqErr.retryAfter)))
+	}
+
+	st := status.New(grpcCode(reqErr.status), reqErr.Error())
+	if len(reqErr.violations) == 0 {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range reqErr.violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: violation.Message,
+		})
+	}
+
+	detailed, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// authorize : verifies the access token of the `authorization` metadata of the call, like the auth middleware
+func (service *grpcServer) authorize(ctx context.Context) (*token.Payload, error) {
+	var authorizationHeader string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(authorizationHeaderKey); len(values) > 0 {
+		authorizationHeader = values[0]
+	}
+
+	return authorize(ctx, service.server.tokenMaker, service.server.store, authorizationHeader)
+}
+
+// allowLogin : applies the login rate limit per client IP, and per username if it isn't empty,
+// to the calls as well
+func (service *grpcServer) allowLogin(ctx context.Context, username string) error {
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+
+		err = allowRequest(service.server.loginIPLimiter, "ip:"+host)
+		if err != nil {
+			return err
+		}
+	}
+
+	if username == "" {
+		return nil
+	}
+	return allowRequest(service.server.loginUsernameLimiter, "username:"+strings.ToLower(username))
+}
+
+func newPBMoney(m money.Money) *pb.Money {
+	return &pb.Money{Amount: m.Decimal(), Currency: m.Currency.Code}
+}
+
+func newPBUser(user *userResponse) *pb.User {
+	if user == nil {
+		return nil
+	}
+
+	return &pb.User{
+		Username:        user.Username,
+		FullName:        user.FullName,
+		Email:           user.Email,
+		IsEmailVerified: user.IsEmailVerified,
+		IsTotpEnabled:   user.IsTotpEnabled,
+		CreatedAt:       timestamppb.New(user.CreatedAt),
+	}
+}
+
+func newPBLoginUserResponse(resp *loginUserResponse) *pb.LoginUserResponse {
+	return &pb.LoginUserResponse{
+		AccessToken:       resp.AccessToken,
+		User:              newPBUser(resp.User),
+		TwoFactorRequired: resp.TwoFactorRequired,
+		ChallengeToken:    resp.ChallengeToken,
+	}
+}
+
+func newPBAccount(account accountResponse) *pb.Account {
+	return &pb.Account{
+		Id:            account.ID,
+		UserId:        account.UserID,
+		Balance:       newPBMoney(account.Balance),
+		Currency:      account.Currency,
+		Type:          account.Type,
+		Nickname:      account.Nickname,
+		AccountNumber: account.AccountNumber,
+		CreatedAt:     timestamppb.New(account.CreatedAt),
+	}
+}
+
+func newPBEntry(entry entryResponse) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    newPBMoney(entry.Amount),
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func newPBCreateTransferResponse(resp transferTxnResponse) *pb.CreateTransferResponse {
+	return &pb.CreateTransferResponse{
+		Transfer: &pb.Transfer{
+			Id:            resp.Transfer.ID,
+			FromAccountId: resp.Transfer.FromAccountID,
+			ToAccountId:   resp.Transfer.ToAccountID,
+			Amount:        newPBMoney(resp.Transfer.Amount),
+			Gross:         newPBMoney(resp.Transfer.Gross),
+			Fee:           newPBMoney(resp.Transfer.Fee),
+			Net:           newPBMoney(resp.Transfer.Net),
+			CreatedAt:     timestamppb.New(resp.Transfer.CreatedAt),
+		},
+		FromAccount: newPBAccount(resp.FromAccount),
+		ToAccount:   newPBAccount(resp.ToAccount),
+		FromEntry:   newPBEntry(resp.FromEntry),
+		ToEntry:     newPBEntry(resp.ToEntry),
+	}
+}
+
+func (service *grpcServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	createReq := createUserRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		FullName: req.GetFullName(),
+		Email:    req.GetEmail(),
+	}
+
+	err := validateRequest(createReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.registerUser(ctx, createReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBUser(resp), nil
+}
+
+func (service *grpcServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	err := service.allowLogin(ctx, req.GetUsername())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	loginReq := loginUserRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	}
+
+	err = validateRequest(loginReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.login(ctx, loginReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBLoginUserResponse(resp), nil
+}
+
+func (service *grpcServer) LoginUserTOTP(ctx context.Context, req *pb.LoginUserTOTPRequest) (*pb.LoginUserResponse, error) {
+	err := service.allowLogin(ctx, "")
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	loginReq := loginUserTOTPRequest{
+		ChallengeToken: req.GetChallengeToken(),
+		Code:           req.GetCode(),
+		RecoveryCode:   req.GetRecoveryCode(),
+	}
+
+	err = validateRequest(loginReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.loginTOTP(ctx, loginReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBLoginUserResponse(resp), nil
+}
+
+func (service *grpcServer) GetCurrentUser(ctx context.Context, req *pb.GetCurrentUserRequest) (*pb.User, error) {
+	payload, err := service.authorize(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.currentUser(ctx, int64(payload.UserID))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBUser(resp), nil
+}
+
+func (service *grpcServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.Account, error) {
+	payload, err := service.authorize(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	createReq := createAccountRequest{
+		Currency: req.GetCurrency(),
+		Type:     req.GetType(),
+		Nickname: req.GetNickname(),
+	}
+
+	err = validateRequest(createReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.openAccount(ctx, int64(payload.UserID), createReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBAccount(resp), nil
+}
+
+func (service *grpcServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	payload, err := service.authorize(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	err = validateRequest(getAccountRequest{ID: req.GetId()})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.accountBalances(ctx, int64(payload.UserID), req.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	account := newPBAccount(resp.accountResponse)
+	account.LedgerBalance = newPBMoney(resp.LedgerBalance)
+	account.AvailableBalance = newPBMoney(resp.AvailableBalance)
+	return account, nil
+}
+
+func (service *grpcServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	payload, err := service.authorize(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	listReq := listAccountsRequest{
+		PageID:   req.GetPageId(),
+		PageSize: req.GetPageSize(),
+		Currency: req.GetCurrency(),
+		Type:     req.GetType(),
+	}
+
+	err = validateRequest(listReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	accounts, err := service.server.userAccounts(ctx, int64(payload.UserID), listReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, 0, len(accounts))}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, newPBAccount(account))
+	}
+	return resp, nil
+}
+
+func (service *grpcServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	payload, err := service.authorize(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	transferReq := transferRequest{
+		FromAccountID:   req.GetFromAccountId(),
+		ToAccountNumber: req.GetToAccountNumber(),
+		ToUsername:      req.GetToUsername(),
+		BeneficiaryID:   req.GetBeneficiaryId(),
+		Amount:          req.GetAmount(),
+		Currency:        req.GetCurrency(),
+		OTPCode:         req.GetOtpCode(),
+	}
+
+	err = validateRequest(transferReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp, err := service.server.transferMoney(ctx, int64(payload.UserID), transferReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newPBCreateTransferResponse(resp), nil
+}
@@ -0,0 +1,460 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/pb"
+	"github.com/skamranahmed/banking-system/ratelimit"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient : serves the gRPC API of the server over an in-memory connection and returns a client for it
+func newTestGRPCClient(t *testing.T, server *Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+
+	grpcServer := newGRPCServer(server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// withAuthorization : adds the bearer token of the user to the metadata of the calls made with the context
+func withAuthorization(t *testing.T, server *Server, userID uint) context.Context {
+	accessToken, _, err := server.tokenMaker.CreateToken(userID, time.Minute)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+}
+
+func TestGRPCGetAccount(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	account := randomAccount(uint(user.ID))
+	account.Currency = utils.USD
+	account.Balance = 10000
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		noAuth        bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, resp *pb.Account, err error)
+	}{
+		{
+			name:   "Happy Case - All OK",
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetHeldAmount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(int64(2500), nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.Account, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, resp.GetId())
+				require.Equal(t, account.UserID, resp.GetUserId())
+				require.Equal(t, account.AccountNumber, resp.GetAccountNumber())
+				require.Equal(t, "100.00", resp.GetBalance().GetAmount())
+				require.Equal(t, utils.USD, resp.GetBalance().GetCurrency())
+				require.Equal(t, "100.00", resp.GetLedgerBalance().GetAmount())
+				require.Equal(t, "75.00", resp.GetAvailableBalance().GetAmount())
+				require.WithinDuration(t, account.CreatedAt, resp.GetCreatedAt().AsTime(), time.Microsecond)
+			},
+		},
+		{
+			name:   "Failure Case - No Authorization",
+			noAuth: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.Account, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:   "Failure Case - Account Of Another User",
+			userID: user.ID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.Account, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.Equal(t, "account does not belong to the authenticated user", status.Convert(err).Message())
+			},
+		},
+		{
+			name:   "Failure Case - Not Found",
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, resp *pb.Account, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:   "Failure Case - Internal Error",
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, resp *pb.Account, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(newTestGRPCClient(t, server))
+
+			ctx := context.Background()
+			if !tc.noAuth {
+				ctx = withAuthorization(t, server, uint(tc.userID))
+			}
+
+			resp, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: account.ID})
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func TestGRPCListAccounts(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = utils.RandomInt(1, 1000)
+
+	accounts := []db.Account{randomAccount(uint(user.ID)), randomAccount(uint(user.ID))}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, resp *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "Happy Case - All OK",
+			req:  &pb.ListAccountsRequest{PageId: 2, PageSize: 5, Type: utils.AccountTypeChecking},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					UserID: user.ID,
+					Type:   sql.NullString{String: utils.AccountTypeChecking, Valid: true},
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.GetAccounts(), len(accounts))
+				for i, account := range resp.GetAccounts() {
+					require.Equal(t, accounts[i].ID, account.GetId())
+					require.Equal(t, accounts[i].Currency, account.GetCurrency())
+				}
+			},
+		},
+		{
+			name: "Failure Case - Invalid Page Size",
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(newTestGRPCClient(t, server))
+
+			resp, err := client.ListAccounts(withAuthorization(t, server, uint(user.ID)), tc.req)
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func TestGRPCCreateUser(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, resp *pb.User, err error)
+	}{
+		{
+			name: "Happy Case - All OK",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: "Zebra!Lamp42",
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, resp.GetUsername())
+				require.Equal(t, user.FullName, resp.GetFullName())
+				require.Equal(t, user.Email, resp.GetEmail())
+			},
+		},
+		{
+			name: "Failure Case - Invalid Email",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: "Zebra!Lamp42",
+				FullName: user.FullName,
+				Email:    "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.User, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Failure Case - Weak Password",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: "zebras",
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.User, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+				// the violations of the password policy are part of the status
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				badRequest, ok := details[0].(*errdetails.BadRequest)
+				require.True(t, ok)
+				require.NotEmpty(t, badRequest.GetFieldViolations())
+				require.Equal(t, "password", badRequest.GetFieldViolations()[0].GetField())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.passwordPolicy = utils.PasswordPolicy{MinLength: 8, RequireDigit: true}
+			useFileMailer(t, server)
+			client := pb.NewUserServiceClient(newTestGRPCClient(t, server))
+
+			resp, err := client.CreateUser(context.Background(), tc.req)
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func TestGRPCCreateTransfer(t *testing.T) {
+	amount := int64(100)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	user1.ID = utils.RandomInt(1, 1000)
+	user2.ID = user1.ID + 1
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.INR
+	account1.Balance = amount
+	account2.Currency = utils.INR
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, resp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "Happy Case - All OK",
+			req: &pb.CreateTransferRequest{
+				FromAccountId:   account1.ID,
+				ToAccountNumber: account2.AccountNumber,
+				Amount:          "1.00",
+				Currency:        utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxnParams{
+					FromAccountID:  account1.ID,
+					ToAccountID:    account2.ID,
+					Amount:         amount,
+					CheckAvailable: true,
+				}
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxnResult{
+					Transfer:    db.Transfer{ID: 7, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount, CreatedAt: time.Now()},
+					FromAccount: db.Account{ID: account1.ID, Currency: utils.INR},
+					ToAccount:   db.Account{ID: account2.ID, Currency: utils.INR, Balance: account2.Balance + amount},
+					FromEntry:   db.Entry{ID: 1, AccountID: account1.ID, Amount: -amount},
+					ToEntry:     db.Entry{ID: 2, AccountID: account2.ID, Amount: amount},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(7), resp.GetTransfer().GetId())
+				require.Equal(t, "1.00", resp.GetTransfer().GetNet().GetAmount())
+				require.Equal(t, "0.00", resp.GetTransfer().GetFee().GetAmount())
+				require.Equal(t, "-1.00", resp.GetFromEntry().GetAmount().GetAmount())
+				require.Equal(t, account2.ID, resp.GetToAccount().GetId())
+			},
+		},
+		{
+			name: "Failure Case - Ambiguous Recipient",
+			req: &pb.CreateTransferRequest{
+				FromAccountId:   account1.ID,
+				ToAccountNumber: account2.AccountNumber,
+				ToUsername:      user2.Username,
+				Amount:          "1.00",
+				Currency:        utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				require.Equal(t, errAmbiguousRecipient.Error(), status.Convert(err).Message())
+			},
+		},
+		{
+			name: "Failure Case - Insufficient Balance",
+			req: &pb.CreateTransferRequest{
+				FromAccountId:   account1.ID,
+				ToAccountNumber: account2.AccountNumber,
+				Amount:          "2.00",
+				Currency:        utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewTransferServiceClient(newTestGRPCClient(t, server))
+
+			resp, err := client.CreateTransfer(withAuthorization(t, server, uint(user1.ID)), tc.req)
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func TestGRPCLoginUserRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store)
+	limiter, err := ratelimit.NewMemoryLimiter(1, time.Minute)
+	require.NoError(t, err)
+	server.loginUsernameLimiter = limiter
+
+	client := pb.NewUserServiceClient(newTestGRPCClient(t, server))
+	req := &pb.LoginUserRequest{Username: "alice", Password: "secret123"}
+
+	_, err = client.LoginUser(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// the second attempt of the username is rejected before the store is queried
+	var header metadata.MD
+	_, err = client.LoginUser(context.Background(), req, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NotEmpty(t, header.Get("retry-after"))
+}
+
+func TestGRPCCode(t *testing.T) {
+	testCases := []struct {
+		httpStatus int
+		code       codes.Code
+	}{
+		{httpStatus: 400, code: codes.InvalidArgument},
+		{httpStatus: 401, code: codes.Unauthenticated},
+		{httpStatus: 403, code: codes.PermissionDenied},
+		{httpStatus: 404, code: codes.NotFound},
+		{httpStatus: 409, code: codes.AlreadyExists},
+		{httpStatus: 429, code: codes.ResourceExhausted},
+		{httpStatus: 500, code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.code, grpcCode(tc.httpStatus), "status %d", tc.httpStatus)
+	}
+}
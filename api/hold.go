@@ -0,0 +1,337 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/accountnumber"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+// a hold reserves the funds of a card-like payment on the account, the money only moves once the hold is captured
+type createHoldRequest struct {
+	FromAccountID    int64  `json:"from_account_id" binding:"required,min=1"` // the account on which the funds are reserved
+	ToAccountNumber  string `json:"to_account_number" binding:"required"`     // the account credited on capture
+	Amount           string `json:"amount" binding:"required"`                // decimal string in the major unit of the currency, e.g. "12.50"
+	Currency         string `json:"currency" binding:"required,currency"`
+	ExpiresInMinutes int    `json:"expires_in_minutes" binding:"omitempty,min=1"` // defaults to HOLD_DEFAULT_EXPIRY_MINUTES
+	OTPCode          string `json:"otp_code" binding:"omitempty,len=6,numeric"`   // required for the holds over the step up threshold
+}
+
+type holdResponse struct {
+	ID             int64       `json:"id"`
+	AccountID      int64       `json:"account_id"`
+	ToAccountID    int64       `json:"to_account_id"`
+	Amount         money.Money `json:"amount"`
+	CapturedAmount money.Money `json:"captured_amount"`
+	Status         string      `json:"status"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+	TransferID     *int64      `json:"transfer_id,omitempty"` // the transfer of the capture
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// newHoldResponse : the hold shares the currency of its account, an active hold past its expiry
+// is reported as expired even before it has been swept
+func newHoldResponse(hold db.Hold, currency money.Currency) holdResponse {
+	status := hold.Status
+	if status == db.HoldStatusActive && !hold.ExpiresAt.After(time.Now()) {
+		status = db.HoldStatusExpired
+	}
+
+	var transferID *int64
+	if hold.TransferID.Valid {
+		transferID = &hold.TransferID.Int64
+	}
+
+	return holdResponse{
+		ID:             hold.ID,
+		AccountID:      hold.AccountID,
+		ToAccountID:    hold.ToAccountID,
+		Amount:         money.New(hold.Amount, currency),
+		CapturedAmount: money.New(hold.CapturedAmount, currency),
+		Status:         status,
+		ExpiresAt:      hold.ExpiresAt,
+		TransferID:     transferID,
+		CreatedAt:      hold.CreatedAt,
+		UpdatedAt:      hold.UpdatedAt,
+	}
+}
+
+func (server *Server) createHold(c *gin.Context) {
+	var req createHoldRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the currency has already been validated against the registry
+	amount, err := money.Parse(req.Amount, server.currency(req.Currency))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, errorResponse(errors.New("amount must be positive")))
+		return
+	}
+
+	expiresIn := req.ExpiresInMinutes
+	if expiresIn == 0 {
+		expiresIn = config.HoldDefaultExpiryMinutes
+	}
+
+	if expiresIn > config.HoldMaxExpiryMinutes {
+		err := fmt.Errorf("a hold can't be created for more than %d minutes", config.HoldMaxExpiryMinutes)
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	toAccountNumber := accountnumber.Normalize(req.ToAccountNumber)
+	err = accountnumber.Validate(toAccountNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if config.RequireVerifiedEmailForTransfers {
+		err = server.requireVerifiedEmail(c, int64(authPayload.UserID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	fromAccount, err := server.validAccount(c, req.FromAccountID, req.Currency)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if fromAccount.UserID != int64(authPayload.UserID) {
+		err := errors.New("fromAccount does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	toAccount, err := server.validAccountNumber(c, toAccountNumber, req.Currency)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// a hold commits the funds to a transfer, so it is authorized like one
+	if server.currencies.RequiresStepUp(amount) {
+		err = server.authorizeStepUp(c, fromAccount.UserID, req.OTPCode)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	hold, err := server.store.CreateHoldTxn(c, db.CreateHoldParams{
+		AccountID:   fromAccount.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      amount.Amount,
+		ExpiresAt:   time.Now().Add(time.Minute * time.Duration(expiresIn)),
+	})
+	if err != nil {
+		if err == db.ErrInsufficientFunds {
+			err := fmt.Errorf("accountID: %d, insufficient available balance", fromAccount.ID)
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, newHoldResponse(hold, amount.Currency))
+	return
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// lookupHold : looks up the hold from the uri along with the account it is on and the account credited
+// on capture, responds on its own and returns false if the hold can't be found
+func (server *Server) lookupHold(c *gin.Context) (db.Hold, db.Account, db.Account, bool) {
+	var req getHoldRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Hold{}, db.Account{}, db.Account{}, false
+	}
+
+	hold, err := server.store.GetHold(c, req.ID)
+	if err != nil {
+		handleAccountLookupError(c, err)
+		return hold, db.Account{}, db.Account{}, false
+	}
+
+	account, err := server.store.GetAccount(c, hold.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, account, db.Account{}, false
+	}
+
+	toAccount, err := server.store.GetAccount(c, hold.ToAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, account, toAccount, false
+	}
+
+	return hold, account, toAccount, true
+}
+
+// payeeHold : same as lookupHold, the hold must be payable to an account of the authenticated user.
+// The payee settles or cancels the hold, the payer can't take back the funds it has committed
+func (server *Server) payeeHold(c *gin.Context) (db.Hold, db.Account, db.Account, bool) {
+	hold, account, toAccount, ok := server.lookupHold(c)
+	if !ok {
+		return hold, account, toAccount, false
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if authPayload.UserID != uint(toAccount.UserID) {
+		err := errors.New("hold is not payable to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, account, toAccount, false
+	}
+
+	return hold, account, toAccount, true
+}
+
+func (server *Server) getHold(c *gin.Context) {
+	hold, account, toAccount, ok := server.lookupHold(c)
+	if !ok {
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// both the payer and the payee can see the hold
+	if authPayload.UserID != uint(account.UserID) && authPayload.UserID != uint(toAccount.UserID) {
+		err := errors.New("hold does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newHoldResponse(hold, server.currency(account.Currency)))
+	return
+}
+
+type captureHoldRequest struct {
+	Amount string `json:"amount"` // decimal string, defaults to the full amount of the hold
+}
+
+type captureHoldResponse struct {
+	Hold     holdResponse        `json:"hold"`
+	Transfer transferTxnResponse `json:"transfer"`
+}
+
+func (server *Server) captureHold(c *gin.Context) {
+	// the body is optional
+	var req captureHoldRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, account, toAccount, ok := server.payeeHold(c)
+	if !ok {
+		return
+	}
+
+	if account.IsFrozen || toAccount.IsFrozen {
+		c.JSON(http.StatusForbidden, errorResponse(errAccountFrozen))
+		return
+	}
+
+	currency := server.currency(account.Currency)
+	amount := money.New(hold.Amount, currency)
+	if req.Amount != "" {
+		amount, err = money.Parse(req.Amount, currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	if !amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, errorResponse(errors.New("amount must be positive")))
+		return
+	}
+
+	if amount.Amount > hold.Amount {
+		c.JSON(http.StatusBadRequest, errorResponse(db.ErrCaptureExceedsHold))
+		return
+	}
+
+	fee, feeAccountID, err := server.transferFee(c, amount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result, err := server.store.CaptureHoldTxn(c, db.CaptureHoldTxnParams{
+		HoldID:       hold.ID,
+		Amount:       amount.Amount,
+		Fee:          fee.Amount,
+		FeeAccountID: feeAccountID,
+	})
+	if err != nil {
+		handleHoldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, captureHoldResponse{
+		Hold:     newHoldResponse(result.Hold, currency),
+		Transfer: server.newTransferTxnResponse(result.Transfer, currency),
+	})
+	return
+}
+
+func (server *Server) releaseHold(c *gin.Context) {
+	hold, account, _, ok := server.payeeHold(c)
+	if !ok {
+		return
+	}
+
+	hold, err := server.store.ReleaseHoldTxn(c, hold.ID)
+	if err != nil {
+		handleHoldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newHoldResponse(hold, server.currency(account.Currency)))
+	return
+}
+
+// handleHoldError : maps the errors of the capture and the release of a hold
+func handleHoldError(c *gin.Context, err error) {
+	switch err {
+	case db.ErrHoldNotActive:
+		c.JSON(http.StatusConflict, errorResponse(err))
+	case db.ErrCaptureExceedsHold, db.ErrFeeExceedsAmount:
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	default:
+		handleAccountLookupError(c, err)
+	}
+}
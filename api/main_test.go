@@ -1,28 +1,101 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/skamranahmed/banking-system/config"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/mail"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/stream"
 	"github.com/stretchr/testify/require"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	// load config
-	config.Load("../config")
+	config.LoadForTests("../config")
 
-	server, err := NewServer(store)
+	// the auth middleware looks up when the password was changed for every authenticated request,
+	// the tokens are never revoked unless the test stubs this call before creating the server
+	mockStore, ok := store.(*mockdb.MockStore)
+	if ok {
+		mockStore.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+
+		// no funds are held either, unless stubbed by the test
+		mockStore.EXPECT().
+			GetHeldAmount(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+	}
+
+	server, err := NewServer(store, stream.NewHub())
 	require.NoError(t, err)
 
 	return server
 }
 
+// useFileMailer : makes the server write the emails to a temporary directory and returns a function
+// which reads the emails sent so far
+func useFileMailer(t *testing.T, server *Server) func() []string {
+	dir := t.TempDir()
+
+	mailer, err := mail.NewFileSender("bank@example.com", dir)
+	require.NoError(t, err)
+	server.mailer = mailer
+
+	return func() []string {
+		server.pendingEmails.Wait()
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+
+		emails := make([]string, 0, len(files))
+		for _, file := range files {
+			content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+			require.NoError(t, err)
+			emails = append(emails, string(content))
+		}
+		return emails
+	}
+}
+
+// failingMailer : fails to send every email
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	return errors.New("mail server unavailable")
+}
+
 func TestMain(m *testing.M) {
 	// run gin in TestMode for tests for cleaner stdout response
 	gin.SetMode(gin.TestMode)
 
 	os.Exit(m.Run())
 }
+
+// useStepUpThreshold : makes the transfers of the currency require a one time password from the decimal threshold
+func useStepUpThreshold(t *testing.T, server *Server, code string, threshold string) {
+	currencies := make([]money.Currency, 0)
+	for _, c := range server.currencies.Codes() {
+		currency, _ := server.currencies.Lookup(c)
+		if c == code {
+			currency.StepUpThreshold = threshold
+		}
+		currencies = append(currencies, currency)
+	}
+
+	registry, err := money.NewRegistry(currencies)
+	require.NoError(t, err)
+	server.currencies = registry
+}
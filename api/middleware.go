@@ -1,11 +1,19 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"io"
+	"math"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/ratelimit"
 	"github.com/skamranahmed/banking-system/token"
 )
 
@@ -15,37 +23,135 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// errRevokedToken : is returned for the tokens issued before the password of the user was changed
+var errRevokedToken = errors.New("token has been revoked")
+
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authorizationHeader := c.GetHeader(authorizationHeaderKey)
-		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is required")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		payload, err := authorize(c, tokenMaker, store, c.GetHeader(authorizationHeaderKey))
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
-			return
+		c.Set(authorizationPayloadKey, payload)
+		c.Next()
+	}
+}
+
+// authorize : verifies the access token of the authorization header, shared by the auth middleware
+// and the gRPC API
+func authorize(ctx context.Context, tokenMaker token.Maker, store db.Store, authorizationHeader string) (*token.Payload, error) {
+	if len(authorizationHeader) == 0 {
+		err := errors.New("authorization header is required")
+		return nil, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		err := errors.New("invalid authorization header format")
+		return nil, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		err := errors.New("incorrect authorization type")
+		return nil, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	accessToken := fields[1]
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	// tokens issued for other purposes, e.g: the login challenge, can't be used for authorization
+	if payload.Scope != token.ScopeAccess {
+		return nil, newRequestError(http.StatusUnauthorized, token.ErrInvalidToken)
+	}
+
+	// changing the password revokes all the tokens issued before it
+	passwordChangedAt, err := store.GetUserPasswordChangedAt(ctx, int64(payload.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newRequestError(http.StatusUnauthorized, token.ErrInvalidToken)
 		}
+		return nil, err
+	}
+
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return nil, newRequestError(http.StatusUnauthorized, errRevokedToken)
+	}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != authorizationTypeBearer {
-			err := errors.New("incorrect authorization type")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+	return payload, nil
+}
+
+// rateLimitMiddleware : rejects the request once the key returned by keyFunc exceeds the limit,
+// requests for which no key could be derived are let through
+func rateLimitMiddleware(limiter ratelimit.Limiter, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
 			return
 		}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		err := allowRequest(limiter, key)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			respondError(c, err)
 			return
 		}
 
-		c.Set(authorizationPayloadKey, payload)
 		c.Next()
 	}
 }
+
+// allowRequest : returns a too many requests error once the key exceeds the limit, shared by the rate limit
+// middleware and the gRPC API
+func allowRequest(limiter ratelimit.Limiter, key string) error {
+	allowed, retryAfter := limiter.Allow(key)
+	if !allowed {
+		return &requestError{
+			status:     http.StatusTooManyRequests,
+			err:        errors.New("too many requests, try again later"),
+			retryAfter: int(math.Ceil(retryAfter.Seconds())),
+		}
+	}
+	return nil
+}
+
+// clientIPKey : limits the requests per client IP
+func clientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// maxUsernameKeyBodySize : the login body only holds the credentials, the middleware runs before the
+// authentication so a larger body isn't read
+const maxUsernameKeyBodySize = 4 << 10
+
+// usernameKey : limits the requests per username present in the JSON body,
+// the body is restored so that the handler can still bind it
+func usernameKey(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxUsernameKeyBodySize))
+	if err != nil {
+		// the handler fails to bind the empty body
+		c.Request.Body = http.NoBody
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Username string `json:"username"`
+	}
+
+	err = json.Unmarshal(body, &req)
+	if err != nil || req.Username == "" {
+		return ""
+	}
+
+	return "username:" + strings.ToLower(req.Username)
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/skamranahmed/banking-system/money"
+)
+
+// currency : returns the registered currency of the code, the accounts of a currency which
+// has since been removed from the registry are still served with two minor units
+func (server *Server) currency(code string) money.Currency {
+	currency, ok := server.currencies.Lookup(code)
+	if !ok {
+		return money.Currency{Code: code, MinorUnits: 2}
+	}
+	return currency
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec : the OpenAPI 3 document of the users, accounts and transfers routes, it is checked
+// against the routes and the binding tags of the requests by the tests
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// getOpenAPISpec : serves the OpenAPI document, the clients can generate their request types from it
+func (server *Server) getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
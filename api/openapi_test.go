@@ -0,0 +1,436 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/stretchr/testify/require"
+)
+
+// the parts of the OpenAPI document checked by the tests
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string             `json:"operationId"`
+	Parameters  []openAPIParameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]openAPIMediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]openAPIMediaType `json:"content"`
+	} `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Format     string                    `json:"format"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Required   []string                  `json:"required"`
+	Items      *openAPISchema            `json:"items"`
+	Enum       []string                  `json:"enum"`
+	OneOf      []*openAPISchema          `json:"oneOf"`
+	Minimum    *float64                  `json:"minimum"`
+	Maximum    *float64                  `json:"maximum"`
+	MinLength  *int                      `json:"minLength"`
+	MaxLength  *int                      `json:"maxLength"`
+}
+
+// documentedPrefixes : the routes described by the OpenAPI document
+var documentedPrefixes = []string{"/users", "/accounts", "/transfers"}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+func loadOpenAPIDocument(t *testing.T) *openAPIDocument {
+	var doc openAPIDocument
+	err := json.Unmarshal(openAPISpec, &doc)
+	require.NoError(t, err)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	return &doc
+}
+
+// resolve : follows the reference of the schema to the components
+func (doc *openAPIDocument) resolve(t *testing.T, schema *openAPISchema) *openAPISchema {
+	require.NotNil(t, schema)
+	if schema.Ref == "" {
+		return schema
+	}
+
+	resolved, ok := doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	require.True(t, ok, "unknown schema %s", schema.Ref)
+	return resolved
+}
+
+func (doc *openAPIDocument) operation(t *testing.T, operationID string) openAPIOperation {
+	for _, methods := range doc.Paths {
+		for _, operation := range methods {
+			if operation.OperationID == operationID {
+				return operation
+			}
+		}
+	}
+
+	require.Failf(t, "operation not documented", "operationId: %s", operationID)
+	return openAPIOperation{}
+}
+
+// bindingRules : parses the binding tag of the field, e.g: `required,min=6` -> {required: "", min: "6"}
+func bindingRules(field reflect.StructField) map[string]string {
+	rules := make(map[string]string)
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		if name != "" {
+			rules[name] = value
+		}
+	}
+	return rules
+}
+
+// tagName : returns the name of the field in the tag, empty if the field isn't part of it
+func tagName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// taggedFields : returns the fields of the struct having the tag, the fields of the embedded structs included
+func taggedFields(typ reflect.Type, tag string) []reflect.StructField {
+	fields := make([]reflect.StructField, 0)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, taggedFields(field.Type, tag)...)
+			continue
+		}
+
+		if tagName(field, tag) != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func indirect(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
+
+// requireSchemaMatchesType : checks that the schema has a property of the matching type for every JSON field of the type
+func requireSchemaMatchesType(t *testing.T, doc *openAPIDocument, schema *openAPISchema, typ reflect.Type, name string) {
+	typ = indirect(typ)
+	if typ == reflect.TypeOf(money.Money{}) {
+		require.NotNil(t, schema, name)
+		require.Equal(t, "#/components/schemas/Money", schema.Ref, name)
+		return
+	}
+	schema = doc.resolve(t, schema)
+
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		require.Equal(t, "string", schema.Type, name)
+		require.Equal(t, "date-time", schema.Format, name)
+	case typ.Kind() == reflect.Struct:
+		require.Equal(t, "object", schema.Type, name)
+
+		fields := taggedFields(typ, "json")
+		properties := make([]string, 0, len(fields))
+		for _, field := range fields {
+			property := tagName(field, "json")
+			properties = append(properties, property)
+
+			propertySchema, ok := schema.Properties[property]
+			require.True(t, ok, "%s.%s is not documented", name, property)
+			requireSchemaMatchesType(t, doc, propertySchema, field.Type, name+"."+property)
+		}
+		require.ElementsMatch(t, properties, keys(schema.Properties), name)
+	case typ.Kind() == reflect.Slice:
+		require.Equal(t, "array", schema.Type, name)
+		requireSchemaMatchesType(t, doc, schema.Items, typ.Elem(), name+"[]")
+	case typ.Kind() == reflect.String:
+		require.Equal(t, "string", schema.Type, name)
+	case typ.Kind() == reflect.Bool:
+		require.Equal(t, "boolean", schema.Type, name)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		require.Equal(t, "integer", schema.Type, name)
+	default:
+		require.Failf(t, "unexpected field type", "%s: %s", name, typ)
+	}
+}
+
+// requireSchemaMatchesBinding : checks the required properties and the constraints of the schema against the binding tags
+func requireSchemaMatchesBinding(t *testing.T, doc *openAPIDocument, schema *openAPISchema, typ reflect.Type, name string) {
+	schema = doc.resolve(t, schema)
+
+	required := make([]string, 0)
+	for _, field := range taggedFields(typ, "json") {
+		property := tagName(field, "json")
+		rules := bindingRules(field)
+		if _, ok := rules["required"]; ok {
+			required = append(required, property)
+		}
+		requireConstraints(t, doc.resolve(t, schema.Properties[property]), field, name+"."+property)
+	}
+	require.ElementsMatch(t, required, schema.Required, "%s required", name)
+}
+
+// requireParametersMatchBinding : checks the query and the path parameters against the form and the uri tags
+func requireParametersMatchBinding(t *testing.T, parameters []openAPIParameter, typ reflect.Type, tag string, in string) {
+	fields := taggedFields(typ, tag)
+	for _, field := range fields {
+		name := tagName(field, tag)
+
+		var parameter *openAPIParameter
+		for i := range parameters {
+			if parameters[i].Name == name && parameters[i].In == in {
+				parameter = &parameters[i]
+			}
+		}
+		require.NotNil(t, parameter, "%s parameter %s is not documented", in, name)
+
+		_, required := bindingRules(field)["required"]
+		require.Equal(t, required, parameter.Required, "%s parameter %s required", in, name)
+		requireConstraints(t, parameter.Schema, field, name)
+	}
+
+	documented := 0
+	for _, parameter := range parameters {
+		if parameter.In == in {
+			documented++
+		}
+	}
+	require.Equal(t, len(fields), documented, "%s parameters", in)
+}
+
+// requireConstraints : checks the min, max, oneof and email rules of the field against the schema
+func requireConstraints(t *testing.T, schema *openAPISchema, field reflect.StructField, name string) {
+	require.NotNil(t, schema, name)
+	isString := indirect(field.Type).Kind() == reflect.String
+
+	for rule, value := range bindingRules(field) {
+		switch rule {
+		case "min", "max":
+			limit, err := strconv.Atoi(value)
+			require.NoError(t, err)
+
+			var documented interface{}
+			switch {
+			case isString && rule == "min":
+				documented = schema.MinLength
+			case isString && rule == "max":
+				documented = schema.MaxLength
+			case rule == "min":
+				documented = schema.Minimum
+			default:
+				documented = schema.Maximum
+			}
+
+			require.NotNil(t, documented, "%s %s", name, rule)
+			require.EqualValues(t, limit, reflect.ValueOf(documented).Elem().Interface(), "%s %s", name, rule)
+		case "oneof":
+			require.ElementsMatch(t, strings.Fields(value), schema.Enum, "%s enum", name)
+		case "email":
+			require.Equal(t, "email", schema.Format, "%s format", name)
+		}
+	}
+}
+
+func keys(properties map[string]*openAPISchema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	doc := loadOpenAPIDocument(t)
+
+	documented := make([]string, 0)
+	for path, methods := range doc.Paths {
+		for method := range methods {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	routes := make([]string, 0)
+	for _, route := range server.router.Routes() {
+		for _, prefix := range documentedPrefixes {
+			if strings.HasPrefix(route.Path, prefix) {
+				routes = append(routes, route.Method+" "+ginPathParam.ReplaceAllString(route.Path, "{$1}"))
+				break
+			}
+		}
+	}
+
+	require.ElementsMatch(t, routes, documented)
+}
+
+func TestOpenAPIRequests(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	testCases := []struct {
+		operationID string
+		body        interface{} // bound from the JSON body
+		query       interface{} // bound from the query string
+		uri         interface{} // bound from the path
+	}{
+		{operationID: "createUser", body: createUserRequest{}},
+		{operationID: "loginUser", body: loginUserRequest{}},
+		{operationID: "loginUserTOTP", body: loginUserTOTPRequest{}},
+		{operationID: "forgotPassword", body: forgotPasswordRequest{}},
+		{operationID: "resetPassword", body: resetPasswordRequest{}},
+		{operationID: "verifyEmail", query: verifyEmailRequest{}},
+		{operationID: "getCurrentUser"},
+		{operationID: "updateCurrentUser", body: updateUserRequest{}},
+		{operationID: "changePassword", body: changePasswordRequest{}},
+		{operationID: "resendVerificationEmail"},
+		{operationID: "enrollTOTP"},
+		{operationID: "verifyTOTP", body: verifyTOTPRequest{}},
+		{operationID: "createAccount", body: createAccountRequest{}},
+		{operationID: "listAccounts", query: listAccountsRequest{}},
+		{operationID: "getAccount", uri: getAccountRequest{}},
+		{operationID: "updateAccount", body: updateAccountRequest{}, uri: getAccountRequest{}},
+		{operationID: "listAccountEntries", query: listAccountEntriesRequest{}, uri: getAccountRequest{}},
+		{operationID: "streamAccountEvents", uri: getAccountRequest{}},
+		{operationID: "createTransfer", body: transferRequest{}},
+		{operationID: "createBatchTransfer", body: batchTransferRequest{}},
+	}
+
+	operations := 0
+	for _, methods := range doc.Paths {
+		operations += len(methods)
+	}
+	require.Len(t, testCases, operations, "every documented operation is checked")
+
+	for _, tc := range testCases {
+		t.Run(tc.operationID, func(t *testing.T) {
+			operation := doc.operation(t, tc.operationID)
+
+			if tc.body == nil {
+				require.Nil(t, operation.RequestBody)
+			} else {
+				require.NotNil(t, operation.RequestBody)
+				schema := operation.RequestBody.Content["application/json"].Schema
+				typ := reflect.TypeOf(tc.body)
+				requireSchemaMatchesType(t, doc, schema, typ, tc.operationID)
+				requireSchemaMatchesBinding(t, doc, schema, typ, tc.operationID)
+			}
+
+			var queryType, uriType reflect.Type = reflect.TypeOf(struct{}{}), reflect.TypeOf(struct{}{})
+			if tc.query != nil {
+				queryType = reflect.TypeOf(tc.query)
+			}
+			if tc.uri != nil {
+				uriType = reflect.TypeOf(tc.uri)
+			}
+			requireParametersMatchBinding(t, operation.Parameters, queryType, "form", "query")
+			requireParametersMatchBinding(t, operation.Parameters, uriType, "uri", "path")
+		})
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	testCases := []struct {
+		operationID string
+		status      int
+		response    interface{}
+	}{
+		{operationID: "createUser", status: http.StatusCreated, response: userResponse{}},
+		{operationID: "createUser", status: http.StatusBadRequest, response: passwordPolicyErrorResponse{}},
+		{operationID: "loginUser", status: http.StatusOK, response: loginUserResponse{}},
+		{operationID: "loginUserTOTP", status: http.StatusOK, response: loginUserResponse{}},
+		{operationID: "forgotPassword", status: http.StatusAccepted, response: messageResponse{}},
+		{operationID: "resetPassword", status: http.StatusOK, response: messageResponse{}},
+		{operationID: "verifyEmail", status: http.StatusOK, response: messageResponse{}},
+		{operationID: "getCurrentUser", status: http.StatusOK, response: userResponse{}},
+		{operationID: "updateCurrentUser", status: http.StatusOK, response: userResponse{}},
+		{operationID: "changePassword", status: http.StatusOK, response: loginUserResponse{}},
+		{operationID: "resendVerificationEmail", status: http.StatusAccepted, response: messageResponse{}},
+		{operationID: "enrollTOTP", status: http.StatusOK, response: enrollTOTPResponse{}},
+		{operationID: "verifyTOTP", status: http.StatusOK, response: verifyTOTPResponse{}},
+		{operationID: "createAccount", status: http.StatusCreated, response: accountResponse{}},
+		{operationID: "listAccounts", status: http.StatusOK, response: []accountResponse{}},
+		{operationID: "getAccount", status: http.StatusOK, response: getAccountResponse{}},
+		{operationID: "updateAccount", status: http.StatusOK, response: accountResponse{}},
+		{operationID: "listAccountEntries", status: http.StatusOK, response: []entryResponse{}},
+		{operationID: "createTransfer", status: http.StatusOK, response: transferTxnResponse{}},
+		{operationID: "createBatchTransfer", status: http.StatusOK, response: batchTransferResponse{}},
+		{operationID: "createBatchTransfer", status: http.StatusBadRequest, response: struct {
+			Error string                   `json:"error"`
+			Items []batchItemErrorResponse `json:"items"`
+		}{}},
+	}
+
+	for _, tc := range testCases {
+		name := tc.operationID + " " + strconv.Itoa(tc.status)
+		t.Run(name, func(t *testing.T) {
+			response, ok := doc.operation(t, tc.operationID).Responses[strconv.Itoa(tc.status)]
+			require.True(t, ok, "response %d is not documented", tc.status)
+
+			schema := response.Content["application/json"].Schema
+			require.NotNil(t, schema)
+
+			// the error responses of the password policy are one of the documented schemas
+			if len(schema.OneOf) > 0 {
+				schema = schema.OneOf[len(schema.OneOf)-1]
+			}
+			requireSchemaMatchesType(t, doc, schema, reflect.TypeOf(tc.response), name)
+		})
+	}
+}
+
+func TestGetOpenAPISpecAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+
+	// the document is public, it doesn't need an access token
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+	require.JSONEq(t, string(openAPISpec), recorder.Body.String())
+}
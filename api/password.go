@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/mail"
+	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/utils"
+)
+
+const (
+	passwordResetEmailTimeout = 30 * time.Second
+
+	passwordResetSubject  = "Reset your password"
+	passwordResetTemplate = "Hi %s,\n\nUse the link below to reset your password, it expires in %d minutes:\n%s\n\nIf you didn't request a password reset, you can ignore this email."
+)
+
+var (
+	errIncorrectPassword = errors.New("incorrect password")
+	errInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type passwordPolicyErrorResponse struct {
+	Error      string                    `json:"error"`
+	Violations []utils.PasswordViolation `json:"violations"`
+}
+
+// validateNewPassword : checks the password against the policy, the returned error holds the violations.
+// The userInputs are the username, email etc. which the password must not resemble
+func (server *Server) validateNewPassword(password string, userInputs ...string) error {
+	violations := server.passwordPolicy.Validate(password, userInputs...)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &requestError{
+		status:     http.StatusBadRequest,
+		err:        errors.New("password does not meet the password policy"),
+		violations: violations,
+	}
+}
+
+// checkCurrentPassword : returns the user if the password is the current one. The wrong passwords count
+// towards the lockout, so that a leaked token can't be used to guess the password
+func (server *Server) checkCurrentPassword(ctx context.Context, userID int64, password string) (db.User, error) {
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return user, newRequestError(http.StatusNotFound, errors.New("no user found"))
+		}
+		return user, err
+	}
+
+	err = utils.CheckPassword(password, user.Password)
+
+	if user.LockedUntil.After(time.Now()) {
+		return user, newRequestError(http.StatusUnauthorized, errIncorrectPassword)
+	}
+
+	if err != nil {
+		err = server.recordFailedLogin(ctx, user.ID)
+		if err != nil {
+			return user, err
+		}
+		return user, newRequestError(http.StatusUnauthorized, errIncorrectPassword)
+	}
+
+	return user, nil
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,min=6,nefield=OldPassword"`
+}
+
+// changePassword : changes the password of the authenticated user, the previously issued tokens
+// get revoked so a new access token is returned
+func (server *Server) changePassword(c *gin.Context) {
+	var req changePasswordRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.checkCurrentPassword(c, int64(authPayload.UserID), req.OldPassword)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	err = server.validateNewPassword(req.NewPassword, user.Username, user.Email)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.ChangePasswordTxnParams{
+		UserID:            user.ID,
+		Password:          hashedPassword,
+		PasswordChangedAt: passwordChangedAt(),
+	}
+
+	user, err = server.store.ChangePasswordTxn(c, arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.completeLogin(c, user)
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword : emails a password reset link to the user, the response is the same
+// whether the email belongs to a user or not
+func (server *Server) forgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	resp := &messageResponse{
+		Message: "if the email belongs to a user, a password reset link has been sent to it",
+	}
+
+	user, err := server.store.GetUserByEmail(c, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusAccepted, resp)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the link is created and sent after the response, so that neither the time it takes nor its failures
+	// reveal whether the email belongs to a user
+	server.pendingEmails.Add(1)
+	go func() {
+		defer server.pendingEmails.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetEmailTimeout)
+		defer cancel()
+
+		err := server.sendPasswordResetEmail(ctx, user)
+		if err != nil {
+			log.Printf("unable to send the password reset email to user %d, err: %v", user.ID, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, resp)
+	return
+}
+
+// sendPasswordResetEmail : emails the user a link with a new reset token
+func (server *Server) sendPasswordResetEmail(ctx context.Context, user db.User) error {
+	resetToken, err := token.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	// only the hash is stored, so that a leaked database can't be used to reset the passwords
+	arg := db.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: token.HashOpaqueToken(resetToken),
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(config.PasswordResetTokenDuration)),
+	}
+
+	_, err = server.store.CreatePasswordResetToken(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", config.AppBaseURL, resetToken)
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: passwordResetSubject,
+		Body:    fmt.Sprintf(passwordResetTemplate, user.FullName, config.PasswordResetTokenDuration, resetLink),
+	}
+
+	return server.mailer.Send(ctx, msg)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// resetPassword : sets a new password using the token from the reset link, the token can only be used once
+func (server *Server) resetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the user is only known once the token is used, so the password can't be compared with the user info
+	err = server.validateNewPassword(req.NewPassword)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.ResetPasswordTxnParams{
+		TokenHash:         token.HashOpaqueToken(req.Token),
+		Password:          hashedPassword,
+		PasswordChangedAt: passwordChangedAt(),
+	}
+
+	_, err = server.store.ResetPasswordTxn(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &messageResponse{Message: "password has been reset"})
+	return
+}
+
+// passwordChangedAt : returns the current time truncated to the precision of postgres,
+// so that the tokens issued right after the change aren't considered older than it
+func passwordChangedAt() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
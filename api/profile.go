@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
+)
+
+// getCurrentUser : returns the profile of the authenticated user
+func (server *Server) getCurrentUser(c *gin.Context) {
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	response, err := server.currentUser(c, int64(authPayload.UserID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// currentUser : returns the profile of the user, shared by the HTTP and the gRPC APIs
+func (server *Server) currentUser(ctx context.Context, userID int64) (*userResponse, error) {
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newRequestError(http.StatusNotFound, errors.New("no user found"))
+		}
+		return nil, err
+	}
+
+	return newUserResponse(user), nil
+}
+
+type updateUserRequest struct {
+	FullName        *string `json:"full_name" binding:"omitempty,min=1"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	CurrentPassword string  `json:"current_password"` // required to change the email
+}
+
+// updateCurrentUser : updates the profile of the authenticated user, a changed email has to be verified again.
+// The password reset links are sent to the email, so changing it requires the current password as well
+func (server *Server) updateCurrentUser(c *gin.Context) {
+	var req updateUserRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FullName == nil && req.Email == nil {
+		err := errors.New("at least one of full_name or email is required")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.Email != nil {
+		if req.CurrentPassword == "" {
+			err := errors.New("current_password is required to change the email")
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		_, err = server.checkCurrentPassword(c, int64(authPayload.UserID), req.CurrentPassword)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	arg := db.UpdateUserParams{
+		ID: int64(authPayload.UserID),
+	}
+
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+
+	if req.Email != nil {
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+	}
+
+	user, err := server.store.UpdateUser(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no user found")))
+			return
+		}
+
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// a changed email is unverified again, so a new verification link is sent to it
+	if req.Email != nil && !user.IsEmailVerified {
+		err = server.sendVerificationEmail(c, user)
+		if err != nil {
+			// the user can ask for a new link, so the update itself doesn't fail
+			log.Printf("unable to send the verification email to user %d, err: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+	return
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/skamranahmed/banking-system/utils"
+)
+
+// requestError : a failed request along with the HTTP status it is responded with. It is returned by the
+// logic shared by the HTTP and the gRPC APIs, which map it to a response of their own
+type requestError struct {
+	status     int
+	err        error
+	violations []utils.PasswordViolation // of the password policy, if the new password was rejected
+	retryAfter int                       // seconds to wait before retrying a rate limited request
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// newRequestError : returns the error responded with the status
+func newRequestError(status int, err error) error {
+	return &requestError{status: status, err: err}
+}
+
+// asRequestError : returns the error as a requestError, any other error is an internal server error
+func asRequestError(err error) *requestError {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return reqErr
+	}
+	return &requestError{status: http.StatusInternalServerError, err: err}
+}
+
+// respondError : responds with the status and the body of the error, the request is aborted so that
+// the middlewares can respond with it as well
+func respondError(c *gin.Context, err error) {
+	reqErr := asRequestError(err)
+
+	if reqErr.retryAfter > 0 {
+		c.Header("Retry-After", fmt.Sprintf("%d", reqErr.retryAfter))
+	}
+
+	if len(reqErr.violations) > 0 {
+		c.AbortWithStatusJSON(reqErr.status, &passwordPolicyErrorResponse{
+			Error:      reqErr.Error(),
+			Violations: reqErr.violations,
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(reqErr.status, errorResponse(reqErr))
+}
+
+// validateRequest : checks the binding tags of a request which hasn't been bound by gin, e.g: of a gRPC call
+func validateRequest(req interface{}) error {
+	err := binding.Validator.ValidateStruct(req)
+	if err != nil {
+		return newRequestError(http.StatusBadRequest, err)
+	}
+	return nil
+}
+
+// accountLookupError : a missing record is not found, any other error is internal
+func accountLookupError(err error) error {
+	if err == sql.ErrNoRows {
+		return newRequestError(http.StatusNotFound, errors.New("no record found"))
+	}
+	return err
+}
@@ -2,13 +2,21 @@ package api
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/skamranahmed/banking-system/config"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/fee"
+	"github.com/skamranahmed/banking-system/mail"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/ratelimit"
+	"github.com/skamranahmed/banking-system/stream"
 	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/utils"
 )
 
 // Server : will serve the HTTP requests for our API
@@ -16,46 +24,183 @@ type Server struct {
 	store      db.Store
 	tokenMaker token.Maker
 	router     *gin.Engine
+	mailer     mail.Sender
+	currencies *money.Registry
+	fees       *fee.Schedule
+
+	// the committed changes of the accounts, streamed to the clients
+	accountEvents *stream.Hub
+
+	// hashes the new passwords, the hashes of other algorithms or costs get upgraded on login
+	passwordHasher         utils.PasswordHasher
+	passwordPolicy         utils.PasswordPolicy
+	dummyPasswordHashOnce  sync.Once
+	dummyPasswordHashValue string
+
+	// limiters for the login attempts
+	loginIPLimiter       ratelimit.Limiter
+	loginUsernameLimiter ratelimit.Limiter
+
+	// the emails sent after the response, e.g: the password reset links
+	pendingEmails sync.WaitGroup
 }
 
 // NewServer : will create a new Server and also setup the routes
-func NewServer(store db.Store) (*Server, error) {
+func NewServer(store db.Store, accountEvents *stream.Hub) (*Server, error) {
 	tokenMaker, err := token.NewJWTMaker(config.TokenSigningKey)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialise token maker, err: %v", err)
 	}
 
+	loginRateLimitWindow := time.Minute * time.Duration(config.LoginRateLimitWindow)
+	loginIPLimiter, err := ratelimit.NewMemoryLimiter(config.LoginRateLimitPerIP, loginRateLimitWindow)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise login ip limiter, err: %v", err)
+	}
+
+	loginUsernameLimiter, err := ratelimit.NewMemoryLimiter(config.LoginRateLimitPerUsername, loginRateLimitWindow)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise login username limiter, err: %v", err)
+	}
+
+	mailer, err := mail.NewSender(config.MailSender, config.MailFrom, config.MailFileDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise mail sender, err: %v", err)
+	}
+
+	currencies, err := money.LoadRegistry(config.CurrenciesFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise currency registry, err: %v", err)
+	}
+
+	fees, err := fee.LoadSchedule(config.FeeScheduleFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise fee schedule, err: %v", err)
+	}
+
+	argon2idParams := utils.Argon2idParams{
+		Memory:      uint32(config.Argon2Memory),
+		Iterations:  uint32(config.Argon2Iterations),
+		Parallelism: uint8(config.Argon2Parallelism),
+	}
+
+	passwordHasher, err := utils.NewPasswordHasher(config.PasswordHashAlgorithm, config.BcryptCost, argon2idParams)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise password hasher, err: %v", err)
+	}
+
+	passwordPolicy := utils.PasswordPolicy{
+		MinLength:        config.PasswordMinLength,
+		MaxLength:        config.PasswordMaxLength,
+		RequireUppercase: config.PasswordRequireUppercase,
+		RequireLowercase: config.PasswordRequireLowercase,
+		RequireDigit:     config.PasswordRequireDigit,
+		RequireSymbol:    config.PasswordRequireSymbol,
+		DisallowUserInfo: config.PasswordDisallowUserInfo,
+	}
+
+	// the characters over the bytes bcrypt hashes would be ignored
+	if config.PasswordHashAlgorithm == utils.PasswordHashBcrypt {
+		passwordPolicy.MaxBytes = utils.BcryptMaxPasswordBytes
+	}
+
+	if config.BreachedPasswordsFile != "" {
+		passwordPolicy.Breached, err = utils.LoadBreachedPasswordList(config.BreachedPasswordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialise password policy, err: %v", err)
+		}
+	}
+
 	server := &Server{
-		store:      store,
-		tokenMaker: tokenMaker,
+		store:                store,
+		tokenMaker:           tokenMaker,
+		mailer:               mailer,
+		currencies:           currencies,
+		fees:                 fees,
+		accountEvents:        accountEvents,
+		passwordHasher:       passwordHasher,
+		passwordPolicy:       passwordPolicy,
+		loginIPLimiter:       loginIPLimiter,
+		loginUsernameLimiter: loginUsernameLimiter,
 	}
 
 	// get the binding engine that gin is using
 	v, ok := binding.Validator.Engine().(*validator.Validate)
 	if ok {
-		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("currency", validCurrency(currencies))
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterValidation("event_type", validEventType)
+	}
+
+	err = server.setupRouter()
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise router, err: %v", err)
 	}
 
-	server.setupRouter()
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	// gin router
 	router := gin.Default()
 
+	// the client IP of the rate limits is the address of the peer, unless the peer is a trusted proxy,
+	// otherwise any client could pick its own with X-Forwarded-For
+	err := router.SetTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
 	// setup routes
 	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	router.POST(
+		"/users/login",
+		rateLimitMiddleware(server.loginIPLimiter, clientIPKey),
+		rateLimitMiddleware(server.loginUsernameLimiter, usernameKey),
+		server.loginUser,
+	)
+	router.POST("/users/login/2fa", rateLimitMiddleware(server.loginIPLimiter, clientIPKey), server.loginUserTOTP)
+	router.POST("/users/password/forgot", rateLimitMiddleware(server.loginIPLimiter, clientIPKey), server.forgotPassword)
+	router.POST("/users/password/reset", rateLimitMiddleware(server.loginIPLimiter, clientIPKey), server.resetPassword)
+	router.GET("/users/verify-email", server.verifyEmail)
+	router.GET("/openapi.json", server.getOpenAPISpec)
 
 	// authenticated routes
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+	authRoutes.GET("/users/me", server.getCurrentUser)
+	authRoutes.PATCH("/users/me", server.updateCurrentUser)
+	authRoutes.PATCH("/users/me/password", server.changePassword)
+	authRoutes.POST("/users/me/email/verify", server.resendVerificationEmail)
+	authRoutes.POST("/users/me/totp", server.enrollTOTP)
+	authRoutes.POST("/users/me/totp/verify", server.verifyTOTP)
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts", server.listAccounts)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.PATCH("/accounts/:id", server.updateAccount)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
+	authRoutes.GET("/account-numbers/:account_number", server.lookupAccount)
+	authRoutes.POST("/beneficiaries", server.createBeneficiary)
+	authRoutes.GET("/beneficiaries", server.listBeneficiaries)
+	authRoutes.GET("/beneficiaries/:id", server.getBeneficiary)
+	authRoutes.PATCH("/beneficiaries/:id", server.updateBeneficiary)
+	authRoutes.DELETE("/beneficiaries/:id", server.deleteBeneficiary)
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+	authRoutes.POST("/holds", server.createHold)
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/release", server.releaseHold)
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.GET("/webhooks", server.listWebhooks)
+	authRoutes.GET("/webhooks/:id", server.getWebhook)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+	authRoutes.POST("/webhooks/:id/replay", server.replayDeadWebhookDeliveries)
+	authRoutes.POST("/webhooks/:id/deliveries/:delivery_id/replay", server.replayWebhookDelivery)
 
 	server.router = router
+	return nil
 }
 
 // Start runs the HTTP server on the provided port
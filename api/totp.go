@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/totp"
+)
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"` // can be rendered as a QR code for the authenticator apps
+}
+
+func (server *Server) enrollTOTP(c *gin.Context) {
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(c, int64(authPayload.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no user found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		err := errors.New("two factor authentication is already enabled")
+		c.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: secret,
+	}
+
+	user, err = server.store.SetUserTOTPSecret(c, arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resp := &enrollTOTPResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(config.TOTPIssuer, user.Username, secret),
+	}
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+type verifyTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type verifyTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // only shown once, each one can be used instead of a one time password
+}
+
+func (server *Server) verifyTOTP(c *gin.Context) {
+	var req verifyTOTPRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(c, int64(authPayload.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no user found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		err := errors.New("two factor authentication is already enabled")
+		c.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	if user.TotpSecret == "" {
+		err := errors.New("two factor authentication enrollment has not been started")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	step, err := totp.Validate(req.Code, user.TotpSecret, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recoveryCodes, err := totp.GenerateRecoveryCodes(totp.RecoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, totp.HashRecoveryCode(code))
+	}
+
+	arg := db.EnableTOTPTxnParams{
+		UserID:             user.ID,
+		Step:               step,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	}
+
+	_, err = server.store.EnableTOTPTxn(c, arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &verifyTOTPResponse{RecoveryCodes: recoveryCodes})
+	return
+}
+
+type loginUserTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// loginUserTOTP : second step of the login for the users with two factor authentication,
+// exchanges the challenge token returned by loginUser and a one time password for an access token
+func (server *Server) loginUserTOTP(c *gin.Context) {
+	var req loginUserTOTPRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	response, err := server.loginTOTP(c, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// loginTOTP : checks the challenge token and the one time password of a validated request,
+// shared by the HTTP and the gRPC APIs
+func (server *Server) loginTOTP(ctx context.Context, req loginUserTOTPRequest) (*loginUserResponse, error) {
+	payload, err := server.tokenMaker.VerifyToken(req.ChallengeToken)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	if payload.Scope != token.ScopeLoginChallenge {
+		return nil, newRequestError(http.StatusUnauthorized, token.ErrInvalidToken)
+	}
+
+	user, err := server.store.GetUser(ctx, int64(payload.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newRequestError(http.StatusUnauthorized, token.ErrInvalidToken)
+		}
+		return nil, err
+	}
+
+	// changing the password revokes the challenges issued before it, like the access tokens
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		return nil, newRequestError(http.StatusUnauthorized, errRevokedToken)
+	}
+
+	if user.LockedUntil.After(time.Now()) {
+		return nil, newRequestError(http.StatusUnauthorized, errInvalidCredentials)
+	}
+
+	// the user may have been disabled since the challenge was issued
+	if user.IsDisabled {
+		return nil, newRequestError(http.StatusForbidden, errUserDisabled)
+	}
+
+	if req.Code != "" {
+		err = server.checkOneTimePassword(ctx, user, req.Code)
+	} else {
+		err = server.useRecoveryCode(ctx, user, req.RecoveryCode)
+	}
+
+	if err != nil {
+		if err != totp.ErrInvalidCode {
+			return nil, err
+		}
+
+		// the failed codes count towards the lockout as well, so that they can't be brute forced
+		err = server.recordFailedLogin(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newRequestError(http.StatusUnauthorized, totp.ErrInvalidCode)
+	}
+
+	return server.issueAccessToken(ctx, user)
+}
+
+// checkOneTimePassword : validates the totp code of the user and marks its time step as used,
+// so that every code can be used only once
+func (server *Server) checkOneTimePassword(ctx context.Context, user db.User, code string) error {
+	step, err := totp.Validate(code, user.TotpSecret, time.Now())
+	if err != nil {
+		return totp.ErrInvalidCode
+	}
+
+	if step <= user.TotpLastUsedStep {
+		return totp.ErrInvalidCode
+	}
+
+	arg := db.UpdateUserTOTPLastUsedStepParams{
+		ID:   user.ID,
+		Step: step,
+	}
+
+	// no rows are updated if the same code has been used concurrently
+	rowsAffected, err := server.store.UpdateUserTOTPLastUsedStep(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return totp.ErrInvalidCode
+	}
+	return nil
+}
+
+// useRecoveryCode : marks the unused recovery code of the user as used
+func (server *Server) useRecoveryCode(ctx context.Context, user db.User, code string) error {
+	arg := db.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: totp.HashRecoveryCode(code),
+	}
+
+	rowsAffected, err := server.store.UseRecoveryCode(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return totp.ErrInvalidCode
+	}
+	return nil
+}
+
+// authorizeStepUp : requires a valid one time password from the user, it returns an error
+// if the request should not proceed
+func (server *Server) authorizeStepUp(ctx context.Context, userID int64, code string) error {
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTotpEnabled {
+		err := errors.New("two factor authentication must be enabled for this operation")
+		return newRequestError(http.StatusForbidden, err)
+	}
+
+	if code == "" {
+		err := errors.New("one time password is required for this operation")
+		return newRequestError(http.StatusForbidden, err)
+	}
+
+	// the lockout of the logins applies as well, a stolen access token mustn't allow guessing the code
+	if user.LockedUntil.After(time.Now()) {
+		return newRequestError(http.StatusUnauthorized, errStepUpLocked)
+	}
+
+	err = server.checkOneTimePassword(ctx, user, code)
+	if err != nil {
+		if err != totp.ErrInvalidCode {
+			return err
+		}
+
+		// the failed codes count towards the lockout, so that they can't be brute forced
+		err = server.recordFailedLogin(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		return newRequestError(http.StatusUnauthorized, totp.ErrInvalidCode)
+	}
+
+	return nil
+}
+
+var errStepUpLocked = errors.New("too many failed attempts, try again later")
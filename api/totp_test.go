@@ -0,0 +1,422 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/totp"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	enabledUser := user
+	enabledUser.IsTotpEnabled = true
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SetUserTOTPSecretParams) (db.User, error) {
+						enrolledUser := user
+						enrolledUser.TotpSecret = arg.TotpSecret
+						return enrolledUser, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.Secret)
+				require.Contains(t, resp.OTPAuthURI, resp.Secret)
+			},
+		},
+		{
+			name: "Failure Case - Already Enabled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(enabledUser, nil)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - No Authorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/users/me/totp"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			// add authorization header to the request
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestVerifyTOTPAPI(t *testing.T) {
+	user, secret := randomTOTPUser(t)
+	user.IsTotpEnabled = false
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().
+					EnableTOTPTxn(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.EnableTOTPTxnParams) (db.User, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, totp.Step(time.Now()), arg.Step)
+						require.Len(t, arg.RecoveryCodeHashes, totp.RecoveryCodeCount)
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp verifyTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.Len(t, resp.RecoveryCodes, totp.RecoveryCodeCount)
+			},
+		},
+		{
+			name: "Failure Case - Incorrect Code",
+			body: gin.H{"code": incorrectCode(code)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().EnableTOTPTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Enrollment Not Started",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				notEnrolledUser := user
+				notEnrolledUser.TotpSecret = ""
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(notEnrolledUser, nil)
+				store.EXPECT().EnableTOTPTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Invalid Code Format",
+			body: gin.H{"code": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/me/totp/verify"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserTOTPAPI(t *testing.T) {
+	user, secret := randomTOTPUser(t)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		body         func(challengeToken string) gin.H
+		scope        string
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - One Time Password",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Happy Case - Recovery Code",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "recovery_code": "abcde-fghjk"}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UseRecoveryCodeParams{
+					UserID:   user.ID,
+					CodeHash: totp.HashRecoveryCode("abcde-fghjk"),
+				}
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Replayed Code",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				usedUser := user
+				usedUser.TotpLastUsedStep = totp.Step(time.Now())
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(usedUser, nil)
+				store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(usedUser, nil)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Incorrect Code",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": incorrectCode(code)}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Password Changed After The Challenge",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				changedUser := user
+				changedUser.PasswordChangedAt = time.Now().Add(time.Minute)
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(changedUser, nil)
+				store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IncrementFailedLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Access Token Used As Challenge",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			scope: token.ScopeAccess,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Missing Code",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Internal Server Error",
+			body: func(challengeToken string) gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			scope: token.ScopeLoginChallenge,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			expectStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			challengeToken, _, err := server.tokenMaker.CreateScopedToken(uint(user.ID), tc.scope, time.Minute)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(tc.body(challengeToken))
+			require.NoError(t, err)
+
+			url := "/users/login/2fa"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestLoginUserTwoFactorRequired(t *testing.T) {
+	user, _ := randomTOTPUser(t)
+	password := "secret-password"
+
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+	user.Password = hashedPassword
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().ResetFailedLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp loginUserResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.True(t, resp.TwoFactorRequired)
+	require.Empty(t, resp.AccessToken)
+	require.Nil(t, resp.User)
+
+	// the challenge token can't be used as an access token
+	payload, err := server.tokenMaker.VerifyToken(resp.ChallengeToken)
+	require.NoError(t, err)
+	require.Equal(t, token.ScopeLoginChallenge, payload.Scope)
+}
+
+// randomTOTPUser : returns a random user with two factor authentication enabled along with the totp secret
+func randomTOTPUser(t *testing.T) (db.User, string) {
+	user, _ := randomUser(t)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	user.TotpSecret = secret
+	user.IsTotpEnabled = true
+	return user, secret
+}
+
+// incorrectCode : returns a code that differs from the provided one
+func incorrectCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
@@ -1,22 +1,98 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/skamranahmed/banking-system/accountnumber"
+	"github.com/skamranahmed/banking-system/config"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
 	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+// the account to which the money is getting credited is addressed by exactly one of
+// `to_account_number`, `to_username` or `beneficiary_id`
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"` // the account from where the money is getting debited
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`   // the account to which the money is getting credited
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID   int64  `json:"from_account_id" binding:"required,min=1"` // the account from where the money is getting debited
+	ToAccountNumber string `json:"to_account_number"`
+	ToUsername      string `json:"to_username" binding:"omitempty,alphanum"` // resolves to the account of the user in the currency
+	BeneficiaryID   int64  `json:"beneficiary_id" binding:"omitempty,min=1"` // a saved payee of the authenticated user
+	Amount          string `json:"amount" binding:"required"`                // decimal string in the major unit of the currency, e.g. "12.50"
+	Currency        string `json:"currency" binding:"required,currency"`
+	OTPCode         string `json:"otp_code" binding:"omitempty,len=6,numeric"` // required for the transfers over the step up threshold
+}
+
+var errAmbiguousRecipient = errors.New("exactly one of to_account_number, to_username or beneficiary_id is required")
+
+// errAccountFrozen : a frozen account can't send or receive money until support unfreezes it
+var errAccountFrozen = errors.New("account is frozen")
+
+type transferResponse struct {
+	ID            int64       `json:"id"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        money.Money `json:"amount"` // same as the gross amount
+	Gross         money.Money `json:"gross"`  // debited from the `from account`
+	Fee           money.Money `json:"fee"`    // charged by the bank out of the gross amount
+	Net           money.Money `json:"net"`    // credited to the `to account`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type entryResponse struct {
+	ID        int64       `json:"id"`
+	AccountID int64       `json:"account_id"`
+	Amount    money.Money `json:"amount"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type transferTxnResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferResponse(transfer db.Transfer, currency money.Currency) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        money.New(transfer.Amount, currency),
+		Gross:         money.New(transfer.Amount, currency),
+		Fee:           money.New(transfer.Fee, currency),
+		Net:           money.New(transfer.Amount-transfer.Fee, currency),
+		CreatedAt:     transfer.CreatedAt,
+	}
+}
+
+// newTransferTxnResponse : both the accounts of a transfer share the currency
+func (server *Server) newTransferTxnResponse(result db.TransferTxnResult, currency money.Currency) transferTxnResponse {
+	return transferTxnResponse{
+		Transfer:    newTransferResponse(result.Transfer, currency),
+		FromAccount: server.newAccountResponse(result.FromAccount),
+		ToAccount:   server.newAccountResponse(result.ToAccount),
+		FromEntry: entryResponse{
+			ID:        result.FromEntry.ID,
+			AccountID: result.FromEntry.AccountID,
+			Amount:    money.New(result.FromEntry.Amount, currency),
+			CreatedAt: result.FromEntry.CreatedAt,
+		},
+		ToEntry: entryResponse{
+			ID:        result.ToEntry.ID,
+			AccountID: result.ToEntry.AccountID,
+			Amount:    money.New(result.ToEntry.Amount, currency),
+			CreatedAt: result.ToEntry.CreatedAt,
+		},
+	}
 }
 
 func (server *Server) createTransfer(c *gin.Context) {
@@ -30,64 +106,284 @@ func (server *Server) createTransfer(c *gin.Context) {
 	// extract the authPayload from the request context
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	// verify the currency of `fromAccount`
-	fromAccount, isFromAccountValid := server.validAccount(c, req.FromAccountID, req.Currency)
-	if !isFromAccountValid {
+	response, err := server.transferMoney(c, int64(authPayload.UserID), req)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// transferMoney : validates the transfer request of the user and performs it, shared by the HTTP and the gRPC APIs
+func (server *Server) transferMoney(ctx context.Context, userID int64, req transferRequest) (transferTxnResponse, error) {
+	// the currency has already been validated against the registry
+	amount, err := money.Parse(req.Amount, server.currency(req.Currency))
+	if err != nil {
+		return transferTxnResponse{}, newRequestError(http.StatusBadRequest, err)
+	}
+
+	if !amount.IsPositive() {
+		return transferTxnResponse{}, newRequestError(http.StatusBadRequest, errors.New("amount must be positive"))
+	}
+
+	recipients := 0
+	for _, isSet := range []bool{req.ToAccountNumber != "", req.ToUsername != "", req.BeneficiaryID != 0} {
+		if isSet {
+			recipients++
+		}
+	}
+
+	if recipients != 1 {
+		return transferTxnResponse{}, newRequestError(http.StatusBadRequest, errAmbiguousRecipient)
+	}
+
+	// a typo in the account number is caught by its check digits before it can reach another account
+	toAccountNumber := accountnumber.Normalize(req.ToAccountNumber)
+	if toAccountNumber != "" {
+		err = accountnumber.Validate(toAccountNumber)
+		if err != nil {
+			return transferTxnResponse{}, newRequestError(http.StatusBadRequest, err)
+		}
+	}
+
+	if config.RequireVerifiedEmailForTransfers {
+		err = server.requireVerifiedEmail(ctx, userID)
+		if err != nil {
+			return transferTxnResponse{}, err
+		}
+	}
+
+	// verify the currency of `fromAccount`
+	fromAccount, err := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if err != nil {
+		return transferTxnResponse{}, err
+	}
+
 	// verify whether the `fromAccount` belongs to the authenticated user
-	if fromAccount.UserID != int64(authPayload.UserID) {
+	if fromAccount.UserID != userID {
 		err := errors.New("fromAccount does not belong to the authenticated user")
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
+		return transferTxnResponse{}, newRequestError(http.StatusUnauthorized, err)
 	}
 
-	// verify whether the `fromAccount` has enough balance
-	if fromAccount.Balance < req.Amount {
-		err := fmt.Errorf("accountID: %d, insufficient balance: %d", fromAccount.ID, fromAccount.Balance)
-		c.JSON(http.StatusBadRequest, errorResponse(err))
-		return
+	// verify whether the `fromAccount` has enough balance, the funds reserved by holds can't be transferred.
+	// It is checked again by the transaction, this one fails early with the available balance
+	err = server.coversAmount(ctx, fromAccount, amount)
+	if err != nil {
+		return transferTxnResponse{}, err
 	}
 
 	// verify the currency of `toAccount`
-	_, isToAccountValid := server.validAccount(c, req.ToAccountID, req.Currency)
-	if !isToAccountValid {
-		return
+	var toAccount db.Account
+	switch {
+	case req.ToUsername != "":
+		toAccount, err = server.usernameAccount(ctx, req.ToUsername, req.Currency)
+	case req.BeneficiaryID != 0:
+		toAccount, err = server.beneficiaryAccount(ctx, req.BeneficiaryID, userID, req.Currency)
+	default:
+		toAccount, err = server.validAccountNumber(ctx, toAccountNumber, req.Currency)
+	}
+	if err != nil {
+		return transferTxnResponse{}, err
+	}
+
+	// the accounts resolved from a username aren't checked by the lookup
+	if toAccount.IsFrozen {
+		return transferTxnResponse{}, newRequestError(http.StatusForbidden, errAccountFrozen)
+	}
+
+	// the fee is taken out of the amount, the recipient gets the rest
+	fee, feeAccountID, err := server.transferFee(ctx, amount)
+	if err != nil {
+		return transferTxnResponse{}, err
+	}
+
+	// large transfers require a one time password on top of the access token,
+	// it is checked last so that the code isn't used up by a request that fails validation
+	if server.currencies.RequiresStepUp(amount) {
+		err = server.authorizeStepUp(ctx, fromAccount.UserID, req.OTPCode)
+		if err != nil {
+			return transferTxnResponse{}, err
+		}
 	}
 
 	arg := db.TransferTxnParams{
 		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount.Amount,
+		Fee:           fee.Amount,
+		FeeAccountID:  feeAccountID,
+
+		CheckAvailable: true,
 	}
 
-	result, err := server.store.TransferTxn(c, arg)
+	result, err := server.store.TransferTxn(ctx, arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		// the balance changed since it was checked above
+		if err == db.ErrInsufficientFunds {
+			err = fmt.Errorf("accountID: %d, insufficient balance", req.FromAccountID)
+			return transferTxnResponse{}, newRequestError(http.StatusBadRequest, err)
+		}
+		return transferTxnResponse{}, err
 	}
 
-	c.JSON(http.StatusOK, result)
-	return
+	return server.newTransferTxnResponse(result, amount.Currency), nil
 }
 
-func (server *Server) validAccount(c *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(c, accountID)
+// validAccount : looks up the account and checks that it is in the currency and isn't frozen
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
-			return account, false
-		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return account, false
+		return account, accountLookupError(err)
 	}
 
 	if account.Currency != currency {
 		err := fmt.Errorf("accountID:%d currency mismatch. Account Currency:%s, got currency:%s", account.ID, account.Currency, currency)
-		c.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
+		return account, newRequestError(http.StatusBadRequest, err)
 	}
 
-	return account, true
+	if account.IsFrozen {
+		return account, newRequestError(http.StatusForbidden, errAccountFrozen)
+	}
+
+	return account, nil
+}
+
+// validAccountNumber : same as validAccount for the accounts addressed by their external number,
+// the errors don't mention the internal ID of the account
+func (server *Server) validAccountNumber(ctx context.Context, accountNumber string, currency string) (db.Account, error) {
+	account, err := server.store.GetAccountByNumber(ctx, accountNumber)
+	if err != nil {
+		return account, accountLookupError(err)
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account:%s currency mismatch. Account Currency:%s, got currency:%s", accountNumber, account.Currency, currency)
+		return account, newRequestError(http.StatusBadRequest, err)
+	}
+
+	if account.IsFrozen {
+		return account, newRequestError(http.StatusForbidden, errAccountFrozen)
+	}
+
+	return account, nil
+}
+
+// usernameAccount : resolves the account of the user in the currency, the checking accounts are
+// preferred over the other types and the oldest account wins
+func (server *Server) usernameAccount(ctx context.Context, username string, currency string) (db.Account, error) {
+	user, err := server.store.GetUserByUsername(ctx, username)
+	if err != nil {
+		return db.Account{}, accountLookupError(err)
+	}
+
+	arg := db.ListAccountsParams{
+		UserID:   user.ID,
+		Currency: sql.NullString{String: currency, Valid: true},
+		Type:     sql.NullString{String: utils.AccountTypeChecking, Valid: true},
+		Limit:    1,
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, arg)
+	if err == nil && len(accounts) == 0 {
+		arg.Type = sql.NullString{}
+		accounts, err = server.store.ListAccounts(ctx, arg)
+	}
+
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	if len(accounts) == 0 {
+		err := fmt.Errorf("user %s has no %s account", username, currency)
+		return db.Account{}, newRequestError(http.StatusNotFound, err)
+	}
+
+	return accounts[0], nil
+}
+
+// beneficiaryAccount : resolves the account of a saved payee of the user
+func (server *Server) beneficiaryAccount(ctx context.Context, beneficiaryID int64, userID int64, currency string) (db.Account, error) {
+	beneficiary, err := server.store.GetBeneficiary(ctx, beneficiaryID)
+	if err != nil {
+		return db.Account{}, accountLookupError(err)
+	}
+
+	if beneficiary.UserID != userID {
+		err := errors.New("beneficiary does not belong to the authenticated user")
+		return db.Account{}, newRequestError(http.StatusUnauthorized, err)
+	}
+
+	return server.validAccountNumber(ctx, beneficiary.AccountNumber, currency)
+}
+
+// coversAmount : checks whether the available balance of the account, the balance less the funds
+// reserved by the active holds, covers the amount
+func (server *Server) coversAmount(ctx context.Context, account db.Account, amount money.Money) error {
+	held, err := server.store.GetHeldAmount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if account.Balance-held < amount.Amount {
+		available := money.New(account.Balance-held, amount.Currency)
+		err := fmt.Errorf("accountID: %d, insufficient balance: %s", account.ID, available)
+		return newRequestError(http.StatusBadRequest, err)
+	}
+
+	return nil
+}
+
+// transferFee : returns the fee charged on a transfer of the amount along with the ID of the
+// account the fee is credited to, which is zero if the transfer is free
+func (server *Server) transferFee(ctx context.Context, amount money.Money) (money.Money, int64, error) {
+	fee := server.fees.Fee(amount)
+	if fee.Amount >= amount.Amount {
+		err := fmt.Errorf("amount %s doesn't cover the transfer fee of %s", amount, fee)
+		return fee, 0, newRequestError(http.StatusBadRequest, err)
+	}
+
+	if fee.IsZero() {
+		return fee, 0, nil
+	}
+
+	feeAccountID, err := server.feeAccount(ctx, amount.Currency.Code)
+	if err != nil {
+		return fee, 0, err
+	}
+
+	return fee, feeAccountID, nil
+}
+
+// feeAccount : returns the ID of the fee revenue account of the currency, the account is opened on the first
+// fee charged in the currency and only looked up afterwards
+func (server *Server) feeAccount(ctx context.Context, currency string) (int64, error) {
+	systemAccount, err := server.store.GetSystemAccount(ctx, db.GetSystemAccountParams{
+		Purpose:  db.SystemAccountFeeRevenue,
+		Currency: currency,
+	})
+	if err == nil {
+		return systemAccount.AccountID, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	accountNumber, err := accountnumber.Generate()
+	if err != nil {
+		return 0, err
+	}
+
+	account, err := server.store.SystemAccountTxn(ctx, db.SystemAccountTxnParams{
+		Purpose:       db.SystemAccountFeeRevenue,
+		Currency:      currency,
+		AccountNumber: accountNumber,
+	})
+	return account.ID, err
+}
+
+func handleAccountLookupError(c *gin.Context, err error) {
+	respondError(c, accountLookupError(err))
 }
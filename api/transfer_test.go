@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -11,9 +12,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/skamranahmed/banking-system/config"
 	mockdb "github.com/skamranahmed/banking-system/db/mock"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/fee"
 	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/totp"
 	"github.com/skamranahmed/banking-system/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -30,6 +34,7 @@ func TestTransferAPI(t *testing.T) {
 	account3 := randomAccount(uint(user3.ID))
 
 	account1.Currency = utils.INR
+	account1.Balance = amount
 	account2.Currency = utils.INR
 	account3.Currency = utils.USD
 
@@ -43,41 +48,102 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Happy Case - All OK",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
 
 				arg := db.TransferTxnParams{
-					FromAccountID: account1.ID,
-					ToAccountID:   account2.ID,
-					Amount:        amount,
+					FromAccountID:  account1.ID,
+					ToAccountID:    account2.ID,
+					Amount:         amount,
+					CheckAvailable: true,
 				}
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(arg)).Times(1)
 			},
 			expectStatus: http.StatusOK,
 		},
+		{
+			name: "Failure Case - From Account Frozen",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAccount := account1
+				frozenAccount.IsFrozen = true
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name: "Failure Case - To Account Frozen",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAccount := account2
+				frozenAccount.IsFrozen = true
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name: "Failure Case - Balance Reserved By Holds",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetHeldAmount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(int64(1), nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
 		{
 			name: "Failure Case - FromAccount Does Not Exists / NotFound",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.USD,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(0)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectStatus: http.StatusNotFound,
@@ -85,17 +151,17 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - ToAccount Does Not Exists / NotFound",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(db.Account{}, sql.ErrNoRows)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectStatus: http.StatusNotFound,
@@ -103,17 +169,17 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - FromAccount Currency Mismatch",
 			body: gin.H{
-				"from_account_id": account3.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account3.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user3.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(0)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectStatus: http.StatusBadRequest,
@@ -121,17 +187,17 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - ToAccount Currency Mismatch",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account3.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account3.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account3.AccountNumber)).Times(1).Return(account3, nil)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectStatus: http.StatusBadRequest,
@@ -139,10 +205,10 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - Unsupported Currency Provided by User",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        "XYZ",
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          "XYZ",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
@@ -156,16 +222,85 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - Negative Amount in Transfer Request",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          -amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "-1.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Zero Amount in Transfer Request",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "0.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Amount Finer Than The Minor Unit",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.001",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Amount Not A Decimal String",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            amount,
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Failure Case - Invalid Check Digits Of ToAccount Number",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": mistypedAccountNumber(account2.AccountNumber),
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectStatus: http.StatusBadRequest,
@@ -173,10 +308,10 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - GetAccountError",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
@@ -190,21 +325,39 @@ func TestTransferAPI(t *testing.T) {
 		{
 			name: "Failure Case - TransferTxnError",
 			body: gin.H{
-				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          amount,
-				"currency":        utils.INR,
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
 				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxnResult{}, sql.ErrTxDone)
 			},
 			expectStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "Failure Case - Balance Reserved While Transferring",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxnResult{}, db.ErrInsufficientFunds)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
 	}
 
 	for i := range testCases {
@@ -237,3 +390,628 @@ func TestTransferAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestTransferStepUpAPI(t *testing.T) {
+	amount := int64(100)
+
+	user1, secret := randomTOTPUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.INR
+	account1.Balance = amount
+	account2.Currency = utils.INR
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		body         gin.H
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - Valid One Time Password",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+				"otp_code":          code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(user1, nil)
+				store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(1)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Missing One Time Password",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(user1, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name: "Failure Case - Two Factor Authentication Not Enabled",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+				"otp_code":          code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				userWithoutTOTP := user1
+				userWithoutTOTP.IsTotpEnabled = false
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(userWithoutTOTP, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name: "Failure Case - Incorrect One Time Password",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+				"otp_code":          incorrectCode(code),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				failedUser := user1
+				failedUser.FailedLoginAttempts = 1
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(user1, nil)
+				store.EXPECT().IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(failedUser, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Locked User",
+			body: gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            "1.00",
+				"currency":          utils.INR,
+				"otp_code":          code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				lockedUser := user1
+				lockedUser.LockedUntil = time.Now().Add(time.Minute)
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(lockedUser, nil)
+				store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// the transfers of 1.00 INR or more require a one time password
+			useStepUpThreshold(t, server, utils.INR, "1.00")
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/transfers"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+// TestTransferStepUpLockoutAPI : the incorrect one time passwords of the step up lock the user out,
+// after which even a valid one is refused
+func TestTransferStepUpLockoutAPI(t *testing.T) {
+	user1, secret := randomTOTPUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.INR
+	account1.Balance = 100
+	account2.Currency = utils.INR
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the user as stored, updated by the failed attempts and the lockout
+	stored := user1
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	useStepUpThreshold(t, server, utils.INR, "1.00")
+
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).AnyTimes().Return(account1, nil)
+	store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).AnyTimes().Return(account2, nil)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user1.ID)).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, _ int64) (db.User, error) {
+			return stored, nil
+		})
+	store.EXPECT().
+		IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user1.ID)).
+		Times(config.MaxFailedLoginAttempts).
+		DoAndReturn(func(_ context.Context, _ int64) (db.User, error) {
+			stored.FailedLoginAttempts++
+			return stored, nil
+		})
+	store.EXPECT().
+		LockUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.LockUserParams) error {
+			stored.LockedUntil = arg.LockedUntil
+			return nil
+		})
+	store.EXPECT().UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+
+	transfer := func(otpCode string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{
+			"from_account_id":   account1.ID,
+			"to_account_number": account2.AccountNumber,
+			"amount":            "1.00",
+			"currency":          utils.INR,
+			"otp_code":          otpCode,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 0; i < config.MaxFailedLoginAttempts; i++ {
+		recorder := transfer(incorrectCode(code))
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+	require.True(t, stored.LockedUntil.After(time.Now()))
+
+	recorder := transfer(code)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), errStepUpLocked.Error())
+}
+
+func TestTransferRequireVerifiedEmailAPI(t *testing.T) {
+	amount := int64(100)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.INR
+	account1.Balance = amount
+	account2.Currency = utils.INR
+
+	body := gin.H{
+		"from_account_id":   account1.ID,
+		"to_account_number": account2.AccountNumber,
+		"amount":            "1.00",
+		"currency":          utils.INR,
+	}
+
+	testCases := []struct {
+		name         string
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - Verified Email",
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user1
+				verifiedUser.IsEmailVerified = true
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(verifiedUser, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(1)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - Unverified Email",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.ID)).Times(1).Return(user1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// the config is loaded by newTestServer, so the policy is overridden afterwards
+			config.RequireVerifiedEmailForTransfers = true
+			defer func() { config.RequireVerifiedEmailForTransfers = false }()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			url := "/transfers"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestTransferRecipientAPI(t *testing.T) {
+	amount := int64(100)
+
+	user1, _ := randomUser(t)
+	user1.ID = utils.RandomInt(1, 1000)
+	user2, _ := randomUser(t)
+	user2.ID = user1.ID + 1
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.INR
+	account1.Balance = amount
+	account2.Currency = utils.INR
+
+	beneficiary := randomBeneficiary(user1.ID, account2, user2.FullName)
+
+	checkingArg := db.ListAccountsParams{
+		UserID:   user2.ID,
+		Currency: sql.NullString{String: utils.INR, Valid: true},
+		Type:     sql.NullString{String: utils.AccountTypeChecking, Valid: true},
+		Limit:    1,
+	}
+
+	anyTypeArg := checkingArg
+	anyTypeArg.Type = sql.NullString{}
+
+	transferArg := db.TransferTxnParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         amount,
+		CheckAvailable: true,
+	}
+
+	testCases := []struct {
+		name         string
+		recipient    gin.H
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name:      "Happy Case - Username Resolves To Checking Account",
+			recipient: gin.H{"to_username": user2.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user2.Username)).Times(1).Return(user2, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(checkingArg)).Times(1).Return([]db.Account{account2}, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(transferArg)).Times(1)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:      "Happy Case - Username Falls Back To Any Account Type",
+			recipient: gin.H{"to_username": user2.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user2.Username)).Times(1).Return(user2, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(checkingArg)).Times(1).Return([]db.Account{}, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(anyTypeArg)).Times(1).Return([]db.Account{account2}, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(transferArg)).Times(1)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:      "Failure Case - Username Without Account In Currency",
+			recipient: gin.H{"to_username": user2.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user2.Username)).Times(1).Return(user2, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(2).Return([]db.Account{}, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Happy Case - Beneficiary",
+			recipient: gin.H{"beneficiary_id": beneficiary.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(beneficiary, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(transferArg)).Times(1)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:      "Failure Case - Beneficiary Of Another User",
+			recipient: gin.H{"beneficiary_id": beneficiary.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				otherBeneficiary := beneficiary
+				otherBeneficiary.UserID = user2.ID
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).Times(1).Return(otherBeneficiary, nil)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:      "Failure Case - More Than One Recipient",
+			recipient: gin.H{"to_username": user2.Username, "to_account_number": account2.AccountNumber},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "Failure Case - No Recipient",
+			recipient: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"from_account_id": account1.ID,
+				"amount":          "1.00",
+				"currency":        utils.INR,
+			}
+			for key, value := range tc.recipient {
+				body[key] = value
+			}
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestTransferFeeAPI(t *testing.T) {
+	amount := int64(10000)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(uint(user1.ID))
+	account2 := randomAccount(uint(user2.ID))
+	account1.Currency = utils.USD
+	account1.Balance = amount
+	account2.Currency = utils.USD
+
+	feeAccount := db.Account{ID: account2.ID + 1, Currency: utils.USD}
+	systemAccountArg := db.GetSystemAccountParams{
+		Purpose:  db.SystemAccountFeeRevenue,
+		Currency: utils.USD,
+	}
+
+	// 0.25 + 0.5% of 100.00
+	transferArg := db.TransferTxnParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Fee:           75,
+		FeeAccountID:  feeAccount.ID,
+
+		CheckAvailable: true,
+	}
+
+	transferResult := db.TransferTxnResult{
+		Transfer: db.Transfer{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+			Fee:           75,
+			FeeAccountID:  sql.NullInt64{Int64: feeAccount.ID, Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		amount        string
+		currency      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Happy Case - Fee Credited To Revenue Account",
+			amount:   "100.00",
+			currency: utils.USD,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					GetSystemAccount(gomock.Any(), gomock.Eq(systemAccountArg)).
+					Times(1).
+					Return(db.SystemAccount{AccountID: feeAccount.ID}, nil)
+				store.EXPECT().SystemAccountTxn(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(transferArg)).Times(1).Return(transferResult, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response transferTxnResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, "100.00", response.Transfer.Gross.Decimal())
+				require.Equal(t, "0.75", response.Transfer.Fee.Decimal())
+				require.Equal(t, "99.25", response.Transfer.Net.Decimal())
+				require.Equal(t, utils.USD, response.Transfer.Net.Currency.Code)
+			},
+		},
+		{
+			name:     "Happy Case - Revenue Account Opened On First Fee",
+			amount:   "100.00",
+			currency: utils.USD,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					GetSystemAccount(gomock.Any(), gomock.Eq(systemAccountArg)).
+					Times(1).
+					Return(db.SystemAccount{}, sql.ErrNoRows)
+				store.EXPECT().
+					SystemAccountTxn(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ *gin.Context, arg db.SystemAccountTxnParams) (db.Account, error) {
+						require.Equal(t, db.SystemAccountFeeRevenue, arg.Purpose)
+						require.Equal(t, utils.USD, arg.Currency)
+						return feeAccount, nil
+					})
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Eq(transferArg)).Times(1).Return(transferResult, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Failure Case - Amount Does Not Cover The Fee",
+			amount:   "0.50",
+			currency: utils.USD,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetSystemAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Failure Case - Revenue Account Lookup Error",
+			amount:   "100.00",
+			currency: utils.USD,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+				store.EXPECT().GetSystemAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.SystemAccount{}, sql.ErrConnDone)
+				store.EXPECT().TransferTxn(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.fees, _ = fee.NewSchedule([]fee.Rule{
+				{Currency: utils.USD, Flat: 25, PercentBps: 50, Min: 50},
+			})
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id":   account1.ID,
+				"to_account_number": account2.AccountNumber,
+				"amount":            tc.amount,
+				"currency":          tc.currency,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(user1.ID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -10,9 +12,20 @@ import (
 	"github.com/lib/pq"
 	"github.com/skamranahmed/banking-system/config"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
 	"github.com/skamranahmed/banking-system/utils"
 )
 
+// maxLockoutDuration : upper bound of the progressive lockout
+const maxLockoutDuration = 24 * time.Hour
+
+// errInvalidCredentials : is returned for every failed login, so that the response doesn't reveal
+// whether the username exists, the password was wrong or the user is locked
+var errInvalidCredentials = errors.New("invalid username or password")
+
+// errUserDisabled : the user has been disabled by support, only revealed once the password is right
+var errUserDisabled = errors.New("user has been disabled, contact support")
+
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -27,16 +40,22 @@ type createUserResponse struct {
 }
 
 type userResponse struct {
-	Username string `json:"username"`
-	FullName string `json:"full_name"`
-	Email    string `json:"email"`
+	Username        string    `json:"username"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	IsTotpEnabled   bool      `json:"is_totp_enabled"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 func newUserResponse(user db.User) *userResponse {
 	return &userResponse{
-		Username: user.Username,
-		FullName: user.FullName,
-		Email:    user.Email,
+		Username:        user.Username,
+		FullName:        user.FullName,
+		Email:           user.Email,
+		IsEmailVerified: user.IsEmailVerified,
+		IsTotpEnabled:   user.IsTotpEnabled,
+		CreatedAt:       user.CreatedAt,
 	}
 }
 
@@ -48,13 +67,29 @@ func (server *Server) createUser(c *gin.Context) {
 		return
 	}
 
-	plainTextPassword := req.Password
-	hashedPassword, err := utils.HashPassword(plainTextPassword)
+	response, err := server.registerUser(c, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusCreated, response)
+	return
+}
+
+// registerUser : creates the user of a validated request, shared by the HTTP and the gRPC APIs
+func (server *Server) registerUser(ctx context.Context, req createUserRequest) (*userResponse, error) {
+	err := server.validateNewPassword(req.Password, req.Username, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	plainTextPassword := req.Password
+	hashedPassword, err := server.passwordHasher.Hash(plainTextPassword)
+	if err != nil {
+		return nil, err
+	}
+
 	arg := db.CreateUserParams{
 		Username: req.Username,
 		Password: hashedPassword,
@@ -62,24 +97,26 @@ func (server *Server) createUser(c *gin.Context) {
 		Email:    req.Email,
 	}
 
-	user, err := server.store.CreateUser(c, arg)
+	user, err := server.store.CreateUser(ctx, arg)
 	if err != nil {
 		pqErr, ok := err.(*pq.Error)
 		if ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
-				c.JSON(http.StatusBadRequest, errorResponse(err))
-				return
+				return nil, newRequestError(http.StatusBadRequest, err)
 			}
 		}
 
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return nil, err
 	}
 
-	response := newUserResponse(user)
-	c.JSON(http.StatusCreated, response)
-	return
+	err = server.sendVerificationEmail(ctx, user)
+	if err != nil {
+		// the user can ask for a new link, so the signup itself doesn't fail
+		log.Printf("unable to send the verification email to user %d, err: %v", user.ID, err)
+	}
+
+	return newUserResponse(user), nil
 }
 
 type loginUserRequest struct {
@@ -88,8 +125,13 @@ type loginUserRequest struct {
 }
 
 type loginUserResponse struct {
-	AccessToken string        `json:"access_token"`
-	User        *userResponse `json:"user"`
+	AccessToken string        `json:"access_token,omitempty"`
+	User        *userResponse `json:"user,omitempty"`
+
+	// set instead of the access token when the user has two factor authentication enabled,
+	// the challenge token has to be exchanged for an access token along with a one time password
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 func (server *Server) loginUser(c *gin.Context) {
@@ -100,27 +142,92 @@ func (server *Server) loginUser(c *gin.Context) {
 		return
 	}
 
-	user, err := server.store.GetUserByUsername(c, req.Username)
+	response, err := server.login(c, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// login : checks the credentials of a validated request, shared by the HTTP and the gRPC APIs. The users with
+// two factor authentication get a challenge token instead of the access token
+func (server *Server) login(ctx context.Context, req loginUserRequest) (*loginUserResponse, error) {
+	user, err := server.store.GetUserByUsername(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(errors.New("no user found")))
-			return
+			// compare against a dummy hash so that the response time doesn't reveal whether the username exists
+			utils.CheckPassword(req.Password, server.dummyPasswordHash())
+			return nil, newRequestError(http.StatusUnauthorized, errInvalidCredentials)
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return nil, err
 	}
 
 	err = utils.CheckPassword(req.Password, user.Password)
+
+	// a locked user gets the same response as a wrong password, so that the lockout doesn't reveal the account
+	if user.LockedUntil.After(time.Now()) {
+		return nil, newRequestError(http.StatusUnauthorized, errInvalidCredentials)
+	}
+
+	if err != nil {
+		err = server.recordFailedLogin(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newRequestError(http.StatusUnauthorized, errInvalidCredentials)
+	}
+
+	if user.IsDisabled {
+		return nil, newRequestError(http.StatusForbidden, errUserDisabled)
+	}
+
+	server.rehashPassword(ctx, user, req.Password)
+
+	// the failed attempts are only reset once the one time password has been provided as well
+	if user.IsTotpEnabled {
+		challengeDurationInMinutes := time.Minute * time.Duration(config.LoginChallengeDuration)
+		challengeToken, _, err := server.tokenMaker.CreateScopedToken(uint(user.ID), token.ScopeLoginChallenge, challengeDurationInMinutes)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &loginUserResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}
+
+		return resp, nil
+	}
+
+	return server.issueAccessToken(ctx, user)
+}
+
+// completeLogin : responds with a new access token of the user
+func (server *Server) completeLogin(c *gin.Context, user db.User) {
+	response, err := server.issueAccessToken(c, user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(errors.New("username or password mismatch")))
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, response)
+}
+
+// issueAccessToken : resets the failed login attempts of the user and returns a new access token
+func (server *Server) issueAccessToken(ctx context.Context, user db.User) (*loginUserResponse, error) {
+	if user.FailedLoginAttempts > 0 {
+		err := server.store.ResetFailedLoginAttempts(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	accessTokenDurationInMinutes := time.Minute * time.Duration(config.AccessTokenDuration)
 	accessToken, _, err := server.tokenMaker.CreateToken(uint(user.ID), accessTokenDurationInMinutes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return nil, err
 	}
 
 	resp := &loginUserResponse{
@@ -128,6 +235,69 @@ func (server *Server) loginUser(c *gin.Context) {
 		User:        newUserResponse(user),
 	}
 
-	c.JSON(http.StatusOK, resp)
-	return
+	return resp, nil
+}
+
+// recordFailedLogin : increments the failed login attempts of the user and locks the user once
+// the attempts reach the limit, every further failure doubles the lockout duration
+func (server *Server) recordFailedLogin(ctx context.Context, userID int64) error {
+	user, err := server.store.IncrementFailedLoginAttempts(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	attemptsOverLimit := int(user.FailedLoginAttempts) - config.MaxFailedLoginAttempts
+	if attemptsOverLimit < 0 {
+		return nil
+	}
+
+	arg := db.LockUserParams{
+		ID:          user.ID,
+		LockedUntil: time.Now().Add(lockoutDuration(attemptsOverLimit)),
+	}
+
+	return server.store.LockUser(ctx, arg)
+}
+
+// lockoutDuration : returns the configured lockout duration doubled for every attempt over the limit
+func lockoutDuration(attemptsOverLimit int) time.Duration {
+	duration := time.Minute * time.Duration(config.LoginLockoutDuration)
+	for i := 0; i < attemptsOverLimit && duration < maxLockoutDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > maxLockoutDuration {
+		return maxLockoutDuration
+	}
+	return duration
+}
+
+// dummyPasswordHash : returns the hash compared against when the user doesn't exist,
+// it uses the configured hasher so that it takes as long as checking the password of a user
+func (server *Server) dummyPasswordHash() string {
+	server.dummyPasswordHashOnce.Do(func() {
+		server.dummyPasswordHashValue, _ = server.passwordHasher.Hash(utils.RandomString(16))
+	})
+	return server.dummyPasswordHashValue
+}
+
+// rehashPassword : upgrades the stored hash of the user to the configured algorithm and cost,
+// the plain text password is only available on login so this is the only chance to do it
+func (server *Server) rehashPassword(ctx context.Context, user db.User, plainTextPassword string) {
+	if !server.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(plainTextPassword)
+	if err == nil {
+		err = server.store.UpdateUserPasswordHash(ctx, db.UpdateUserPasswordHashParams{
+			ID:       user.ID,
+			Password: hashedPassword,
+		})
+	}
+
+	// the old hash still works, so the login doesn't fail
+	if err != nil {
+		log.Printf("unable to rehash the password of user %d, err: %v", user.ID, err)
+	}
 }
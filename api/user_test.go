@@ -6,12 +6,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/skamranahmed/banking-system/config"
 	mockdb "github.com/skamranahmed/banking-system/db/mock"
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/ratelimit"
 	"github.com/skamranahmed/banking-system/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -131,6 +135,322 @@ func TestCreateUserAPI(t *testing.T) {
 	}
 }
 
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	lockedUser := user
+	lockedUser.LockedUntil = time.Now().Add(time.Minute)
+
+	testCases := []struct {
+		name         string
+		body         gin.H
+		buildStubs   func(store *mockdb.MockStore)
+		expectStatus int
+	}{
+		{
+			name: "Happy Case - All OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResetFailedLoginAttempts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Happy Case - Failed Attempts Are Reset",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				userWithFailures := user
+				userWithFailures.FailedLoginAttempts = 2
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userWithFailures, nil)
+				store.EXPECT().
+					ResetFailedLoginAttempts(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "Failure Case - User Disabled",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				disabledUser := user
+				disabledUser.IsDisabled = true
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(disabledUser, nil)
+				store.EXPECT().
+					ResetFailedLoginAttempts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name: "Failure Case - User Not Found",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Incorrect Password",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect-password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				userWithFailures := user
+				userWithFailures.FailedLoginAttempts = 1
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(userWithFailures, nil)
+				store.EXPECT().
+					LockUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Incorrect Password Locks The User",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect-password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				userWithFailures := user
+				userWithFailures.FailedLoginAttempts = int32(config.MaxFailedLoginAttempts)
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					IncrementFailedLoginAttempts(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(userWithFailures, nil)
+				store.EXPECT().
+					LockUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Locked User With Correct Password",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(lockedUser, nil)
+				store.EXPECT().
+					IncrementFailedLoginAttempts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure Case - Internal Server Error",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			expectStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Failure Case - Invalid Username",
+			body: gin.H{
+				"username": "invalid-user#1",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestLoginUserRateLimit(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(config.LoginRateLimitPerUsername).
+		Return(db.User{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{
+		"username": user.Username,
+		"password": "incorrect-password",
+	})
+	require.NoError(t, err)
+
+	// the requests over the per username limit don't reach the handler
+	for i := 0; i <= config.LoginRateLimitPerUsername; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+		require.NoError(t, err)
+		server.router.ServeHTTP(recorder, request)
+
+		if i < config.LoginRateLimitPerUsername {
+			require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			continue
+		}
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestLoginUserRateLimitForwardedFor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		expectStatus   int
+	}{
+		{
+			// the peer isn't a trusted proxy, so the client can't pick a new IP for every request
+			name:         "Failure Case - Spoofed X-Forwarded-For",
+			expectStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "Happy Case - Trusted Proxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			expectStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			limiter, err := ratelimit.NewMemoryLimiter(1, time.Minute)
+			require.NoError(t, err)
+			server.loginIPLimiter = limiter
+
+			config.TrustedProxies = tc.trustedProxies
+			defer func() { config.TrustedProxies = nil }()
+			require.NoError(t, server.setupRouter())
+
+			var recorder *httptest.ResponseRecorder
+			for _, forwardedFor := range []string{"198.51.100.1", "198.51.100.2"} {
+				// the body is invalid, the requests which get through the limiter are rejected by the handler
+				request, err := http.NewRequest(http.MethodPost, "/users/login/2fa", strings.NewReader("{}"))
+				require.NoError(t, err)
+				request.RemoteAddr = "203.0.113.7:4321"
+				request.Header.Set("X-Forwarded-For", forwardedFor)
+
+				recorder = httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+			}
+
+			require.Equal(t, tc.expectStatus, recorder.Code)
+		})
+	}
+}
+
+func TestLoginUserBodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{
+		"username": utils.RandomName(),
+		"password": strings.Repeat("x", 2*maxUsernameKeyBodySize),
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
 	password = utils.RandomString(6)
 	hashedPassword, err := utils.HashPassword(password)
@@ -144,3 +464,160 @@ func randomUser(t *testing.T) (user db.User, password string) {
 	}
 	return
 }
+
+func TestLoginUserRehashAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "Happy Case - Outdated Hash Is Upgraded",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserPasswordHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserPasswordHashParams) error {
+						require.Equal(t, user.ID, arg.ID)
+						require.True(t, strings.HasPrefix(arg.Password, "$argon2id$"))
+						require.NoError(t, utils.CheckPassword(password, arg.Password))
+						return nil
+					})
+			},
+		},
+		{
+			name: "Happy Case - Failed Rehash Doesn't Fail The Login",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPasswordHash(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+
+			// the stored bcrypt hash is outdated once the server hashes with argon2id
+			passwordHasher, err := utils.NewArgon2idHasher(utils.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1})
+			require.NoError(t, err)
+			server.passwordHasher = passwordHasher
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func TestCreateUserPasswordPolicyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	breachedPasswords, err := utils.LoadBreachedPasswordList("../config/breachedPasswords.txt")
+	require.NoError(t, err)
+
+	policy := utils.PasswordPolicy{
+		MinLength:        8,
+		RequireDigit:     true,
+		DisallowUserInfo: true,
+		Breached:         breachedPasswords,
+	}
+
+	testCases := []struct {
+		name          string
+		password      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Happy Case - Strong Password",
+			password: "Zebra!Lamp42",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:     "Failure Case - Too Short And Missing Digit",
+			password: "zebras",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchViolations(t, recorder.Body, utils.PasswordTooShort, utils.PasswordMissingDigit)
+			},
+		},
+		{
+			name:     "Failure Case - Contains The Username",
+			password: user.Username + "2022",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchViolations(t, recorder.Body, utils.PasswordSimilarToUserInfo)
+			},
+		},
+		{
+			name:     "Failure Case - Breached Password",
+			password: "password123",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchViolations(t, recorder.Body, utils.PasswordBreached)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.passwordPolicy = policy
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username":  user.Username,
+				"password":  tc.password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
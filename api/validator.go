@@ -2,14 +2,41 @@ package api
 
 import (
 	"github.com/go-playground/validator/v10"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
 	"github.com/skamranahmed/banking-system/utils"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	currency, ok := fieldLevel.Field().Interface().(string)
+// validCurrency : returns a validator which accepts the currencies of the registry
+func validCurrency(registry *money.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		currency, ok := fieldLevel.Field().Interface().(string)
+		if ok {
+			// check if currency is supported or not
+			return registry.IsSupported(currency)
+		}
+
+		return false
+	}
+}
+
+var validAccountType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	accountType, ok := fieldLevel.Field().Interface().(string)
+	if ok {
+		return utils.IsSupportedAccountType(accountType)
+	}
+
+	return false
+}
+
+var validEventType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	eventType, ok := fieldLevel.Field().Interface().(string)
 	if ok {
-		// check if currency is supported or not
-		return utils.IsSupportedCurrency(currency)
+		for _, supported := range db.EventTypes {
+			if eventType == supported {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/token"
+	"github.com/skamranahmed/banking-system/webhook"
+)
+
+// an empty list of event types subscribes the webhook to every event type
+type createWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"omitempty,dive,event_type"`
+}
+
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// the secret is only returned once, when the webhook is created
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+func newWebhookResponse(webhook db.Webhook) webhookResponse {
+	eventTypes := webhook.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return webhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.Url,
+		EventTypes: eventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+// isPublicHost : reports whether the host of a webhook url can be public, a name is only known once resolved
+func isPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return true
+	}
+	return webhook.IsPublicIP(ip)
+}
+
+func (server *Server) createWebhook(c *gin.Context) {
+	var req createWebhookRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the deliveries are plain HTTP requests, the other schemes accepted by the url validator can't receive them
+	webhookURL, err := url.Parse(req.URL)
+	if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		c.JSON(http.StatusBadRequest, errorResponse(errors.New("url must be an absolute http or https url")))
+		return
+	}
+
+	// the dispatcher checks every address the host resolves to when it connects,
+	// the hosts which obviously aren't public are rejected up front
+	if !config.WebhookAllowPrivateNetworks && !isPublicHost(webhookURL.Hostname()) {
+		c.JSON(http.StatusBadRequest, errorResponse(errors.New("url must point to a public host")))
+		return
+	}
+
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	seen := make(map[string]bool)
+	for _, eventType := range req.EventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	// signs the deliveries, so unlike the password reset tokens it has to be stored as is
+	secret, err := token.GenerateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	webhook, err := server.store.CreateWebhook(c, db.CreateWebhookParams{
+		UserID:     int64(authPayload.UserID),
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, createWebhookResponse{
+		webhookResponse: newWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+	return
+}
+
+type getWebhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ownWebhook : fetches the webhook from the uri and checks that it belongs to the authenticated user
+func (server *Server) ownWebhook(c *gin.Context) (db.Webhook, bool) {
+	var req getWebhookRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Webhook{}, false
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	webhook, err := server.store.GetWebhook(c, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+			return webhook, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return webhook, false
+	}
+
+	if authPayload.UserID != uint(webhook.UserID) {
+		err := errors.New("webhook does not belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return webhook, false
+	}
+
+	return webhook, true
+}
+
+func (server *Server) getWebhook(c *gin.Context) {
+	webhook, ok := server.ownWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newWebhookResponse(webhook))
+	return
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listWebhooks(c *gin.Context) {
+	var req listWebhooksRequest
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// extract the authPayload from the request context
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	webhooks, err := server.store.ListWebhooks(c, db.ListWebhooksParams{
+		UserID: int64(authPayload.UserID),
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newWebhookResponse(webhook))
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// deleteWebhook : the pending deliveries of the webhook are deleted along with it
+func (server *Server) deleteWebhook(c *gin.Context) {
+	webhook, ok := server.ownWebhook(c)
+	if !ok {
+		return
+	}
+
+	err := server.store.DeleteWebhook(c, webhook.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+	return
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type,omitempty"`
+	Status         string     `json:"status"` // pending, succeeded or dead
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // only set while the delivery is pending
+	LastError      string     `json:"last_error,omitempty"`
+	LastStatusCode int32      `json:"last_status_code,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	response := webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		LastStatusCode: delivery.LastStatusCode,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+
+	if delivery.Status == db.DeliveryStatusPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	if delivery.DeliveredAt.Valid {
+		response.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+
+	return response
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// listWebhookDeliveries : the most recent deliveries come first
+func (server *Server) listWebhookDeliveries(c *gin.Context) {
+	var req listWebhookDeliveriesRequest
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	webhook, ok := server.ownWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(c, db.ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryResponse := newWebhookDeliveryResponse(db.WebhookDelivery{
+			ID:             delivery.ID,
+			CreatedAt:      delivery.CreatedAt,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastError:      delivery.LastError,
+			LastStatusCode: delivery.LastStatusCode,
+			DeliveredAt:    delivery.DeliveredAt,
+			UpdatedAt:      delivery.UpdatedAt,
+		})
+		deliveryResponse.EventType = delivery.EventType
+		response = append(response, deliveryResponse)
+	}
+
+	c.JSON(http.StatusOK, response)
+	return
+}
+
+// replayDeadWebhookDeliveries : queues the dead-lettered deliveries of the webhook again, e.g: once
+// the receiver is back up. Every replayed delivery gets a fresh set of attempts
+func (server *Server) replayDeadWebhookDeliveries(c *gin.Context) {
+	webhook, ok := server.ownWebhook(c)
+	if !ok {
+		return
+	}
+
+	replayed, err := server.store.ReplayDeadWebhookDeliveries(c, webhook.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+	return
+}
+
+type replayWebhookDeliveryRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// replayWebhookDelivery : queues a single delivery again whatever its status, so that a receiver
+// can also ask for an event it has already acknowledged
+func (server *Server) replayWebhookDelivery(c *gin.Context) {
+	var req replayWebhookDeliveryRequest
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	webhook, ok := server.ownWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(c, req.DeliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the delivery id is only looked up within the webhook, the deliveries of other webhooks are not found
+	if delivery.WebhookID != webhook.ID {
+		c.JSON(http.StatusNotFound, errorResponse(errors.New("no record found")))
+		return
+	}
+
+	delivery, err = server.store.ReplayWebhookDelivery(c, delivery.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newWebhookDeliveryResponse(delivery))
+	return
+}
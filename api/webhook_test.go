@@ -0,0 +1,460 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookAPI(t *testing.T) {
+	userID := utils.RandomInt(1, 1000)
+	webhook := randomWebhook(userID)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy Case - All OK : Every Event Type",
+			body: gin.H{"url": webhook.Url},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, userID, arg.UserID)
+						require.Equal(t, webhook.Url, arg.Url)
+						require.Empty(t, arg.EventTypes)
+						require.NotEmpty(t, arg.Secret)
+
+						webhook.Secret = arg.Secret
+						return webhook, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got createWebhookResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, webhook.ID, got.ID)
+				require.Equal(t, webhook.Url, got.URL)
+				require.Equal(t, webhook.Secret, got.Secret)
+				require.Equal(t, []string{}, got.EventTypes)
+			},
+		},
+		{
+			name: "Happy Case - All OK : Duplicate Event Types",
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": []string{db.EventTransferCredited, db.EventTransferCredited},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, []string{db.EventTransferCredited}, arg.EventTypes)
+						return webhook, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Unknown Event Type",
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": []string{"account.deleted"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Not An HTTP URL",
+			body: gin.H{"url": "ftp://example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Loopback Address",
+			body: gin.H{"url": "http://127.0.0.1:8080/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Cloud Metadata Address",
+			body: gin.H{"url": "http://169.254.169.254/latest/meta-data"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Localhost",
+			body: gin.H{"url": "http://localhost/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Missing URL",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Failure Case - Internal Server Error",
+			body: gin.H{"url": webhook.Url},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(1).Return(db.Webhook{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(userID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestManageWebhookAPI(t *testing.T) {
+	userID := utils.RandomInt(1, 1000)
+	webhook := randomWebhook(userID)
+	delivery := db.WebhookDelivery{
+		ID:        utils.RandomInt(1, 1000),
+		WebhookID: webhook.ID,
+		EventID:   utils.RandomInt(1, 1000),
+		Status:    db.DeliveryStatusDead,
+		Attempts:  8,
+		LastError: "unexpected response status 500: ",
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Happy Case - Get",
+			method: http.MethodGet,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// the secret is only returned on creation
+				require.NotContains(t, recorder.Body.String(), webhook.Secret)
+			},
+		},
+		{
+			name:   "Failure Case - Get Webhook Of Another User",
+			method: http.MethodGet,
+			userID: userID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "Failure Case - Get Not Found",
+			method: http.MethodGet,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(db.Webhook{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Happy Case - Delete",
+			method: http.MethodDelete,
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "Failure Case - Delete Webhook Of Another User",
+			method: http.MethodDelete,
+			userID: userID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "Happy Case - List Deliveries",
+			method: http.MethodGet,
+			path:   "/deliveries?page_id=1&page_size=5",
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListWebhookDeliveriesParams{
+					WebhookID: webhook.ID,
+					Limit:     5,
+					Offset:    0,
+				}
+				row := db.ListWebhookDeliveriesRow{
+					ID:        delivery.ID,
+					WebhookID: webhook.ID,
+					EventID:   delivery.EventID,
+					Status:    db.DeliveryStatusDead,
+					Attempts:  delivery.Attempts,
+					LastError: delivery.LastError,
+					EventType: db.EventTransferCredited,
+				}
+
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.ListWebhookDeliveriesRow{row}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []webhookDeliveryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, delivery.ID, got[0].ID)
+				require.Equal(t, db.EventTransferCredited, got[0].EventType)
+				require.Equal(t, db.DeliveryStatusDead, got[0].Status)
+				require.Nil(t, got[0].NextAttemptAt)
+			},
+		},
+		{
+			name:   "Failure Case - List Deliveries Invalid Page Size",
+			method: http.MethodGet,
+			path:   "/deliveries?page_id=1&page_size=500",
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Happy Case - Replay Dead Deliveries",
+			method: http.MethodPost,
+			path:   "/replay",
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().ReplayDeadWebhookDeliveries(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(int64(3), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"replayed": 3}`, recorder.Body.String())
+			},
+		},
+		{
+			name:   "Happy Case - Replay Delivery",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/deliveries/%d/replay", delivery.ID),
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				replayed := delivery
+				replayed.Status = db.DeliveryStatusPending
+				replayed.Attempts = 0
+				replayed.LastError = ""
+				replayed.NextAttemptAt = time.Now()
+
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(replayed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got webhookDeliveryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.DeliveryStatusPending, got.Status)
+				require.Zero(t, got.Attempts)
+				require.NotNil(t, got.NextAttemptAt)
+			},
+		},
+		{
+			name:   "Failure Case - Replay Delivery Of Another Webhook",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/deliveries/%d/replay", delivery.ID),
+			userID: userID,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := delivery
+				other.WebhookID = webhook.ID + 1
+
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(other, nil)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Failure Case - Replay Delivery Of Another User",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/deliveries/%d/replay", delivery.ID),
+			userID: userID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d%s", webhook.ID, tc.path)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(tc.userID), time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListWebhooksAPI(t *testing.T) {
+	userID := utils.RandomInt(1, 1000)
+
+	n := 5
+	webhooks := make([]db.Webhook, n)
+	for i := 0; i < n; i++ {
+		webhooks[i] = randomWebhook(userID)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.ListWebhooksParams{
+		UserID: userID,
+		Limit:  int32(n),
+		Offset: 0,
+	}
+	store.EXPECT().ListWebhooks(gomock.Any(), gomock.Eq(arg)).Times(1).Return(webhooks, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/webhooks?page_id=1&page_size=%d", n)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, uint(userID), time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []webhookResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Len(t, got, n)
+	for i, webhook := range got {
+		require.Equal(t, webhooks[i].ID, webhook.ID)
+		require.Equal(t, webhooks[i].Url, webhook.URL)
+	}
+}
+
+func randomWebhook(userID int64) db.Webhook {
+	return db.Webhook{
+		ID:         utils.RandomInt(1, 1000),
+		UserID:     userID,
+		Url:        fmt.Sprintf("https://%s.example.com/hooks", utils.RandomString(8)),
+		Secret:     utils.RandomString(32),
+		EventTypes: []string{},
+	}
+}
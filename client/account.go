@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/skamranahmed/banking-system/money"
+)
+
+type Account struct {
+	ID            int64       `json:"id"`
+	UserID        int64       `json:"user_id"`
+	Balance       money.Money `json:"balance"`
+	Currency      string      `json:"currency"`
+	Type          string      `json:"type"`
+	Nickname      string      `json:"nickname"`
+	AccountNumber string      `json:"account_number"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// AccountDetails : the balance is the ledger balance, the funds reserved by the active holds
+// are part of it but can't be transferred
+type AccountDetails struct {
+	Account
+	LedgerBalance    money.Money `json:"ledger_balance"`
+	AvailableBalance money.Money `json:"available_balance"`
+}
+
+type CreateAccountRequest struct {
+	Currency string `json:"currency"`
+	Type     string `json:"type,omitempty"` // defaults to checking
+	Nickname string `json:"nickname,omitempty"`
+}
+
+type UpdateAccountRequest struct {
+	Nickname string `json:"nickname"` // empty removes the nickname
+}
+
+// ListAccountsParams : the page size is between 5 and 10, the currency and the type are optional filters
+type ListAccountsParams struct {
+	PageID   int32
+	PageSize int32
+	Currency string
+	Type     string
+}
+
+// ListAccountEntriesParams : the page size is between 5 and 50
+type ListAccountEntriesParams struct {
+	PageID   int32
+	PageSize int32
+}
+
+// CreateAccount : opens an account for the authenticated user
+func (client *Client) CreateAccount(ctx context.Context, req CreateAccountRequest) (Account, error) {
+	var account Account
+	err := client.do(ctx, http.MethodPost, "/accounts", nil, req, &account)
+	return account, err
+}
+
+// ListAccounts : returns a page of the accounts of the authenticated user
+func (client *Client) ListAccounts(ctx context.Context, params ListAccountsParams) ([]Account, error) {
+	query := url.Values{}
+	query.Set("page_id", strconv.Itoa(int(params.PageID)))
+	query.Set("page_size", strconv.Itoa(int(params.PageSize)))
+	if params.Currency != "" {
+		query.Set("currency", params.Currency)
+	}
+	if params.Type != "" {
+		query.Set("type", params.Type)
+	}
+
+	accounts := make([]Account, 0)
+	err := client.do(ctx, http.MethodGet, "/accounts", query, nil, &accounts)
+	return accounts, err
+}
+
+// GetAccount : returns an account of the authenticated user along with its available balance
+func (client *Client) GetAccount(ctx context.Context, id int64) (AccountDetails, error) {
+	var account AccountDetails
+	err := client.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%d", id), nil, nil, &account)
+	return account, err
+}
+
+// UpdateAccount : changes the nickname of an account of the authenticated user
+func (client *Client) UpdateAccount(ctx context.Context, id int64, req UpdateAccountRequest) (Account, error) {
+	var account Account
+	err := client.do(ctx, http.MethodPatch, fmt.Sprintf("/accounts/%d", id), nil, req, &account)
+	return account, err
+}
+
+// ListAccountEntries : returns a page of the statement of an account of the authenticated user, oldest entry first
+func (client *Client) ListAccountEntries(ctx context.Context, id int64, params ListAccountEntriesParams) ([]Entry, error) {
+	query := url.Values{}
+	query.Set("page_id", strconv.Itoa(int(params.PageID)))
+	query.Set("page_size", strconv.Itoa(int(params.PageSize)))
+
+	entries := make([]Entry, 0)
+	err := client.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%d/entries", id), query, nil, &entries)
+	return entries, err
+}
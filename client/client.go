@@ -0,0 +1,146 @@
+// Package client is a typed Go client of the users, accounts and transfers routes of the HTTP API. It is
+// written by hand rather than generated from api/openapi.json, TestOpenAPISchemas checks that its types
+// keep the properties of the schemas they mirror
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client : typed client of the users, accounts and transfers routes of the HTTP API, the requests and
+// the responses are hand-written mirrors of the schemas of api/openapi.json
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+// New : returns a client of the API served at the base URL, e.g: http://localhost:8080,
+// the default HTTP client is used if httpClient is nil
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// WithToken : returns a copy of the client which authorizes its requests with the access token
+func (client *Client) WithToken(token string) *Client {
+	authorized := *client
+	authorized.token = token
+	return &authorized
+}
+
+// Token : returns the access token the requests are authorized with
+func (client *Client) Token() string {
+	return client.token
+}
+
+// PasswordViolation : a rule of the password policy that the password doesn't follow
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// BatchItemError : the reason a transfer of a batch is invalid
+type BatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// Error : returned for the responses with an error status, along with the details some of the routes respond with
+type Error struct {
+	StatusCode int                 `json:"-"`
+	Message    string              `json:"error"`
+	Violations []PasswordViolation `json:"violations,omitempty"` // the password doesn't meet the policy
+	Items      []BatchItemError    `json:"items,omitempty"`      // invalid transfers in the batch
+	RetryAfter time.Duration       `json:"-"`                    // to wait before retrying a rate limited request
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", err.StatusCode, http.StatusText(err.StatusCode), err.Message)
+}
+
+// retryAfter : returns the delay of the Retry-After header in seconds, 0 if there is none
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// do : sends the request with the body encoded as JSON if it isn't nil, and decodes the response into
+// the response if it isn't nil
+func (client *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, response interface{}) error {
+	target := client.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	resp, err := client.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header)}
+		err = json.Unmarshal(data, apiErr)
+		if err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(data, response)
+	if err != nil {
+		return fmt.Errorf("unable to decode the response of %s %s, err: %v", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest : what the test server received
+type recordedRequest struct {
+	Method        string
+	Path          string
+	Query         string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+// newTestClient : returns a client of a server which responds with the status and the body,
+// the requests it receives are recorded
+func newTestClient(t *testing.T, status int, body string) (*Client, *recordedRequest) {
+	recorded := &recordedRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded.Method = r.Method
+		recorded.Path = r.URL.Path
+		recorded.Query = r.URL.RawQuery
+		recorded.Authorization = r.Header.Get("Authorization")
+
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if len(data) > 0 {
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.Unmarshal(data, &recorded.Body))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return New(server.URL+"/", server.Client()), recorded
+}
+
+func TestCreateUser(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusCreated, `{
+		"username": "alice", "full_name": "Alice", "email": "alice@example.com",
+		"is_email_verified": false, "is_totp_enabled": false, "created_at": "2023-01-02T03:04:05Z"
+	}`)
+
+	user, err := client.CreateUser(context.Background(), CreateUserRequest{
+		Username: "alice",
+		Password: "secret123",
+		FullName: "Alice",
+		Email:    "alice@example.com",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, http.MethodPost, recorded.Method)
+	require.Equal(t, "/users", recorded.Path)
+	require.Empty(t, recorded.Authorization)
+	require.Equal(t, map[string]interface{}{
+		"username":  "alice",
+		"password":  "secret123",
+		"full_name": "Alice",
+		"email":     "alice@example.com",
+	}, recorded.Body)
+
+	require.Equal(t, "alice", user.Username)
+	require.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), user.CreatedAt)
+}
+
+func TestListAccounts(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, `[{
+		"id": 1, "user_id": 2, "balance": {"amount": "12.30", "currency": "USD"}, "currency": "USD",
+		"type": "savings", "nickname": "rainy day", "account_number": "1234567890", "created_at": "2023-01-02T03:04:05Z"
+	}]`)
+
+	accounts, err := client.WithToken("token").ListAccounts(context.Background(), ListAccountsParams{
+		PageID:   1,
+		PageSize: 5,
+		Type:     "savings",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, http.MethodGet, recorded.Method)
+	require.Equal(t, "/accounts", recorded.Path)
+	require.Equal(t, "page_id=1&page_size=5&type=savings", recorded.Query)
+	require.Equal(t, "Bearer token", recorded.Authorization)
+	require.Nil(t, recorded.Body)
+
+	require.Len(t, accounts, 1)
+	require.Equal(t, int64(1230), accounts[0].Balance.Amount)
+	require.Equal(t, "USD", accounts[0].Balance.Currency.Code)
+	require.Equal(t, "savings", accounts[0].Type)
+}
+
+func TestCreateTransfer(t *testing.T) {
+	amount := `{"amount": "10.00", "currency": "USD"}`
+	client, recorded := newTestClient(t, http.StatusOK, `{
+		"transfer": {"id": 3, "from_account_id": 1, "to_account_id": 2, "amount": `+amount+`, "gross": `+amount+`,
+			"fee": {"amount": "0.00", "currency": "USD"}, "net": `+amount+`, "created_at": "2023-01-02T03:04:05Z"},
+		"from_account": {"id": 1}, "to_account": {"id": 2},
+		"from_entry": {"id": 4, "account_id": 1, "amount": {"amount": "-10.00", "currency": "USD"}},
+		"to_entry": {"id": 5, "account_id": 2, "amount": `+amount+`}
+	}`)
+
+	result, err := client.WithToken("token").CreateTransfer(context.Background(), TransferRequest{
+		FromAccountID:   1,
+		ToAccountNumber: "1234567890",
+		Amount:          "10.00",
+		Currency:        "USD",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, http.MethodPost, recorded.Method)
+	require.Equal(t, "/transfers", recorded.Path)
+	require.Equal(t, map[string]interface{}{
+		"from_account_id":   float64(1),
+		"to_account_number": "1234567890",
+		"amount":            "10.00",
+		"currency":          "USD",
+	}, recorded.Body)
+
+	require.Equal(t, int64(3), result.Transfer.ID)
+	require.Equal(t, money.New(1000, money.Currency{Code: "USD", MinorUnits: 2}), result.Transfer.Net)
+	require.Equal(t, int64(-1000), result.FromEntry.Amount.Amount)
+}
+
+func TestError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		checkResponse func(t *testing.T, err *Error)
+	}{
+		{
+			name:   "NotFound",
+			status: http.StatusNotFound,
+			body:   `{"error": "sql: no rows in result set"}`,
+			checkResponse: func(t *testing.T, err *Error) {
+				require.Equal(t, http.StatusNotFound, err.StatusCode)
+				require.Equal(t, "sql: no rows in result set", err.Message)
+				require.Equal(t, "404 Not Found: sql: no rows in result set", err.Error())
+			},
+		},
+		{
+			name:   "PasswordPolicy",
+			status: http.StatusBadRequest,
+			body:   `{"error": "password doesn't meet the policy", "violations": [{"code": "min_length", "message": "too short"}]}`,
+			checkResponse: func(t *testing.T, err *Error) {
+				require.Equal(t, http.StatusBadRequest, err.StatusCode)
+				require.Equal(t, []PasswordViolation{{Code: "min_length", Message: "too short"}}, err.Violations)
+			},
+		},
+		{
+			name:   "NotJSON",
+			status: http.StatusBadGateway,
+			body:   "bad gateway\n",
+			checkResponse: func(t *testing.T, err *Error) {
+				require.Equal(t, http.StatusBadGateway, err.StatusCode)
+				require.Equal(t, "bad gateway", err.Message)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := newTestClient(t, tc.status, tc.body)
+
+			_, err := client.GetAccount(context.Background(), 1)
+			require.Error(t, err)
+
+			apiErr, ok := err.(*Error)
+			require.True(t, ok)
+			tc.checkResponse(t, apiErr)
+		})
+	}
+}
+
+func TestErrorRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "42")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "too many requests, try again later"}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, server.Client()).LoginUser(context.Background(), LoginUserRequest{Username: "alice", Password: "secret123"})
+
+	apiErr, ok := err.(*Error)
+	require.True(t, ok)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	require.Equal(t, 42*time.Second, apiErr.RetryAfter)
+}
+
+// TestOpenAPISchemas : the types of the client have the properties of the schemas they mirror
+func TestOpenAPISchemas(t *testing.T) {
+	data, err := os.ReadFile("../api/openapi.json")
+	require.NoError(t, err)
+
+	var document struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	types := map[string]interface{}{
+		"User":                    User{},
+		"CreateUserRequest":       CreateUserRequest{},
+		"LoginUserRequest":        LoginUserRequest{},
+		"LoginUserResponse":       LoginUserResponse{},
+		"LoginUserTOTPRequest":    LoginUserTOTPRequest{},
+		"ForgotPasswordRequest":   ForgotPasswordRequest{},
+		"ResetPasswordRequest":    ResetPasswordRequest{},
+		"ChangePasswordRequest":   ChangePasswordRequest{},
+		"UpdateUserRequest":       UpdateUserRequest{},
+		"EnrollTOTPResponse":      EnrollTOTPResponse{},
+		"VerifyTOTPRequest":       VerifyTOTPRequest{},
+		"VerifyTOTPResponse":      VerifyTOTPResponse{},
+		"Message":                 Message{},
+		"PasswordViolation":       PasswordViolation{},
+		"Account":                 Account{},
+		"AccountDetails":          AccountDetails{},
+		"CreateAccountRequest":    CreateAccountRequest{},
+		"UpdateAccountRequest":    UpdateAccountRequest{},
+		"TransferRequest":         TransferRequest{},
+		"Transfer":                Transfer{},
+		"Entry":                   Entry{},
+		"TransferResult":          TransferResult{},
+		"BatchTransferRequest":    BatchTransferRequest{},
+		"BatchTransferItem":       BatchTransferItem{},
+		"BatchTransferItemResult": BatchTransferItemResult{},
+		"BatchTransferResult":     BatchTransferResult{},
+		"BatchItemError":          BatchItemError{},
+	}
+
+	for name, value := range types {
+		schema, ok := document.Components.Schemas[name]
+		require.True(t, ok, "schema %s isn't in the spec", name)
+
+		properties := make([]string, 0, len(schema.Properties))
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+
+		require.Equal(t, properties, jsonFields(reflect.TypeOf(value)), "properties of %s", name)
+	}
+}
+
+// jsonFields : returns the sorted JSON names of the fields of the struct, the fields of the embedded
+// structs are flattened like encoding/json does
+func jsonFields(typ reflect.Type) []string {
+	fields := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		fields = append(fields, name)
+	}
+
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/skamranahmed/banking-system/money"
+)
+
+// TransferRequest : the account to which the money is getting credited is addressed by exactly one of
+// `to_account_number`, `to_username` or `beneficiary_id`
+type TransferRequest struct {
+	FromAccountID   int64  `json:"from_account_id"`
+	ToAccountNumber string `json:"to_account_number,omitempty"`
+	ToUsername      string `json:"to_username,omitempty"`
+	BeneficiaryID   int64  `json:"beneficiary_id,omitempty"`
+	Amount          string `json:"amount"` // decimal string in the major unit of the currency, e.g. "12.50"
+	Currency        string `json:"currency"`
+	OTPCode         string `json:"otp_code,omitempty"` // required for the transfers over the step up threshold
+}
+
+type Transfer struct {
+	ID            int64       `json:"id"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        money.Money `json:"amount"` // same as the gross amount
+	Gross         money.Money `json:"gross"`  // debited from the `from account`
+	Fee           money.Money `json:"fee"`    // charged by the bank out of the gross amount
+	Net           money.Money `json:"net"`    // credited to the `to account`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type Entry struct {
+	ID        int64       `json:"id"`
+	AccountID int64       `json:"account_id"`
+	Amount    money.Money `json:"amount"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type TransferResult struct {
+	Transfer    Transfer `json:"transfer"`
+	FromAccount Account  `json:"from_account"`
+	ToAccount   Account  `json:"to_account"`
+	FromEntry   Entry    `json:"from_entry"`
+	ToEntry     Entry    `json:"to_entry"`
+}
+
+// batch modes
+const (
+	BatchModeAtomic     = "atomic"      // all the transfers succeed or fail together
+	BatchModeBestEffort = "best_effort" // every transfer has its own result
+)
+
+type BatchTransferRequest struct {
+	FromAccountID int64               `json:"from_account_id"`
+	Currency      string              `json:"currency"`
+	Mode          string              `json:"mode"`
+	OTPCode       string              `json:"otp_code,omitempty"` // required if the total is over the step up threshold
+	Items         []BatchTransferItem `json:"items"`
+}
+
+type BatchTransferItem struct {
+	ToAccountNumber string `json:"to_account_number"`
+	Amount          string `json:"amount"`              // decimal string in the major unit of the currency, e.g. "12.50"
+	Reference       string `json:"reference,omitempty"` // echoed back in the result, e.g: an employee ID
+}
+
+type BatchTransferItemResult struct {
+	Index     int       `json:"index"`
+	Reference string    `json:"reference"`
+	Status    string    `json:"status"` // succeeded or failed
+	Error     string    `json:"error,omitempty"`
+	Transfer  *Transfer `json:"transfer,omitempty"`
+}
+
+type BatchTransferResult struct {
+	Mode      string                    `json:"mode"`
+	Total     money.Money               `json:"total"` // gross amount of the succeeded transfers
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+	Results   []BatchTransferItemResult `json:"results"`
+}
+
+// CreateTransfer : transfers money from an account of the authenticated user
+func (client *Client) CreateTransfer(ctx context.Context, req TransferRequest) (TransferResult, error) {
+	var result TransferResult
+	err := client.do(ctx, http.MethodPost, "/transfers", nil, req, &result)
+	return result, err
+}
+
+// CreateBatchTransfer : sends the transfers of the batch from one account, the invalid transfers
+// are listed in the items of the returned *Error
+func (client *Client) CreateBatchTransfer(ctx context.Context, req BatchTransferRequest) (BatchTransferResult, error) {
+	var result BatchTransferResult
+	err := client.do(ctx, http.MethodPost, "/transfers/batch", nil, req, &result)
+	return result, err
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type User struct {
+	Username        string    `json:"username"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	IsTotpEnabled   bool      `json:"is_totp_enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+type LoginUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginUserResponse : the users with two factor authentication enabled get a challenge token instead of
+// the access token, it is exchanged for an access token by LoginUserTOTP
+type LoginUserResponse struct {
+	AccessToken       string `json:"access_token,omitempty"`
+	User              *User  `json:"user,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+// LoginUserTOTPRequest : the code is required unless a recovery code is provided
+type LoginUserTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// UpdateUserRequest : the nil fields are left as they are
+type UpdateUserRequest struct {
+	FullName        *string `json:"full_name,omitempty"`
+	Email           *string `json:"email,omitempty"`
+	CurrentPassword string  `json:"current_password,omitempty"` // required to change the email
+}
+
+type EnrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type VerifyTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type VerifyTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type Message struct {
+	Message string `json:"message"`
+}
+
+// CreateUser : signs up a new user, a verification link is sent to the email
+func (client *Client) CreateUser(ctx context.Context, req CreateUserRequest) (User, error) {
+	var user User
+	err := client.do(ctx, http.MethodPost, "/users", nil, req, &user)
+	return user, err
+}
+
+// LoginUser : logs in with the username and the password, see WithToken for using the access token
+func (client *Client) LoginUser(ctx context.Context, req LoginUserRequest) (LoginUserResponse, error) {
+	var resp LoginUserResponse
+	err := client.do(ctx, http.MethodPost, "/users/login", nil, req, &resp)
+	return resp, err
+}
+
+// LoginUserTOTP : exchanges the challenge token of LoginUser and a one time password for an access token
+func (client *Client) LoginUserTOTP(ctx context.Context, req LoginUserTOTPRequest) (LoginUserResponse, error) {
+	var resp LoginUserResponse
+	err := client.do(ctx, http.MethodPost, "/users/login/2fa", nil, req, &resp)
+	return resp, err
+}
+
+// ForgotPassword : sends a password reset link, the response doesn't reveal whether the email exists
+func (client *Client) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) (Message, error) {
+	var resp Message
+	err := client.do(ctx, http.MethodPost, "/users/password/forgot", nil, req, &resp)
+	return resp, err
+}
+
+// ResetPassword : resets the password with the token of the reset link
+func (client *Client) ResetPassword(ctx context.Context, req ResetPasswordRequest) (Message, error) {
+	var resp Message
+	err := client.do(ctx, http.MethodPost, "/users/password/reset", nil, req, &resp)
+	return resp, err
+}
+
+// GetCurrentUser : returns the authenticated user
+func (client *Client) GetCurrentUser(ctx context.Context) (User, error) {
+	var user User
+	err := client.do(ctx, http.MethodGet, "/users/me", nil, nil, &user)
+	return user, err
+}
+
+// UpdateCurrentUser : updates the profile of the authenticated user, a changed email has to be verified again
+func (client *Client) UpdateCurrentUser(ctx context.Context, req UpdateUserRequest) (User, error) {
+	var user User
+	err := client.do(ctx, http.MethodPatch, "/users/me", nil, req, &user)
+	return user, err
+}
+
+// ChangePassword : changes the password, the previously issued tokens get revoked so a new access token is returned
+func (client *Client) ChangePassword(ctx context.Context, req ChangePasswordRequest) (LoginUserResponse, error) {
+	var resp LoginUserResponse
+	err := client.do(ctx, http.MethodPatch, "/users/me/password", nil, req, &resp)
+	return resp, err
+}
+
+// ResendVerificationEmail : sends a new verification link to the email of the authenticated user
+func (client *Client) ResendVerificationEmail(ctx context.Context) (Message, error) {
+	var resp Message
+	err := client.do(ctx, http.MethodPost, "/users/me/email/verify", nil, nil, &resp)
+	return resp, err
+}
+
+// EnrollTOTP : starts enrolling an authenticator app for two factor authentication
+func (client *Client) EnrollTOTP(ctx context.Context) (EnrollTOTPResponse, error) {
+	var resp EnrollTOTPResponse
+	err := client.do(ctx, http.MethodPost, "/users/me/totp", nil, nil, &resp)
+	return resp, err
+}
+
+// VerifyTOTP : enables two factor authentication with a one time password of the enrolled app
+func (client *Client) VerifyTOTP(ctx context.Context, req VerifyTOTPRequest) (VerifyTOTPResponse, error) {
+	var resp VerifyTOTPResponse
+	err := client.do(ctx, http.MethodPost, "/users/me/totp/verify", nil, req, &resp)
+	return resp, err
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/skamranahmed/banking-system/client"
+)
+
+// login : stores the access token along with the server for the following commands
+func (c *cli) login(ctx context.Context, args []string) error {
+	flags := newFlagSet("login")
+	username := flags.String("username", "", "")
+	otp := flags.String("otp", "", "one time password, if two factor authentication is enabled")
+	err := flags.Parse(args)
+	if err != nil || *username == "" || flags.NArg() > 0 {
+		return errors.New("usage: bankctl login -username NAME [-otp CODE]")
+	}
+
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.LoginUser(ctx, client.LoginUserRequest{Username: *username, Password: password})
+	if err != nil {
+		return err
+	}
+
+	if resp.TwoFactorRequired {
+		if *otp == "" {
+			return errors.New("two factor authentication is enabled, provide the one time password with -otp")
+		}
+
+		resp, err = c.client.LoginUserTOTP(ctx, client.LoginUserTOTPRequest{ChallengeToken: resp.ChallengeToken, Code: *otp})
+		if err != nil {
+			return err
+		}
+	}
+
+	c.credentials.Username = *username
+	c.credentials.Token = resp.AccessToken
+	err = saveCredentials(c.credentialsPath, c.credentials)
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		return printJSON(c.stdout, resp.User)
+	}
+	fmt.Fprintf(c.stdout, "logged in to %s as %s\n", c.credentials.Server, *username)
+	return nil
+}
+
+func (c *cli) logout() error {
+	err := removeCredentials(c.credentialsPath)
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputTable {
+		fmt.Fprintln(c.stdout, "logged out")
+	}
+	return nil
+}
+
+const accountsUsage = "usage: bankctl accounts create -currency CODE [-type TYPE] [-nickname NAME] | list [-page N] [-page-size N] [-currency CODE] [-type TYPE]"
+
+// accounts : handles `accounts create|list`
+func (c *cli) accounts(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(accountsUsage)
+	}
+
+	err := c.requireLogin()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		flags := newFlagSet("accounts create")
+		currency := flags.String("currency", "", "")
+		accountType := flags.String("type", "", "checking, savings or wallet, defaults to checking")
+		nickname := flags.String("nickname", "", "")
+		err := flags.Parse(args[1:])
+		if err != nil || *currency == "" || flags.NArg() > 0 {
+			return errors.New(accountsUsage)
+		}
+
+		account, err := c.client.CreateAccount(ctx, client.CreateAccountRequest{
+			Currency: *currency,
+			Type:     *accountType,
+			Nickname: *nickname,
+		})
+		if err != nil {
+			return err
+		}
+
+		if c.output == outputJSON {
+			return printJSON(c.stdout, account)
+		}
+		return printAccounts(c.stdout, []client.Account{account})
+
+	case "list":
+		flags := newFlagSet("accounts list")
+		page := flags.Int("page", 1, "")
+		pageSize := flags.Int("page-size", 10, "between 5 and 10")
+		currency := flags.String("currency", "", "")
+		accountType := flags.String("type", "", "")
+		err := flags.Parse(args[1:])
+		if err != nil || flags.NArg() > 0 {
+			return errors.New(accountsUsage)
+		}
+
+		accounts, err := c.client.ListAccounts(ctx, client.ListAccountsParams{
+			PageID:   int32(*page),
+			PageSize: int32(*pageSize),
+			Currency: *currency,
+			Type:     *accountType,
+		})
+		if err != nil {
+			return err
+		}
+
+		if c.output == outputJSON {
+			return printJSON(c.stdout, accounts)
+		}
+		return printAccounts(c.stdout, accounts)
+	}
+
+	return fmt.Errorf("unknown accounts command %q, %s", args[0], accountsUsage)
+}
+
+// statement : the balances of an account followed by a page of its entries
+func (c *cli) statement(ctx context.Context, args []string) error {
+	const statementUsage = "usage: bankctl statement [-page N] [-page-size N] ACCOUNT_ID"
+
+	flags := newFlagSet("statement")
+	page := flags.Int("page", 1, "")
+	pageSize := flags.Int("page-size", 50, "between 5 and 50")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() != 1 {
+		return errors.New(statementUsage)
+	}
+
+	id, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account id %q, %s", flags.Arg(0), statementUsage)
+	}
+
+	err = c.requireLogin()
+	if err != nil {
+		return err
+	}
+
+	account, err := c.client.GetAccount(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.client.ListAccountEntries(ctx, id, client.ListAccountEntriesParams{
+		PageID:   int32(*page),
+		PageSize: int32(*pageSize),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		return printJSON(c.stdout, struct {
+			Account client.AccountDetails `json:"account"`
+			Entries []client.Entry        `json:"entries"`
+		}{account, entries})
+	}
+	return printStatement(c.stdout, account, entries)
+}
+
+// transfer : sends money from an account of the logged in user
+func (c *cli) transfer(ctx context.Context, args []string) error {
+	const transferUsage = "usage: bankctl transfer -from ACCOUNT_ID (-to-account NUMBER | -to-user NAME | -beneficiary ID) -amount AMOUNT -currency CODE [-otp CODE]"
+
+	flags := newFlagSet("transfer")
+	from := flags.Int64("from", 0, "")
+	toAccount := flags.String("to-account", "", "")
+	toUser := flags.String("to-user", "", "")
+	beneficiary := flags.Int64("beneficiary", 0, "")
+	amount := flags.String("amount", "", "decimal amount, e.g: 12.50")
+	currency := flags.String("currency", "", "")
+	otp := flags.String("otp", "", "one time password, required over the step up threshold")
+	err := flags.Parse(args)
+	if err != nil || *from == 0 || *amount == "" || *currency == "" || flags.NArg() > 0 {
+		return errors.New(transferUsage)
+	}
+
+	err = c.requireLogin()
+	if err != nil {
+		return err
+	}
+
+	result, err := c.client.CreateTransfer(ctx, client.TransferRequest{
+		FromAccountID:   *from,
+		ToAccountNumber: *toAccount,
+		ToUsername:      *toUser,
+		BeneficiaryID:   *beneficiary,
+		Amount:          *amount,
+		Currency:        *currency,
+		OTPCode:         *otp,
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		return printJSON(c.stdout, result)
+	}
+	return printTransfer(c.stdout, result)
+}
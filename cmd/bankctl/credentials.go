@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// credentials : stored by login so that the following commands are authorized, the file is only
+// readable by its owner since the token grants access to the accounts
+type credentials struct {
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// defaultCredentialsPath : the credentials are kept in the user's config directory, e.g: ~/.config/bankctl
+func defaultCredentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bankctl", "credentials.json"), nil
+}
+
+// loadCredentials : returns empty credentials if none have been stored yet
+func loadCredentials(path string) (credentials, error) {
+	var creds credentials
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return creds, nil
+		}
+		return creds, err
+	}
+
+	err = json.Unmarshal(data, &creds)
+	return creds, err
+}
+
+func saveCredentials(path string, creds credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+// removeCredentials : logging out twice isn't an error
+func removeCredentials(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/skamranahmed/banking-system/client"
+)
+
+const usage = `usage: bankctl [-server URL] [-output table|json] [-credentials FILE] <command> [flags]
+
+commands:
+  login -username NAME [-otp CODE]       log in, the password is read from BANKCTL_PASSWORD or stdin
+  logout                                 forget the stored access token
+  accounts create -currency CODE [-type TYPE] [-nickname NAME]
+  accounts list [-page N] [-page-size N] [-currency CODE] [-type TYPE]
+  statement [-page N] [-page-size N] ACCOUNT_ID
+  transfer -from ACCOUNT_ID (-to-account NUMBER | -to-user NAME | -beneficiary ID) -amount AMOUNT -currency CODE [-otp CODE]`
+
+// defaultServer : used unless the server is provided by the flag or was stored by login
+const defaultServer = "http://localhost:8080"
+
+// output modes
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bankctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// cli : the state shared by the commands
+type cli struct {
+	client          *client.Client
+	credentials     credentials
+	credentialsPath string
+	output          string
+	stdin           io.Reader
+	stdout          io.Writer
+}
+
+// run : parses the global flags and runs the command
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	credentialsPath, err := defaultCredentialsPath()
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("bankctl", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	server := flags.String("server", "", "base URL of the API, defaults to the one logged in to")
+	output := flags.String("output", outputTable, "output mode, table or json")
+	flags.StringVar(&credentialsPath, "credentials", credentialsPath, "file storing the access token")
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, usage)
+	}
+
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("invalid output mode %q, %s", *output, usage)
+	}
+
+	if flags.NArg() == 0 {
+		return errors.New(usage)
+	}
+
+	creds, err := loadCredentials(credentialsPath)
+	if err != nil {
+		return err
+	}
+
+	// a server provided by the flag doesn't take the token of another server
+	if *server != "" && strings.TrimRight(*server, "/") != creds.Server {
+		creds = credentials{Server: strings.TrimRight(*server, "/")}
+	}
+	if creds.Server == "" {
+		creds.Server = defaultServer
+	}
+
+	c := &cli{
+		client:          client.New(creds.Server, nil).WithToken(creds.Token),
+		credentials:     creds,
+		credentialsPath: credentialsPath,
+		output:          *output,
+		stdin:           stdin,
+		stdout:          stdout,
+	}
+
+	name, args := flags.Arg(0), flags.Args()[1:]
+	switch name {
+	case "login":
+		return c.login(ctx, args)
+	case "logout":
+		return c.logout()
+	case "accounts":
+		return c.accounts(ctx, args)
+	case "statement":
+		return c.statement(ctx, args)
+	case "transfer":
+		return c.transfer(ctx, args)
+	}
+
+	return fmt.Errorf("unknown command %q\n%s", name, usage)
+}
+
+// newFlagSet : the flags of a command, the errors are returned to run instead of being printed
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// requireLogin : fails the commands of the authenticated routes before calling them without a token
+func (c *cli) requireLogin() error {
+	if c.credentials.Token == "" {
+		return fmt.Errorf("not logged in to %s, run `bankctl login` first", c.credentials.Server)
+	}
+	return nil
+}
+
+// readPassword : reads the password from BANKCTL_PASSWORD, or else the first line of stdin
+func (c *cli) readPassword() (string, error) {
+	password, ok := os.LookupEnv("BANKCTL_PASSWORD")
+	if ok {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password provided on stdin or BANKCTL_PASSWORD")
+	}
+	return password, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "access-token"
+
+const testAccounts = `[{
+	"id": 7, "user_id": 1, "balance": {"amount": "12.30", "currency": "USD"}, "currency": "USD",
+	"type": "savings", "nickname": "rainy day", "account_number": "1234567890", "created_at": "2023-01-02T03:04:05Z"
+}]`
+
+// newTestAPI : serves the routes used by the commands, the authenticated ones require the test token
+func newTestAPI(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/users/login", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		if req.Password != "secret123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid username or password"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "` + testToken + `", "user": {"username": "` + req.Username + `"}}`))
+	})
+
+	authorized := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "authorization header is not provided"}`))
+				return
+			}
+			handler(w, r)
+		}
+	}
+
+	mux.HandleFunc("/accounts", authorized(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "page_id=1&page_size=10", r.URL.RawQuery)
+		w.Write([]byte(testAccounts))
+	}))
+
+	mux.HandleFunc("/accounts/7", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 7, "account_number": "1234567890",
+			"ledger_balance": {"amount": "12.30", "currency": "USD"}, "available_balance": {"amount": "10.00", "currency": "USD"}}`))
+	}))
+
+	mux.HandleFunc("/accounts/7/entries", authorized(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "page_id=1&page_size=50", r.URL.RawQuery)
+		w.Write([]byte(`[{"id": 3, "account_id": 7, "amount": {"amount": "-2.30", "currency": "USD"}, "created_at": "2023-01-02T03:04:05Z"}]`))
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runTest : runs the command with the credentials kept in a temporary directory
+func runTest(t *testing.T, credentialsPath string, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	args = append([]string{"-credentials", credentialsPath}, args...)
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout)
+	return stdout.String(), err
+}
+
+func TestLogin(t *testing.T) {
+	server := newTestAPI(t)
+	credentialsPath := filepath.Join(t.TempDir(), "bankctl", "credentials.json")
+
+	_, err := runTest(t, credentialsPath, "wrong\n", "-server", server.URL, "login", "-username", "alice")
+	require.EqualError(t, err, "401 Unauthorized: invalid username or password")
+
+	_, err = os.Stat(credentialsPath)
+	require.True(t, os.IsNotExist(err))
+
+	output, err := runTest(t, credentialsPath, "secret123\n", "-server", server.URL, "login", "-username", "alice")
+	require.NoError(t, err)
+	require.Equal(t, "logged in to "+server.URL+" as alice\n", output)
+
+	info, err := os.Stat(credentialsPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	creds, err := loadCredentials(credentialsPath)
+	require.NoError(t, err)
+	require.Equal(t, credentials{Server: server.URL, Username: "alice", Token: testToken}, creds)
+
+	// the stored server is used without the flag
+	_, err = runTest(t, credentialsPath, "", "accounts", "list")
+	require.NoError(t, err)
+
+	_, err = runTest(t, credentialsPath, "", "logout")
+	require.NoError(t, err)
+
+	_, err = runTest(t, credentialsPath, "", "accounts", "list")
+	require.EqualError(t, err, "not logged in to "+defaultServer+", run `bankctl login` first")
+}
+
+func TestAccountsList(t *testing.T) {
+	server := newTestAPI(t)
+	credentialsPath := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, saveCredentials(credentialsPath, credentials{Server: server.URL, Token: testToken}))
+
+	output, err := runTest(t, credentialsPath, "", "accounts", "list")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"ID  NUMBER      TYPE     NICKNAME   BALANCE    CREATED\n"+
+		"7   1234567890  savings  rainy day  12.30 USD  2023-01-02T03:04:05Z\n", output)
+
+	output, err = runTest(t, credentialsPath, "", "-output", "json", "accounts", "list")
+	require.NoError(t, err)
+	require.JSONEq(t, testAccounts, output)
+}
+
+func TestStatement(t *testing.T) {
+	server := newTestAPI(t)
+	credentialsPath := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, saveCredentials(credentialsPath, credentials{Server: server.URL, Token: testToken}))
+
+	output, err := runTest(t, credentialsPath, "", "statement", "7")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"ACCOUNT            7 (1234567890)\n"+
+		"LEDGER BALANCE     12.30 USD\n"+
+		"AVAILABLE BALANCE  10.00 USD\n"+
+		"\n"+
+		"ENTRY  DATE                  AMOUNT\n"+
+		"3      2023-01-02T03:04:05Z  -2.30 USD\n", output)
+
+	_, err = runTest(t, credentialsPath, "", "statement", "seven")
+	require.Error(t, err)
+}
+
+func TestUsage(t *testing.T) {
+	credentialsPath := filepath.Join(t.TempDir(), "credentials.json")
+
+	_, err := runTest(t, credentialsPath, "")
+	require.EqualError(t, err, usage)
+
+	_, err = runTest(t, credentialsPath, "", "-output", "yaml", "logout")
+	require.Error(t, err)
+
+	_, err = runTest(t, credentialsPath, "", "unknown")
+	require.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skamranahmed/banking-system/client"
+)
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func newTableWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+func printAccounts(w io.Writer, accounts []client.Account) error {
+	tw := newTableWriter(w)
+	fmt.Fprintln(tw, "ID\tNUMBER\tTYPE\tNICKNAME\tBALANCE\tCREATED")
+	for _, account := range accounts {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			account.ID,
+			account.AccountNumber,
+			account.Type,
+			account.Nickname,
+			account.Balance,
+			account.CreatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+func printStatement(w io.Writer, account client.AccountDetails, entries []client.Entry) error {
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, "ACCOUNT\t%d (%s)\n", account.ID, account.AccountNumber)
+	fmt.Fprintf(tw, "LEDGER BALANCE\t%s\n", account.LedgerBalance)
+	fmt.Fprintf(tw, "AVAILABLE BALANCE\t%s\n", account.AvailableBalance)
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+
+	tw = newTableWriter(w)
+	fmt.Fprintln(tw, "ENTRY\tDATE\tAMOUNT")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", entry.ID, entry.CreatedAt.Format(time.RFC3339), entry.Amount)
+	}
+	return tw.Flush()
+}
+
+func printTransfer(w io.Writer, result client.TransferResult) error {
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, "TRANSFER\t%d\n", result.Transfer.ID)
+	fmt.Fprintf(tw, "FROM\t%d (%s)\n", result.FromAccount.ID, result.FromAccount.AccountNumber)
+	fmt.Fprintf(tw, "TO\t%s\n", result.ToAccount.AccountNumber)
+	fmt.Fprintf(tw, "GROSS\t%s\n", result.Transfer.Gross)
+	fmt.Fprintf(tw, "FEE\t%s\n", result.Transfer.Fee)
+	fmt.Fprintf(tw, "NET\t%s\n", result.Transfer.Net)
+	fmt.Fprintf(tw, "BALANCE\t%s\n", result.FromAccount.Balance)
+	return tw.Flush()
+}
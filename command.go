@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skamranahmed/banking-system/config"
+	"github.com/skamranahmed/banking-system/db/migration"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/interest"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/seed"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/skamranahmed/banking-system/webhook"
+)
+
+const (
+	migrateUsage  = "usage: main migrate up|down [N|all]|status|version"
+	interestUsage = "usage: main interest accrue [YYYY-MM-DD]|post [YYYY-MM]"
+	holdsUsage    = "usage: main holds expire"
+	webhooksUsage = "usage: main webhooks dispatch"
+	seedUsage     = "usage: main seed [-seed N] [-users N] [-currencies USD,INR] [-accounts-per-currency N] [-transfers N] [-password PASSWORD]"
+)
+
+// runCommand : dispatches the subcommand provided on the command line
+func runCommand(conn *sql.DB, name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrateCommand(conn, args)
+	case "interest":
+		return runInterestCommand(conn, args)
+	case "holds":
+		return runHoldsCommand(conn, args)
+	case "webhooks":
+		return runWebhooksCommand(conn, args)
+	case "admin":
+		return runAdminCommand(conn, args)
+	case "seed":
+		return runSeedCommand(conn, args)
+	case "load":
+		return runLoadCommand(conn, args)
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// runMigrateCommand : handles `migrate up|down|status|version`
+func runMigrateCommand(conn *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+
+	migrator, err := migration.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(ctx, migrator)
+
+	case "down":
+		// only the last migration is reverted unless asked otherwise
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = 0
+			} else {
+				steps, err = strconv.Atoi(args[1])
+				if err != nil || steps < 1 {
+					return fmt.Errorf("invalid number of steps %q, %s", args[1], migrateUsage)
+				}
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			if err == migration.ErrNoChange {
+				fmt.Println("no migration to revert")
+				return nil
+			}
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, state)
+		}
+		return w.Flush()
+
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+			return nil
+		}
+		fmt.Println(version)
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+}
+
+// runInterestCommand : handles `interest accrue|post`, meant to be scheduled daily and monthly.
+// By default the previous day is accrued and the previous month is posted
+func runInterestCommand(conn *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(interestUsage)
+	}
+
+	ctx := context.Background()
+
+	engine := interest.NewEngine(db.NewStore(conn), interest.Rates{
+		utils.AccountTypeChecking: config.InterestRateChecking,
+		utils.AccountTypeSavings:  config.InterestRateSavings,
+		utils.AccountTypeWallet:   config.InterestRateWallet,
+	})
+
+	now := time.Now().UTC()
+
+	switch args[0] {
+	case "accrue":
+		day := now.AddDate(0, 0, -1)
+		if len(args) > 1 {
+			var err error
+			day, err = time.Parse("2006-01-02", args[1])
+			if err != nil {
+				return fmt.Errorf("invalid day %q, %s", args[1], interestUsage)
+			}
+		}
+
+		report, err := engine.Accrue(ctx, day)
+		if err != nil {
+			if err == interest.ErrAlreadyCompleted {
+				fmt.Printf("interest already accrued for %s\n", report.Period)
+				return nil
+			}
+			return err
+		}
+		fmt.Printf("accrued the interest of %d account(s) for %s, skipped %d\n", report.Accounts, report.Period, report.Skipped)
+		return nil
+
+	case "post":
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		if len(args) > 1 {
+			var err error
+			month, err = time.Parse("2006-01", args[1])
+			if err != nil {
+				return fmt.Errorf("invalid month %q, %s", args[1], interestUsage)
+			}
+		}
+
+		report, err := engine.Post(ctx, month)
+		if err != nil {
+			if err == interest.ErrAlreadyCompleted {
+				fmt.Printf("interest already posted for %s\n", report.Period)
+				return nil
+			}
+			return err
+		}
+		fmt.Printf("posted the interest of %d account(s) for %s\n", report.Accounts, report.Period)
+
+		currencies, err := money.LoadRegistry(config.CurrenciesFile)
+		if err != nil {
+			return err
+		}
+		for code, total := range report.Totals {
+			currency, ok := currencies.Lookup(code)
+			if !ok {
+				currency = money.Currency{Code: code, MinorUnits: 2}
+			}
+			fmt.Printf("  %s\n", money.New(total, currency))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown interest command %q, %s", args[0], interestUsage)
+}
+
+// runHoldsCommand : handles `holds expire`, meant to be scheduled frequently. The expired holds stop
+// reserving funds on their own, this only updates their status
+func runHoldsCommand(conn *sql.DB, args []string) error {
+	if len(args) != 1 || args[0] != "expire" {
+		return errors.New(holdsUsage)
+	}
+
+	expired, err := db.New(conn).ExpireHolds(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("expired %d hold(s)\n", expired)
+	return nil
+}
+
+// runWebhooksCommand : handles `webhooks dispatch`, a single run of the dispatcher for the deployments
+// which schedule it instead of running it alongside the server
+func runWebhooksCommand(conn *sql.DB, args []string) error {
+	if len(args) != 1 || args[0] != "dispatch" {
+		return errors.New(webhooksUsage)
+	}
+
+	report, err := newWebhookDispatcher(db.NewStore(conn)).RunOnce(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("fanned out %d event(s) to %d delivery(ies)\n", report.Events, report.Deliveries)
+	fmt.Printf("delivered %d, retrying %d, dead-lettered %d\n", report.Succeeded, report.Retried, report.Dead)
+	return nil
+}
+
+// newWebhookDispatcher : creates the webhook dispatcher from the config
+func newWebhookDispatcher(store db.Store) *webhook.Dispatcher {
+	timeout := time.Second * time.Duration(config.WebhookTimeoutSeconds)
+	return webhook.NewDispatcher(store, webhook.NewHTTPClient(timeout, config.WebhookAllowPrivateNetworks), webhook.Config{
+		BatchSize:   int32(config.WebhookBatchSize),
+		MaxAttempts: int32(config.WebhookMaxAttempts),
+		BaseBackoff: time.Second * time.Duration(config.WebhookBackoffSeconds),
+		MaxBackoff:  time.Second * time.Duration(config.WebhookMaxBackoffSeconds),
+		Timeout:     timeout,
+	})
+}
+
+// runSeedCommand : handles `seed`, populates the database with users, accounts and transfers for demos
+// and benchmarks. The same flags always produce the same data
+func runSeedCommand(conn *sql.DB, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	seedValue := flags.Int64("seed", 1, "seed of the generated data")
+	users := flags.Int("users", 100, "number of users")
+	currencyCodes := flags.String("currencies", "", "comma separated currencies of the accounts, all the supported ones by default")
+	accountsPerCurrency := flags.Int("accounts-per-currency", 1, "accounts opened by every user in every currency")
+	transfers := flags.Int("transfers", 1000, "number of transfers between the accounts")
+	password := flags.String("password", "seedpassword", "password of all the users")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 {
+		return errors.New(seedUsage)
+	}
+
+	registry, err := money.LoadRegistry(config.CurrenciesFile)
+	if err != nil {
+		return err
+	}
+
+	codes := registry.Codes()
+	if *currencyCodes != "" {
+		codes = strings.Split(*currencyCodes, ",")
+	}
+
+	var currencies []money.Currency
+	for _, code := range codes {
+		currency, ok := registry.Lookup(strings.ToUpper(strings.TrimSpace(code)))
+		if !ok {
+			return fmt.Errorf("unsupported currency %q, %s", code, seedUsage)
+		}
+		currencies = append(currencies, currency)
+	}
+
+	hasher, err := newPasswordHasher()
+	if err != nil {
+		return err
+	}
+
+	// all the users share the password, it is hashed once since hashing is slow on purpose
+	hashedPassword, err := hasher.Hash(*password)
+	if err != nil {
+		return err
+	}
+
+	seeder := seed.NewSeeder(db.NewStore(conn), seed.Config{
+		Seed:                *seedValue,
+		Users:               *users,
+		Currencies:          currencies,
+		AccountsPerCurrency: *accountsPerCurrency,
+		Transfers:           *transfers,
+		PasswordHash:        hashedPassword,
+	})
+
+	report, err := seeder.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created %d user(s) with the password %q and %d account(s)\n", report.Users, *password, report.Accounts)
+	fmt.Printf("made %d opening deposit(s) and %d transfer(s), skipped %d\n", report.Deposits, report.Transfers, report.Skipped)
+	for _, currency := range currencies {
+		fmt.Printf("  %s transferred\n", money.New(report.Volume[currency.Code], currency))
+	}
+	return nil
+}
+
+// newPasswordHasher : creates the password hasher from the config
+func newPasswordHasher() (utils.PasswordHasher, error) {
+	return utils.NewPasswordHasher(config.PasswordHashAlgorithm, config.BcryptCost, utils.Argon2idParams{
+		Memory:      uint32(config.Argon2Memory),
+		Iterations:  uint32(config.Argon2Iterations),
+		Parallelism: uint8(config.Argon2Parallelism),
+	})
+}
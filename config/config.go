@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 
@@ -26,20 +28,89 @@ var (
 	DbPassword string
 	DbPort     string
 
-	// Test Database Credentials
-	TestDbDriver   string
-	TestDbHost     string
-	TestDbUser     string
-	TestDbName     string
-	TestDbPassword string
-	TestDbPort     string
-
 	// Token
 	TokenSigningKey     string
 	AccessTokenDuration int // in minutes
 
 	// Server
-	ServerPort string
+	ServerPort     string
+	GRPCServerPort string
+	TrustedProxies []string // addresses or CIDRs whose X-Forwarded-For is trusted for the client IP, none by default
+
+	// Login Protection
+	LoginRateLimitPerIP       int // max login requests per client IP within the window
+	LoginRateLimitPerUsername int // max login requests per username within the window
+	LoginRateLimitWindow      int // in minutes
+	MaxFailedLoginAttempts    int // consecutive failed logins after which the user gets locked
+	LoginLockoutDuration      int // in minutes, doubles with every failed login over the limit
+
+	// Two Factor Authentication
+	TOTPIssuer             string // shown by the authenticator apps
+	LoginChallengeDuration int    // in minutes, time within which the one time password has to be provided
+
+	// Password Hashing
+	PasswordHashAlgorithm string // `bcrypt` or `argon2id`, the hashes of other algorithms or costs are upgraded on login
+	BcryptCost            int
+	Argon2Memory          int // in KiB
+	Argon2Iterations      int
+	Argon2Parallelism     int
+
+	// Password Policy
+	PasswordMinLength        int
+	PasswordMaxLength        int // 0 means no limit
+	PasswordRequireUppercase bool
+	PasswordRequireLowercase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordDisallowUserInfo bool   // rejects the passwords which contain the username or email
+	BreachedPasswordsFile    string // SHA-1 hashes of the breached passwords ordered by hash, empty disables the check
+
+	// Password Reset
+	AppBaseURL                 string // used to build the links sent to the users
+	PasswordResetTokenDuration int    // in minutes
+
+	// Email Verification
+	EmailVerificationLinkDuration    int  // in minutes
+	RequireVerifiedEmailForTransfers bool // blocks the transfers of the users who haven't verified their email
+
+	// Currencies
+	CurrenciesFile string // ISO 4217 currencies registry, empty uses the built-in currencies
+
+	// Accounts
+	MaxAccountsPerCurrency int // accounts a user can open per currency, 0 means no limit
+
+	// Interest, annual rates in basis points per account type
+	InterestRateChecking int
+	InterestRateSavings  int
+	InterestRateWallet   int
+
+	// Fees
+	FeeScheduleFile string // fee rules of the transfers, empty means the transfers are free
+
+	// Holds
+	HoldDefaultExpiryMinutes int // lifetime of a hold unless the request asks for less
+	HoldMaxExpiryMinutes     int // longest lifetime a hold can be created with
+
+	// Batch transfers
+	BatchTransferMaxItems int // transfers a single batch can contain
+
+	// Webhooks
+	WebhookDispatcherEnabled       bool // runs the dispatcher alongside the server
+	WebhookDispatchIntervalSeconds int  // pause between two runs of the dispatcher
+	WebhookBatchSize               int  // events fanned out and deliveries attempted per run
+	WebhookMaxAttempts             int  // a delivery is dead-lettered once it has failed this many times
+	WebhookBackoffSeconds          int  // wait after the first failed attempt, doubled on every further failure
+	WebhookMaxBackoffSeconds       int  // longest wait between two attempts
+	WebhookTimeoutSeconds          int  // of a single attempt
+	WebhookAllowPrivateNetworks    bool // lets the webhooks receive the deliveries on loopback and private addresses, for development only
+
+	// Mail
+	MailSender  string // `log` or `file`
+	MailFrom    string
+	MailFileDir string // directory the `file` sender writes the emails to
+
+	// Migrations
+	AutoMigrate bool // applies the pending migrations before the server starts
 
 	// Environment
 	Environment AppEnvironment
@@ -66,6 +137,12 @@ const (
 
 	// ConfigFileType : yaml
 	ConfigFileType string = "yaml"
+
+	// testTokenSigningKey : used by LoadForTests if the token signing key isn't set
+	testTokenSigningKey string = "12345678901234567890123456789012"
+
+	// testAccessTokenDuration : used by LoadForTests if the access token duration isn't set, in minutes
+	testAccessTokenDuration string = "15"
 )
 
 // func init() {
@@ -76,8 +153,23 @@ func Load(path string) {
 	SetConfigFromViper(path)
 }
 
+// LoadForTests : loads the config like Load, except that a missing local config file isn't fatal, the env
+// vars are used instead, and the token settings which have no default get test values. So the tests run
+// on a clean checkout without a config file or the env vars of the CI
+func LoadForTests(path string) {
+	Environment = getCurrentHostEnvironment()
+
+	if Environment.IsLocal() && hasConfigFile(path) {
+		setEnvironmentVarsFromConfig(path)
+	}
+
+	setEnvDefault("TOKEN_SIGNING_KEY", testTokenSigningKey)
+	setEnvDefault("ACCESS_TOKEN_DURATION", testAccessTokenDuration)
+
+	setConfigFromEnv()
+}
+
 func SetConfigFromViper(path string) {
-	var err error
 	Environment = getCurrentHostEnvironment()
 	log.Printf("🚀 Current Host Environment: %s\n", Environment)
 
@@ -86,7 +178,13 @@ func SetConfigFromViper(path string) {
 		setEnvironmentVarsFromConfig(path)
 	}
 
-	// fetch the env vars and store in variables
+	setConfigFromEnv()
+}
+
+// setConfigFromEnv : fetches the env vars and stores them in the variables
+func setConfigFromEnv() {
+	var err error
+
 	// Database Credentials
 	DbDriver = os.Getenv("DB_DRIVER")
 	DbHost = os.Getenv("DB_HOST")
@@ -95,14 +193,6 @@ func SetConfigFromViper(path string) {
 	DbPassword = os.Getenv("DB_PASSWORD")
 	DbPort = os.Getenv("DB_PORT")
 
-	// Test Database Credentials
-	TestDbDriver = os.Getenv("TEST_DB_DRIVER")
-	TestDbHost = os.Getenv("TEST_DB_HOST")
-	TestDbUser = os.Getenv("TEST_DB_USER")
-	TestDbName = os.Getenv("TEST_DB_NAME")
-	TestDbPassword = os.Getenv("TEST_DB_PASSWORD")
-	TestDbPort = os.Getenv("TEST_DB_PORT")
-
 	// Token
 	TokenSigningKey = os.Getenv("TOKEN_SIGNING_KEY")
 	AccessTokenDuration, err = strconv.Atoi(os.Getenv("ACCESS_TOKEN_DURATION"))
@@ -112,6 +202,83 @@ func SetConfigFromViper(path string) {
 
 	// Server
 	ServerPort = os.Getenv("SERVER_PORT")
+	GRPCServerPort = getEnv("GRPC_SERVER_PORT", "9090")
+	TrustedProxies = getEnvAsList("TRUSTED_PROXIES")
+
+	// Login Protection
+	LoginRateLimitPerIP = getEnvAsInt("LOGIN_RATE_LIMIT_PER_IP", 20)
+	LoginRateLimitPerUsername = getEnvAsInt("LOGIN_RATE_LIMIT_PER_USERNAME", 5)
+	LoginRateLimitWindow = getEnvAsInt("LOGIN_RATE_LIMIT_WINDOW", 1)
+	MaxFailedLoginAttempts = getEnvAsInt("MAX_FAILED_LOGIN_ATTEMPTS", 5)
+	LoginLockoutDuration = getEnvAsInt("LOGIN_LOCKOUT_DURATION", 1)
+
+	// Two Factor Authentication
+	TOTPIssuer = getEnv("TOTP_ISSUER", "Banking System")
+	LoginChallengeDuration = getEnvAsInt("LOGIN_CHALLENGE_DURATION", 5)
+
+	// Password Hashing
+	PasswordHashAlgorithm = getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	BcryptCost = getEnvAsInt("BCRYPT_COST", 10)
+	Argon2Memory = getEnvAsInt("ARGON2_MEMORY", 64*1024)
+	Argon2Iterations = getEnvAsInt("ARGON2_ITERATIONS", 3)
+	Argon2Parallelism = getEnvAsInt("ARGON2_PARALLELISM", 2)
+
+	// Password Policy
+	PasswordMinLength = getEnvAsInt("PASSWORD_MIN_LENGTH", 6)
+	PasswordMaxLength = getEnvAsInt("PASSWORD_MAX_LENGTH", 72)
+	PasswordRequireUppercase = getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", false)
+	PasswordRequireLowercase = getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", false)
+	PasswordRequireDigit = getEnvAsBool("PASSWORD_REQUIRE_DIGIT", false)
+	PasswordRequireSymbol = getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false)
+	PasswordDisallowUserInfo = getEnvAsBool("PASSWORD_DISALLOW_USER_INFO", true)
+	BreachedPasswordsFile = getEnv("BREACHED_PASSWORDS_FILE", "")
+
+	// Password Reset
+	AppBaseURL = getEnv("APP_BASE_URL", "http://localhost:8080")
+	PasswordResetTokenDuration = getEnvAsInt("PASSWORD_RESET_TOKEN_DURATION", 30)
+
+	// Email Verification
+	EmailVerificationLinkDuration = getEnvAsInt("EMAIL_VERIFICATION_LINK_DURATION", 1440)
+	RequireVerifiedEmailForTransfers = getEnvAsBool("REQUIRE_VERIFIED_EMAIL_FOR_TRANSFERS", false)
+
+	// Currencies
+	CurrenciesFile = getEnv("CURRENCIES_FILE", "")
+
+	// Accounts
+	MaxAccountsPerCurrency = getEnvAsInt("MAX_ACCOUNTS_PER_CURRENCY", 1)
+
+	// Interest
+	InterestRateChecking = getEnvAsInt("INTEREST_RATE_CHECKING", 0)
+	InterestRateSavings = getEnvAsInt("INTEREST_RATE_SAVINGS", 200)
+	InterestRateWallet = getEnvAsInt("INTEREST_RATE_WALLET", 0)
+
+	// Fees
+	FeeScheduleFile = getEnv("FEE_SCHEDULE_FILE", "")
+
+	// Holds
+	HoldDefaultExpiryMinutes = getEnvAsInt("HOLD_DEFAULT_EXPIRY_MINUTES", 10080)
+	HoldMaxExpiryMinutes = getEnvAsInt("HOLD_MAX_EXPIRY_MINUTES", 43200)
+
+	// Batch transfers
+	BatchTransferMaxItems = getEnvAsInt("BATCH_TRANSFER_MAX_ITEMS", 500)
+
+	// Webhooks
+	WebhookDispatcherEnabled = getEnvAsBool("WEBHOOK_DISPATCHER_ENABLED", true)
+	WebhookDispatchIntervalSeconds = getEnvAsInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 5)
+	WebhookBatchSize = getEnvAsInt("WEBHOOK_BATCH_SIZE", 100)
+	WebhookMaxAttempts = getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	WebhookBackoffSeconds = getEnvAsInt("WEBHOOK_BACKOFF_SECONDS", 30)
+	WebhookMaxBackoffSeconds = getEnvAsInt("WEBHOOK_MAX_BACKOFF_SECONDS", 3600)
+	WebhookTimeoutSeconds = getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10)
+	WebhookAllowPrivateNetworks = getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+
+	// Mail
+	MailSender = getEnv("MAIL_SENDER", "log")
+	MailFrom = getEnv("MAIL_FROM", "no-reply@banking-system.local")
+	MailFileDir = getEnv("MAIL_FILE_DIR", "tmp/mail")
+
+	// Migrations
+	AutoMigrate = getEnvAsBool("AUTO_MIGRATE", false)
 }
 
 func setEnvironmentVarsFromConfig(path string) {
@@ -150,20 +317,6 @@ func setEnvironmentVarsFromConfig(path string) {
 	os.Setenv("DB_PASSWORD", dbPassword)
 	os.Setenv("DB_PORT", dbPort)
 
-	// Test Database Credentials
-	testDbDriver := viper.GetString("TEST_DB_DRIVER")
-	testDbHost := viper.GetString("TEST_DB_HOST")
-	testDbUser := viper.GetString("TEST_DB_USER")
-	testDbName := viper.GetString("TEST_DB_NAME")
-	testDbPassword := viper.GetString("TEST_DB_PASSWORD")
-	testDbPort := viper.GetString("TEST_DB_PORT")
-	os.Setenv("TEST_DB_DRIVER", testDbDriver)
-	os.Setenv("TEST_DB_HOST", testDbHost)
-	os.Setenv("TEST_DB_USER", testDbUser)
-	os.Setenv("TEST_DB_NAME", testDbName)
-	os.Setenv("TEST_DB_PASSWORD", testDbPassword)
-	os.Setenv("TEST_DB_PORT", testDbPort)
-
 	// Token
 	tokenSigningKey := viper.GetString("TOKEN_SIGNING_KEY")
 	accessTokenDuration := viper.GetInt("ACCESS_TOKEN_DURATION")
@@ -172,7 +325,193 @@ func setEnvironmentVarsFromConfig(path string) {
 
 	// Server
 	serverPort := viper.GetString("SERVER_PORT")
+	grpcServerPort := viper.GetString("GRPC_SERVER_PORT")
+	trustedProxies := viper.GetString("TRUSTED_PROXIES")
 	os.Setenv("SERVER_PORT", serverPort)
+	os.Setenv("GRPC_SERVER_PORT", grpcServerPort)
+	os.Setenv("TRUSTED_PROXIES", trustedProxies)
+
+	// Login Protection
+	loginRateLimitPerIP := viper.GetString("LOGIN_RATE_LIMIT_PER_IP")
+	loginRateLimitPerUsername := viper.GetString("LOGIN_RATE_LIMIT_PER_USERNAME")
+	loginRateLimitWindow := viper.GetString("LOGIN_RATE_LIMIT_WINDOW")
+	maxFailedLoginAttempts := viper.GetString("MAX_FAILED_LOGIN_ATTEMPTS")
+	loginLockoutDuration := viper.GetString("LOGIN_LOCKOUT_DURATION")
+	os.Setenv("LOGIN_RATE_LIMIT_PER_IP", loginRateLimitPerIP)
+	os.Setenv("LOGIN_RATE_LIMIT_PER_USERNAME", loginRateLimitPerUsername)
+	os.Setenv("LOGIN_RATE_LIMIT_WINDOW", loginRateLimitWindow)
+	os.Setenv("MAX_FAILED_LOGIN_ATTEMPTS", maxFailedLoginAttempts)
+	os.Setenv("LOGIN_LOCKOUT_DURATION", loginLockoutDuration)
+
+	// Two Factor Authentication
+	totpIssuer := viper.GetString("TOTP_ISSUER")
+	loginChallengeDuration := viper.GetString("LOGIN_CHALLENGE_DURATION")
+	os.Setenv("TOTP_ISSUER", totpIssuer)
+	os.Setenv("LOGIN_CHALLENGE_DURATION", loginChallengeDuration)
+
+	// Password Hashing
+	passwordHashAlgorithm := viper.GetString("PASSWORD_HASH_ALGORITHM")
+	bcryptCost := viper.GetString("BCRYPT_COST")
+	argon2Memory := viper.GetString("ARGON2_MEMORY")
+	argon2Iterations := viper.GetString("ARGON2_ITERATIONS")
+	argon2Parallelism := viper.GetString("ARGON2_PARALLELISM")
+	os.Setenv("PASSWORD_HASH_ALGORITHM", passwordHashAlgorithm)
+	os.Setenv("BCRYPT_COST", bcryptCost)
+	os.Setenv("ARGON2_MEMORY", argon2Memory)
+	os.Setenv("ARGON2_ITERATIONS", argon2Iterations)
+	os.Setenv("ARGON2_PARALLELISM", argon2Parallelism)
+
+	// Password Policy
+	passwordMinLength := viper.GetString("PASSWORD_MIN_LENGTH")
+	passwordMaxLength := viper.GetString("PASSWORD_MAX_LENGTH")
+	passwordRequireUppercase := viper.GetString("PASSWORD_REQUIRE_UPPERCASE")
+	passwordRequireLowercase := viper.GetString("PASSWORD_REQUIRE_LOWERCASE")
+	passwordRequireDigit := viper.GetString("PASSWORD_REQUIRE_DIGIT")
+	passwordRequireSymbol := viper.GetString("PASSWORD_REQUIRE_SYMBOL")
+	passwordDisallowUserInfo := viper.GetString("PASSWORD_DISALLOW_USER_INFO")
+	breachedPasswordsFile := viper.GetString("BREACHED_PASSWORDS_FILE")
+	os.Setenv("PASSWORD_MIN_LENGTH", passwordMinLength)
+	os.Setenv("PASSWORD_MAX_LENGTH", passwordMaxLength)
+	os.Setenv("PASSWORD_REQUIRE_UPPERCASE", passwordRequireUppercase)
+	os.Setenv("PASSWORD_REQUIRE_LOWERCASE", passwordRequireLowercase)
+	os.Setenv("PASSWORD_REQUIRE_DIGIT", passwordRequireDigit)
+	os.Setenv("PASSWORD_REQUIRE_SYMBOL", passwordRequireSymbol)
+	os.Setenv("PASSWORD_DISALLOW_USER_INFO", passwordDisallowUserInfo)
+	os.Setenv("BREACHED_PASSWORDS_FILE", breachedPasswordsFile)
+
+	// Password Reset
+	appBaseURL := viper.GetString("APP_BASE_URL")
+	passwordResetTokenDuration := viper.GetString("PASSWORD_RESET_TOKEN_DURATION")
+	os.Setenv("APP_BASE_URL", appBaseURL)
+	os.Setenv("PASSWORD_RESET_TOKEN_DURATION", passwordResetTokenDuration)
+
+	// Email Verification
+	emailVerificationLinkDuration := viper.GetString("EMAIL_VERIFICATION_LINK_DURATION")
+	requireVerifiedEmailForTransfers := viper.GetString("REQUIRE_VERIFIED_EMAIL_FOR_TRANSFERS")
+	os.Setenv("EMAIL_VERIFICATION_LINK_DURATION", emailVerificationLinkDuration)
+	os.Setenv("REQUIRE_VERIFIED_EMAIL_FOR_TRANSFERS", requireVerifiedEmailForTransfers)
+
+	// Currencies
+	currenciesFile := viper.GetString("CURRENCIES_FILE")
+	os.Setenv("CURRENCIES_FILE", currenciesFile)
+
+	// Accounts
+	maxAccountsPerCurrency := viper.GetString("MAX_ACCOUNTS_PER_CURRENCY")
+	os.Setenv("MAX_ACCOUNTS_PER_CURRENCY", maxAccountsPerCurrency)
+
+	// Interest
+	interestRateChecking := viper.GetString("INTEREST_RATE_CHECKING")
+	interestRateSavings := viper.GetString("INTEREST_RATE_SAVINGS")
+	interestRateWallet := viper.GetString("INTEREST_RATE_WALLET")
+	os.Setenv("INTEREST_RATE_CHECKING", interestRateChecking)
+	os.Setenv("INTEREST_RATE_SAVINGS", interestRateSavings)
+	os.Setenv("INTEREST_RATE_WALLET", interestRateWallet)
+
+	// Fees
+	feeScheduleFile := viper.GetString("FEE_SCHEDULE_FILE")
+	os.Setenv("FEE_SCHEDULE_FILE", feeScheduleFile)
+
+	// Holds
+	holdDefaultExpiryMinutes := viper.GetString("HOLD_DEFAULT_EXPIRY_MINUTES")
+	holdMaxExpiryMinutes := viper.GetString("HOLD_MAX_EXPIRY_MINUTES")
+	os.Setenv("HOLD_DEFAULT_EXPIRY_MINUTES", holdDefaultExpiryMinutes)
+	os.Setenv("HOLD_MAX_EXPIRY_MINUTES", holdMaxExpiryMinutes)
+
+	// Batch transfers
+	batchTransferMaxItems := viper.GetString("BATCH_TRANSFER_MAX_ITEMS")
+	os.Setenv("BATCH_TRANSFER_MAX_ITEMS", batchTransferMaxItems)
+
+	// Webhooks
+	webhookDispatcherEnabled := viper.GetString("WEBHOOK_DISPATCHER_ENABLED")
+	webhookDispatchIntervalSeconds := viper.GetString("WEBHOOK_DISPATCH_INTERVAL_SECONDS")
+	webhookBatchSize := viper.GetString("WEBHOOK_BATCH_SIZE")
+	webhookMaxAttempts := viper.GetString("WEBHOOK_MAX_ATTEMPTS")
+	webhookBackoffSeconds := viper.GetString("WEBHOOK_BACKOFF_SECONDS")
+	webhookMaxBackoffSeconds := viper.GetString("WEBHOOK_MAX_BACKOFF_SECONDS")
+	webhookTimeoutSeconds := viper.GetString("WEBHOOK_TIMEOUT_SECONDS")
+	webhookAllowPrivateNetworks := viper.GetString("WEBHOOK_ALLOW_PRIVATE_NETWORKS")
+	os.Setenv("WEBHOOK_DISPATCHER_ENABLED", webhookDispatcherEnabled)
+	os.Setenv("WEBHOOK_DISPATCH_INTERVAL_SECONDS", webhookDispatchIntervalSeconds)
+	os.Setenv("WEBHOOK_BATCH_SIZE", webhookBatchSize)
+	os.Setenv("WEBHOOK_MAX_ATTEMPTS", webhookMaxAttempts)
+	os.Setenv("WEBHOOK_BACKOFF_SECONDS", webhookBackoffSeconds)
+	os.Setenv("WEBHOOK_MAX_BACKOFF_SECONDS", webhookMaxBackoffSeconds)
+	os.Setenv("WEBHOOK_TIMEOUT_SECONDS", webhookTimeoutSeconds)
+	os.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", webhookAllowPrivateNetworks)
+
+	// Mail
+	mailSender := viper.GetString("MAIL_SENDER")
+	mailFrom := viper.GetString("MAIL_FROM")
+	mailFileDir := viper.GetString("MAIL_FILE_DIR")
+	os.Setenv("MAIL_SENDER", mailSender)
+	os.Setenv("MAIL_FROM", mailFrom)
+	os.Setenv("MAIL_FILE_DIR", mailFileDir)
+
+	// Migrations
+	autoMigrate := viper.GetString("AUTO_MIGRATE")
+	os.Setenv("AUTO_MIGRATE", autoMigrate)
+}
+
+// hasConfigFile : reports whether the local config file is in the directory
+func hasConfigFile(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ConfigFileName+"."+ConfigFileType))
+	return err == nil
+}
+
+// setEnvDefault : sets the env var to the value unless it is already set
+func setEnvDefault(key string, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
+	}
+}
+
+// getEnv : returns the value of the env var, the fallback is used if it is unset
+func getEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// getEnvAsInt : returns the integer value of the env var, the fallback is used if it is unset
+func getEnvAsInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("unable to parse %s value from env, err: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvAsList : returns the comma separated values of the env var, nil if it is unset
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsBool : returns the boolean value of the env var, the fallback is used if it is unset
+func getEnvAsBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("unable to parse %s value from env, err: %v", key, err)
+	}
+	return parsed
 }
 
 func getCurrentHostEnvironment() AppEnvironment {
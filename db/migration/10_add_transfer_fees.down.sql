@@ -0,0 +1,9 @@
+BEGIN;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "fee_account_id";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "fee";
+
+COMMIT;
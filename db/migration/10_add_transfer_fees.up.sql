@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_fee_check" CHECK ("fee" >= 0 AND "fee" < "amount");
+
+ALTER TABLE "transfers" ADD COLUMN "fee_account_id" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "transfers"."amount" IS 'gross amount debited from the sender, must be positive';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'part of the amount credited to the fee account, the recipient gets the rest';
+
+COMMENT ON COLUMN "transfers"."fee_account_id" IS 'revenue account of the bank, null if no fee has been charged';
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "expires_at" timestamptz NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "transfer_id" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_amount_check" CHECK ("amount" > 0 AND "captured_amount" >= 0 AND "captured_amount" <= "amount");
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_status_check" CHECK ("status" IN ('active', 'captured', 'released', 'expired'));
+
+CREATE INDEX ON "holds" ("account_id", "status");
+
+CREATE INDEX ON "holds" ("expires_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "holds"."account_id" IS 'the funds are reserved on this account';
+
+COMMENT ON COLUMN "holds"."to_account_id" IS 'credited once the hold is captured';
+
+COMMENT ON COLUMN "holds"."amount" IS 'reserved amount, must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'active, captured, released or expired';
+
+COMMENT ON COLUMN "holds"."expires_at" IS 'an active hold stops reserving the funds once it expires';
+
+COMMENT ON COLUMN "holds"."captured_amount" IS 'at most the amount, the rest is released on capture';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'the transfer which captured the hold';
+
+COMMIT;
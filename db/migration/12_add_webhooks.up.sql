@@ -0,0 +1,72 @@
+BEGIN;
+
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "event_type" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "payload" jsonb NOT NULL,
+  "dispatched_at" timestamptz
+);
+
+ALTER TABLE "outbox_events" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "outbox_events" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "outbox_events" ("id") WHERE "dispatched_at" IS NULL;
+
+COMMENT ON COLUMN "outbox_events"."event_type" IS 'e.g: transfer.debited';
+
+COMMENT ON COLUMN "outbox_events"."user_id" IS 'owner of the account, the event is delivered to the webhooks of this user';
+
+COMMENT ON COLUMN "outbox_events"."dispatched_at" IS 'set once the deliveries of the event have been created';
+
+CREATE TABLE "webhooks" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" bigint NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "webhooks" ("user_id");
+
+COMMENT ON COLUMN "webhooks"."secret" IS 'key of the HMAC signature of the deliveries';
+
+COMMENT ON COLUMN "webhooks"."event_types" IS 'the webhook receives every event type if empty';
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "webhook_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_error" varchar NOT NULL DEFAULT '',
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "delivered_at" timestamptz,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_status_check" CHECK ("status" IN ('pending', 'succeeded', 'dead'));
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("webhook_id", "event_id");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or dead once the attempts have been exhausted';
+
+COMMENT ON COLUMN "webhook_deliveries"."next_attempt_at" IS 'a pending delivery is attempted once this time has passed';
+
+COMMENT ON COLUMN "webhook_deliveries"."last_status_code" IS 'HTTP status of the last attempt, 0 if no response has been received';
+
+COMMIT;
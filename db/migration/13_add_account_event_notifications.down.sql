@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS "holds_notify_account_balance" ON "holds";
+
+DROP TRIGGER IF EXISTS "accounts_notify_account_balance" ON "accounts";
+
+DROP TRIGGER IF EXISTS "entries_notify_account_entry" ON "entries";
+
+DROP FUNCTION IF EXISTS "notify_account_row_balance"();
+
+DROP FUNCTION IF EXISTS "notify_account_balance"();
+
+DROP FUNCTION IF EXISTS "notify_account_entry"();
+
+COMMIT;
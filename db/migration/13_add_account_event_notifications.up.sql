@@ -0,0 +1,52 @@
+BEGIN;
+
+-- the notifications are sent on commit, so the listeners only hear about the committed changes
+
+CREATE FUNCTION "notify_account_entry"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_events', json_build_object(
+    'type', 'entry',
+    'account_id', NEW.account_id,
+    'entry_id', NEW.id,
+    'amount', NEW.amount,
+    'created_at', NEW.created_at
+  )::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION "notify_account_balance"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_events', json_build_object(
+    'type', 'balance',
+    'account_id', NEW.account_id
+  )::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION "notify_account_row_balance"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_events', json_build_object(
+    'type', 'balance',
+    'account_id', NEW.id
+  )::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "entries_notify_account_entry"
+AFTER INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION "notify_account_entry"();
+
+CREATE TRIGGER "accounts_notify_account_balance"
+AFTER UPDATE OF "balance" ON "accounts"
+FOR EACH ROW WHEN (OLD."balance" IS DISTINCT FROM NEW."balance")
+EXECUTE FUNCTION "notify_account_row_balance"();
+
+-- the holds change the available balance
+CREATE TRIGGER "holds_notify_account_balance"
+AFTER INSERT OR UPDATE OF "status" ON "holds"
+FOR EACH ROW EXECUTE FUNCTION "notify_account_balance"();
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "admin_audit_logs";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "is_frozen";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_disabled";
+
+COMMIT;
@@ -0,0 +1,38 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "is_disabled" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "accounts" ADD COLUMN "is_frozen" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "admin_audit_logs" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "user_id" bigint,
+  "account_id" bigint,
+  "reason" varchar NOT NULL,
+  "details" jsonb NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE "admin_audit_logs" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "admin_audit_logs" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "admin_audit_logs" ADD CONSTRAINT "admin_audit_logs_reason_check" CHECK (btrim("reason") <> '');
+
+CREATE INDEX ON "admin_audit_logs" ("user_id");
+
+CREATE INDEX ON "admin_audit_logs" ("account_id");
+
+COMMENT ON COLUMN "users"."is_disabled" IS 'a disabled user can''t log in, the tokens are revoked when disabling';
+
+COMMENT ON COLUMN "accounts"."is_frozen" IS 'a frozen account can''t send or receive transfers through the API';
+
+COMMENT ON COLUMN "admin_audit_logs"."actor" IS 'operator who ran the admin command';
+
+COMMENT ON COLUMN "admin_audit_logs"."action" IS 'e.g: user.disable';
+
+COMMENT ON COLUMN "admin_audit_logs"."details" IS 'the changed values, e.g: the amount of an adjustment';
+
+COMMIT;
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "failed_login_attempts" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "users"."failed_login_attempts" IS 'consecutive failed logins, reset on a successful login';
@@ -0,0 +1,23 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "users" ADD COLUMN "is_totp_enabled" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "users" ADD COLUMN "totp_last_used_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" bigint NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("user_id", "code_hash");
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'base32 encoded, set on enrollment and only used once is_totp_enabled is true';
+
+COMMENT ON COLUMN "users"."totp_last_used_step" IS 'time step of the last accepted code, older or equal steps are rejected to prevent a replay';
+
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'sha256 of the normalized recovery code';
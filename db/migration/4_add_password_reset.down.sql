@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "password_reset_tokens";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "password_changed_at";
+
+COMMIT;
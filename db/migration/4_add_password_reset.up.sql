@@ -0,0 +1,18 @@
+ALTER TABLE "users" ADD COLUMN "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+CREATE TABLE "password_reset_tokens" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" bigint NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz
+);
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "password_reset_tokens" ("user_id");
+
+COMMENT ON COLUMN "users"."password_changed_at" IS 'the access tokens issued before it are rejected';
+
+COMMENT ON COLUMN "password_reset_tokens"."token_hash" IS 'sha256 of the reset token, the token itself is only sent to the user';
@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "accounts_user_id_currency_type_idx";
+
+-- fails if a user has opened more than one account of a currency in the meantime
+CREATE UNIQUE INDEX ON "accounts" ("user_id", "currency");
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "nickname";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "type";
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_type_check" CHECK ("type" IN ('checking', 'savings', 'wallet'));
+
+ALTER TABLE "accounts" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+
+-- the number of accounts per currency is limited by the application's account policy
+DROP INDEX IF EXISTS "accounts_user_id_currency_idx";
+
+CREATE INDEX ON "accounts" ("user_id", "currency", "type");
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or wallet';
+
+COMMENT ON COLUMN "accounts"."nickname" IS 'chosen by the user, empty if not set';
+
+COMMIT;
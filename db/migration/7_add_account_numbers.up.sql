@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar;
+
+-- backfills the existing accounts with random numbers in the layout of the application,
+-- the prefix BK is converted to digits (B = 11, K = 20) for computing the mod-97 check digits
+WITH "numbers" AS (
+  SELECT "id", lpad(floor(random() * 1e16)::bigint::text, 16, '0') AS "bban" FROM "accounts"
+)
+UPDATE "accounts"
+SET "account_number" = 'BK' || lpad((98 - ("numbers"."bban" || '112000')::numeric % 97)::text, 2, '0') || "numbers"."bban"
+FROM "numbers"
+WHERE "accounts"."id" = "numbers"."id";
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number");
+
+COMMENT ON COLUMN "accounts"."account_number" IS 'IBAN-style external number with mod-97 check digits, used for addressing the transfers';
+
+COMMIT;
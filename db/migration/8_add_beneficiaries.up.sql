@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE "beneficiaries" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" bigint NOT NULL,
+  "label" varchar NOT NULL,
+  "account_id" bigint NOT NULL
+);
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE UNIQUE INDEX ON "beneficiaries" ("user_id", "label");
+
+CREATE UNIQUE INDEX ON "beneficiaries" ("user_id", "account_id");
+
+COMMENT ON COLUMN "beneficiaries"."label" IS 'chosen by the user, unique within the address book of the user';
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "job_runs";
+
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "system_accounts";
+
+-- the system user is kept, its accounts are referenced by the transfers
+
+COMMIT;
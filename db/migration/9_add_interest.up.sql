@@ -0,0 +1,57 @@
+BEGIN;
+
+-- owns the accounts of the bank itself, the username can't be registered through the API
+INSERT INTO "users" ("username", "password", "full_name", "email", "is_email_verified")
+VALUES ('_system', '!', 'Banking System', 'system@banking-system.internal', true);
+
+CREATE TABLE "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("purpose", "currency")
+);
+
+ALTER TABLE "system_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "rate_bps" integer NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "posted_at" timestamptz,
+  "transfer_id" bigint
+);
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+
+CREATE TABLE "job_runs" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "job" varchar NOT NULL,
+  "period" varchar NOT NULL,
+  "completed_at" timestamptz
+);
+
+CREATE UNIQUE INDEX ON "job_runs" ("job", "period");
+
+COMMENT ON COLUMN "system_accounts"."purpose" IS 'e.g: interest_expense';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end of day balance the interest has been computed on';
+
+COMMENT ON COLUMN "interest_accruals"."rate_bps" IS 'annual rate in basis points';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'in millionths of the minor unit, rounded once the month is posted';
+
+COMMENT ON COLUMN "interest_accruals"."transfer_id" IS 'the transfer which credited the interest, null if it rounded to zero';
+
+COMMENT ON COLUMN "job_runs"."period" IS 'e.g: 2022-04-30 for a daily job, 2022-04 for a monthly job';
+
+COMMIT;
@@ -0,0 +1,330 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// the sql files of this directory are embedded into the binary, so that the schema can be
+// migrated without shipping the files or the `golang-migrate` CLI alongside the server
+//
+//go:embed *.sql
+var files embed.FS
+
+// the state table uses the same layout as the one maintained by the `golang-migrate` CLI,
+// this keeps databases migrated via `make migrate-up` compatible with the embedded migrator
+const (
+	migrationsTable = "schema_migrations"
+
+	// advisoryLockID : arbitrary key used with `pg_advisory_lock` so that concurrent migrators don't race
+	advisoryLockID int64 = 6483726151
+)
+
+// file names follow the `golang-migrate` convention, e.g: 1_init_schema.sql.up.sql
+var fileNameRegex = regexp.MustCompile(`^([0-9]+)_(.*)\.(down|up)\.sql$`)
+
+var (
+	ErrDirty = errors.New("database is in a dirty state, fix the failed migration manually before retrying")
+
+	ErrNoChange = errors.New("no change")
+)
+
+// Migration : a single versioned schema change
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status : the state of a single migration in the database
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Migrator : applies the embedded migrations and tracks their state in the `schema_migrations` table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator : returns a new Migrator for the embedded migrations
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// load : reads the migrations from the provided fs sorted by their version
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		matches := fileNameRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s, err: %v", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: matches[2]}
+			byVersion[uint(version)] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d is used by more than one name", version)
+		}
+
+		switch matches[3] {
+		case "up":
+			migration.Up = string(content)
+		case "down":
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrations : returns all the embedded migrations sorted by their version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up : applies all the pending migrations and returns the number of migrations applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return ErrDirty
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			err = run(ctx, conn, migration.Version, migration.Up)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up failed, err: %v", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+
+		return nil
+	})
+
+	if err == nil && applied == 0 {
+		return 0, ErrNoChange
+	}
+
+	return applied, err
+}
+
+// Down : reverts the last `steps` applied migrations, all of them if steps <= 0
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return ErrDirty
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if steps > 0 && reverted == steps {
+				break
+			}
+
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			// the version recorded after reverting is the one right before this migration
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			err = run(ctx, conn, previous, migration.Down)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down failed, err: %v", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+
+		return nil
+	})
+
+	if err == nil && reverted == 0 {
+		return 0, ErrNoChange
+	}
+
+	return reverted, err
+}
+
+// Version : returns the currently applied version, 0 means no migration has been applied
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	err = ensureTable(ctx, conn)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version(ctx, conn)
+}
+
+// Status : returns every embedded migration along with whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   migration.Version <= current,
+		})
+	}
+
+	return statuses, nil
+}
+
+// withLock : runs fn on a single connection that holds the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID)
+	if err != nil {
+		return fmt.Errorf("unable to acquire migration lock, err: %v", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+
+	err = ensureTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// run : executes the statements of a migration file and records the resulting version,
+// the version is marked dirty while the statements are being executed
+func run(ctx context.Context, conn *sql.Conn, resultingVersion uint, statements string) error {
+	err := setVersion(ctx, conn, resultingVersion, true)
+	if err != nil {
+		return err
+	}
+
+	// executed without any args, so that the driver sends the whole file in a single simple query
+	_, err = conn.ExecContext(ctx, statements)
+	if err != nil {
+		return err
+	}
+
+	return setVersion(ctx, conn, resultingVersion, false)
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`, migrationsTable)
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+func version(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var current int64
+	var dirty bool
+
+	query := fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, migrationsTable)
+	err := conn.QueryRowContext(ctx, query).Scan(&current, &dirty)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return uint(current), dirty, nil
+}
+
+func setVersion(ctx context.Context, conn *sql.Conn, current uint, dirty bool) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(`TRUNCATE %s`, migrationsTable))
+	if err != nil {
+		return err
+	}
+
+	// no row means that no migration has been applied
+	if current == 0 && !dirty {
+		return nil
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (version, dirty) VALUES ($1, $2)`, migrationsTable)
+	_, err = conn.ExecContext(ctx, query, int64(current), dirty)
+	return err
+}
@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	require.Equal(t, uint(1), migrations[0].Version)
+	require.Equal(t, "init_schema.sql", migrations[0].Name)
+
+	for i, migration := range migrations {
+		require.NotEmpty(t, migration.Up)
+		require.NotEmpty(t, migration.Down)
+
+		if i > 0 {
+			require.Greater(t, migration.Version, migrations[i-1].Version)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fsys          fstest.MapFS
+		expectErr     bool
+		expectVersion []uint
+	}{
+		{
+			name: "Happy Case - Sorted By Version",
+			fsys: fstest.MapFS{
+				"10_add_index.up.sql":   {Data: []byte("CREATE INDEX ...")},
+				"10_add_index.down.sql": {Data: []byte("DROP INDEX ...")},
+				"2_add_table.up.sql":    {Data: []byte("CREATE TABLE ...")},
+				"2_add_table.down.sql":  {Data: []byte("DROP TABLE ...")},
+				"README.md":             {Data: []byte("not a migration")},
+			},
+			expectVersion: []uint{2, 10},
+		},
+		{
+			name: "Failure Case - Missing Up File",
+			fsys: fstest.MapFS{
+				"2_add_table.down.sql": {Data: []byte("DROP TABLE ...")},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Failure Case - Duplicate Version",
+			fsys: fstest.MapFS{
+				"2_add_table.up.sql": {Data: []byte("CREATE TABLE ...")},
+				"2_add_index.up.sql": {Data: []byte("CREATE INDEX ...")},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := load(tc.fsys)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			versions := make([]uint, 0, len(migrations))
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			require.Equal(t, tc.expectVersion, versions)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package pgtest starts a throwaway Postgres cluster for the tests, so that they don't need a database
+// set up beforehand. The cluster is created with the `initdb` of the local Postgres installation in a
+// temporary directory, the migrations are applied once to a template database and every test gets a
+// database of its own copied from the template, which is dropped at the end of the test
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"github.com/skamranahmed/banking-system/db/migration"
+)
+
+const (
+	// templateDatabase : migrated once, the databases of the tests are copies of it
+	templateDatabase = "pgtest_template"
+
+	// startTimeout : how long pg_ctl waits for the server to accept connections, in seconds
+	startTimeout = "60"
+)
+
+var (
+	ErrNotInstalled = errors.New("initdb and pg_ctl not found, install postgres or point PG_BIN to their directory")
+
+	ErrRoot = errors.New("postgres refuses to run as root")
+)
+
+// binDirPatterns : where the packages of the common distributions install the binaries, the ones
+// which aren't on the PATH, e.g: /usr/lib/postgresql/14/bin on Debian and Ubuntu
+var binDirPatterns = []string{
+	"/usr/lib/postgresql/*/bin",
+	"/usr/pgsql-*/bin",
+	"/usr/local/pgsql/bin",
+	"/usr/local/opt/postgresql*/bin",
+	"/opt/homebrew/opt/postgresql*/bin",
+}
+
+// Server : a Postgres cluster in a temporary directory, owned by the test binary
+type Server struct {
+	binDir  string
+	dir     string // the data directory, the socket and the log of the cluster
+	port    int
+	started bool
+
+	databases uint64 // the number of databases created for the tests, used for their names
+}
+
+// Start : creates the cluster, starts it and applies the migrations to the template database. The
+// cluster trusts every local connection and doesn't sync to disk, it must only be used by the tests
+func Start() (*Server, error) {
+	if os.Geteuid() == 0 {
+		return nil, ErrRoot
+	}
+
+	binDir, err := findBinDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "pgtest-")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{binDir: binDir, dir: dir}
+	ok := false
+	defer func() {
+		if !ok {
+			server.Stop()
+		}
+	}()
+
+	err = server.run("initdb", "-D", server.dataDir(), "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if err != nil {
+		return nil, err
+	}
+
+	server.port, err = freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	options := fmt.Sprintf("-F -h 127.0.0.1 -p %d -k %s -c full_page_writes=off -c synchronous_commit=off", server.port, dir)
+	err = server.run("pg_ctl", "-D", server.dataDir(), "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-t", startTimeout, "-w", "start")
+	if err != nil {
+		return nil, err
+	}
+	server.started = true
+
+	err = server.migrateTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	ok = true
+	return server, nil
+}
+
+// URL : returns the connection string of a database of the cluster
+func (s *Server) URL(database string) string {
+	return fmt.Sprintf("postgresql://postgres@127.0.0.1:%d/%s?sslmode=disable", s.port, database)
+}
+
+// NewDatabase : creates a migrated database for the test and returns it with its connection string,
+// the database is dropped once the test and its subtests have completed
+func (s *Server) NewDatabase(t testing.TB) (*sql.DB, string) {
+	t.Helper()
+
+	name := fmt.Sprintf("test_%d", atomic.AddUint64(&s.databases, 1))
+	err := s.exec(fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", name, templateDatabase))
+	if err != nil {
+		t.Fatalf("unable to create the test database, error: %s", err)
+	}
+
+	url := s.URL(name)
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("unable to connect to the test database, error: %s", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+
+		err := s.dropDatabase(name)
+		if err != nil {
+			t.Errorf("unable to drop the test database, error: %s", err)
+		}
+	})
+
+	return db, url
+}
+
+// Stop : stops the cluster and deletes its directory
+func (s *Server) Stop() error {
+	if s.started {
+		err := s.run("pg_ctl", "-D", s.dataDir(), "-m", "immediate", "-w", "stop")
+		if err != nil {
+			return err
+		}
+		s.started = false
+	}
+
+	return os.RemoveAll(s.dir)
+}
+
+// migrateTemplate : creates the template database and applies the migrations to it, a database can
+// only be copied once nobody is connected to it
+func (s *Server) migrateTemplate() error {
+	err := s.exec("CREATE DATABASE " + templateDatabase)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", s.URL(templateDatabase))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil && err != migration.ErrNoChange {
+		return err
+	}
+
+	return nil
+}
+
+// dropDatabase : drops the database, the connections left open by the test are terminated first
+func (s *Server) dropDatabase(name string) error {
+	return s.exec(
+		fmt.Sprintf("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = '%s' AND pid <> pg_backend_pid()", name),
+		"DROP DATABASE IF EXISTS "+name,
+	)
+}
+
+// exec : runs the statements on the maintenance database, CREATE and DROP DATABASE can't run within a
+// transaction or on the database they are about
+func (s *Server) exec(statements ...string) error {
+	db, err := sql.Open("postgres", s.URL("postgres"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, statement := range statements {
+		_, err = db.Exec(statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run : runs a binary of the installation, its output is part of the error if it fails
+func (s *Server) run(name string, args ...string) error {
+	cmd := exec.Command(filepath.Join(s.binDir, name), args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed, err: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *Server) dataDir() string {
+	return filepath.Join(s.dir, "data")
+}
+
+// findBinDir : returns the directory of initdb and pg_ctl, PG_BIN first, then the PATH and then the
+// directories of the common distributions, the newest version first
+func findBinDir() (string, error) {
+	candidates := []string{os.Getenv("PG_BIN")}
+
+	path, err := exec.LookPath("pg_ctl")
+	if err == nil {
+		candidates = append(candidates, filepath.Dir(path))
+	}
+
+	for _, pattern := range binDirPatterns {
+		matches, _ := filepath.Glob(pattern)
+		sort.SliceStable(matches, func(i, j int) bool { return majorVersion(matches[i]) > majorVersion(matches[j]) })
+		candidates = append(candidates, matches...)
+	}
+
+	for _, dir := range candidates {
+		if dir != "" && isExecutable(filepath.Join(dir, "initdb")) && isExecutable(filepath.Join(dir, "pg_ctl")) {
+			return dir, nil
+		}
+	}
+
+	return "", ErrNotInstalled
+}
+
+// majorVersion : the first number of the path, e.g: 14 for /usr/lib/postgresql/14/bin
+func majorVersion(path string) int {
+	digits := strings.FieldsFunc(path, func(r rune) bool { return r < '0' || r > '9' })
+	if len(digits) == 0 {
+		return 0
+	}
+
+	version, _ := strconv.Atoi(digits[0])
+	return version
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// freePort : returns a TCP port nobody listens on, for the cluster
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
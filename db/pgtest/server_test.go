@@ -0,0 +1,69 @@
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMajorVersion(t *testing.T) {
+	require.Equal(t, 14, majorVersion("/usr/lib/postgresql/14/bin"))
+	require.Equal(t, 9, majorVersion("/usr/pgsql-9.6/bin"))
+	require.Equal(t, 0, majorVersion("/usr/local/pgsql/bin"))
+}
+
+func TestFindBinDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PG_BIN", dir)
+
+	// both binaries are required
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "initdb"), []byte("#!/bin/sh\n"), 0755))
+	found, err := findBinDir()
+	if err == nil {
+		require.NotEqual(t, dir, found)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pg_ctl"), []byte("#!/bin/sh\n"), 0755))
+	found, err = findBinDir()
+	require.NoError(t, err)
+	require.Equal(t, dir, found)
+}
+
+func TestNewDatabase(t *testing.T) {
+	server, err := Start()
+	if err != nil {
+		t.Skipf("postgres is not available: %s", err)
+	}
+	defer func() {
+		require.NoError(t, server.Stop())
+	}()
+
+	t.Run("Isolated", func(t *testing.T) {
+		db1, _ := server.NewDatabase(t)
+		db2, _ := server.NewDatabase(t)
+
+		// migrated, with the system user
+		var count int
+		require.NoError(t, db1.QueryRowContext(context.Background(), "SELECT count(*) FROM users").Scan(&count))
+		require.Equal(t, 1, count)
+
+		_, err := db1.Exec("INSERT INTO users (username, password, full_name, email) VALUES ('alice', 'x', 'Alice', 'alice@example.com')")
+		require.NoError(t, err)
+
+		require.NoError(t, db2.QueryRow("SELECT count(*) FROM users").Scan(&count))
+		require.Equal(t, 1, count)
+	})
+
+	// dropped at the end of the test
+	db, err := sql.Open("postgres", server.URL("postgres"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM pg_database WHERE datname LIKE 'test_%'").Scan(&count))
+	require.Zero(t, count)
+}
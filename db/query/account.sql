@@ -2,15 +2,22 @@
 INSERT INTO accounts (
   user_id,
   balance,
-  currency
+  currency,
+  type,
+  nickname,
+  account_number
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -18,10 +25,16 @@ FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
-WHERE user_id = $1
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(type)::varchar IS NULL OR type = sqlc.narg(type))
 ORDER BY id
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CountAccountsByCurrency :one
+SELECT count(*) FROM accounts
+WHERE user_id = $1 AND currency = $2;
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -29,6 +42,12 @@ SET balance = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
@@ -36,4 +55,21 @@ WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1;
\ No newline at end of file
+DELETE FROM accounts WHERE id = $1;
+
+-- name: ListEndOfDayBalances :many
+SELECT a.id, a.type, a.currency,
+  (a.balance - COALESCE((SELECT SUM(e.amount) FROM entries e WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(day_end)), 0))::bigint AS balance
+FROM accounts a
+WHERE a.type = ANY(sqlc.arg(types)::varchar[])
+  AND a.created_at < sqlc.arg(day_end)
+  AND a.id > sqlc.arg(after_id)
+  AND a.id NOT IN (SELECT account_id FROM system_accounts)
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
+
+-- name: SetAccountFrozen :one
+UPDATE accounts
+SET is_frozen = $2
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
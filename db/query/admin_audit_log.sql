@@ -0,0 +1,19 @@
+-- name: CreateAdminAuditLog :one
+INSERT INTO admin_audit_logs (
+  actor,
+  action,
+  user_id,
+  account_id,
+  reason,
+  details
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListAdminAuditLogs :many
+SELECT * FROM admin_audit_logs
+WHERE (sqlc.narg(user_id)::bigint IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
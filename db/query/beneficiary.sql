@@ -0,0 +1,34 @@
+-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (
+  user_id,
+  label,
+  account_id
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetBeneficiary :one
+SELECT b.id, b.created_at, b.user_id, b.label, b.account_id, a.account_number, a.currency, u.full_name AS holder_name
+FROM beneficiaries b
+JOIN accounts a ON a.id = b.account_id
+JOIN users u ON u.id = a.user_id
+WHERE b.id = $1 LIMIT 1;
+
+-- name: ListBeneficiaries :many
+SELECT b.id, b.created_at, b.user_id, b.label, b.account_id, a.account_number, a.currency, u.full_name AS holder_name
+FROM beneficiaries b
+JOIN accounts a ON a.id = b.account_id
+JOIN users u ON u.id = a.user_id
+WHERE b.user_id = $1
+ORDER BY b.label
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateBeneficiaryLabel :one
+UPDATE beneficiaries
+SET label = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteBeneficiary :exec
+DELETE FROM beneficiaries WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,38 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetActiveHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1
+  AND status = 'active'
+  AND expires_at > now()
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetHeldAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM holds
+WHERE account_id = $1
+  AND status = 'active'
+  AND expires_at > now();
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $2, captured_amount = $3, transfer_id = $4, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpireHolds :execrows
+UPDATE holds
+SET status = 'expired', updated_at = now()
+WHERE status = 'active' AND expires_at <= now();
\ No newline at end of file
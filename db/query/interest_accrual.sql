@@ -0,0 +1,29 @@
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id,
+  accrual_date,
+  balance,
+  rate_bps,
+  amount_micros
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING *;
+
+-- name: ListUnpostedInterestAccrualsForUpdate :many
+SELECT * FROM interest_accruals
+WHERE account_id = $1 AND accrual_date >= sqlc.arg(from_date) AND accrual_date < sqlc.arg(to_date) AND posted_at IS NULL
+ORDER BY accrual_date
+FOR UPDATE;
+
+-- name: ListAccountIDsWithUnpostedInterest :many
+SELECT DISTINCT account_id FROM interest_accruals
+WHERE accrual_date >= sqlc.arg(from_date) AND accrual_date < sqlc.arg(to_date) AND posted_at IS NULL AND account_id > sqlc.arg(after_id)
+ORDER BY account_id
+LIMIT sqlc.arg('limit');
+
+-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posted_at = now(), transfer_id = sqlc.narg(transfer_id)
+WHERE account_id = sqlc.arg(account_id) AND accrual_date >= sqlc.arg(from_date) AND accrual_date < sqlc.arg(to_date) AND posted_at IS NULL;
\ No newline at end of file
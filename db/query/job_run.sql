@@ -0,0 +1,15 @@
+-- name: StartJobRun :one
+INSERT INTO job_runs (
+  job,
+  period
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (job, period) DO UPDATE SET job = EXCLUDED.job
+RETURNING *;
+
+-- name: CompleteJobRun :one
+UPDATE job_runs
+SET completed_at = now()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -12,6 +12,97 @@ INSERT INTO users (
 SELECT * FROM users
 WHERE id = $1 LIMIT 1;
 
+-- name: GetUserIDForUpdate :one
+SELECT id FROM users
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: GetUserByUsername :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: IncrementFailedLoginAttempts :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: LockUser :exec
+UPDATE users
+SET locked_until = $2
+WHERE id = $1;
+
+-- name: ResetFailedLoginAttempts :exec
+UPDATE users
+SET
+  failed_login_attempts = 0,
+  locked_until = '0001-01-01 00:00:00Z'
+WHERE id = $1;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = $2,
+  is_totp_enabled = false
+WHERE id = $1
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserTOTPLastUsedStep :execrows
+UPDATE users
+SET totp_last_used_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_used_step < sqlc.arg(step);
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE id = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+  password = $2,
+  password_changed_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+  full_name = COALESCE(sqlc.narg(full_name), full_name),
+  email = COALESCE(sqlc.narg(email), email),
+  is_email_verified = is_email_verified AND COALESCE(sqlc.narg(email), email) = email
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET is_email_verified = true
+WHERE id = $1 AND email = $2;
+
+-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET password = $2
+WHERE id = $1;
+
+-- name: DisableUser :one
+UPDATE users
+SET
+  is_disabled = true,
+  password_changed_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET is_disabled = false
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,29 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  user_id,
+  url,
+  secret,
+  event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+  AND (cardinality(event_types) = 0 OR sqlc.arg(event_type)::varchar = ANY(event_types))
+ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,57 @@
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  webhook_id,
+  event_id
+) VALUES (
+  $1, $2
+) ON CONFLICT (webhook_id, event_id) DO NOTHING;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT d.*, e.event_type
+FROM webhook_deliveries d
+JOIN outbox_events e ON e.id = d.event_id
+WHERE d.webhook_id = $1
+ORDER BY d.id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ClaimWebhookDeliveries :many
+WITH due AS (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+), claimed AS (
+  UPDATE webhook_deliveries d
+  SET next_attempt_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::int), updated_at = now()
+  FROM due
+  WHERE d.id = due.id
+  RETURNING d.id, d.webhook_id, d.event_id, d.attempts
+)
+SELECT c.id, c.webhook_id, c.event_id, c.attempts, w.url, w.secret, e.event_type, e.payload, e.created_at AS event_created_at
+FROM claimed c
+JOIN webhooks w ON w.id = c.webhook_id
+JOIN outbox_events e ON e.id = c.event_id
+ORDER BY c.id;
+
+-- name: RecordWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, last_status_code = $6, delivered_at = $7, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReplayWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = '', last_status_code = 0, delivered_at = NULL, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReplayDeadWebhookDeliveries :execrows
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = '', last_status_code = 0, updated_at = now()
+WHERE webhook_id = $1 AND status = 'dead';
\ No newline at end of file
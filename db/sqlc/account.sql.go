@@ -7,13 +7,17 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, created_at, user_id, balance, currency
+RETURNING id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen
 `
 
 type AddAccountBalanceParams struct {
@@ -30,28 +34,62 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.UserID,
 		&i.Balance,
 		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
 	)
 	return i, err
 }
 
+const countAccountsByCurrency = `-- name: CountAccountsByCurrency :one
+SELECT count(*) FROM accounts
+WHERE user_id = $1 AND currency = $2
+`
+
+type CountAccountsByCurrencyParams struct {
+	UserID   int64  `json:"user_id"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) CountAccountsByCurrency(ctx context.Context, arg CountAccountsByCurrencyParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAccountsByCurrency, arg.UserID, arg.Currency)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   user_id,
   balance,
-  currency
+  currency,
+  type,
+  nickname,
+  account_number
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, created_at, user_id, balance, currency
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen
 `
 
 type CreateAccountParams struct {
-	UserID   int64  `json:"user_id"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	UserID        int64  `json:"user_id"`
+	Balance       int64  `json:"balance"`
+	Currency      string `json:"currency"`
+	Type          string `json:"type"`
+	Nickname      string `json:"nickname"`
+	AccountNumber string `json:"account_number"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.UserID, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.UserID,
+		arg.Balance,
+		arg.Currency,
+		arg.Type,
+		arg.Nickname,
+		arg.AccountNumber,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +97,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.UserID,
 		&i.Balance,
 		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
 	)
 	return i, err
 }
@@ -73,7 +115,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, created_at, user_id, balance, currency FROM accounts
+SELECT id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,12 +128,38 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.UserID,
 		&i.Balance,
 		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, created_at, user_id, balance, currency FROM accounts
+SELECT id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -105,26 +173,40 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.UserID,
 		&i.Balance,
 		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, created_at, user_id, balance, currency FROM accounts
+SELECT id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen FROM accounts
 WHERE user_id = $1
+  AND ($2::varchar IS NULL OR currency = $2)
+  AND ($3::varchar IS NULL OR type = $3)
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $4
+OFFSET $5
 `
 
 type ListAccountsParams struct {
-	UserID int64 `json:"user_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID   int64          `json:"user_id"`
+	Currency sql.NullString `json:"currency"`
+	Type     sql.NullString `json:"type"`
+	Limit    int32          `json:"limit"`
+	Offset   int32          `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts,
+		arg.UserID,
+		arg.Currency,
+		arg.Type,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +220,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.UserID,
 			&i.Balance,
 			&i.Currency,
+			&i.Type,
+			&i.Nickname,
+			&i.AccountNumber,
+			&i.IsFrozen,
 		); err != nil {
 			return nil, err
 		}
@@ -152,11 +238,99 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listEndOfDayBalances = `-- name: ListEndOfDayBalances :many
+SELECT a.id, a.type, a.currency,
+  (a.balance - COALESCE((SELECT SUM(e.amount) FROM entries e WHERE e.account_id = a.id AND e.created_at >= $1), 0))::bigint AS balance
+FROM accounts a
+WHERE a.type = ANY($2::varchar[])
+  AND a.created_at < $1
+  AND a.id > $3
+  AND a.id NOT IN (SELECT account_id FROM system_accounts)
+ORDER BY a.id
+LIMIT $4
+`
+
+type ListEndOfDayBalancesParams struct {
+	DayEnd  time.Time `json:"day_end"`
+	Types   []string  `json:"types"`
+	AfterID int64     `json:"after_id"`
+	Limit   int32     `json:"limit"`
+}
+
+type ListEndOfDayBalancesRow struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+func (q *Queries) ListEndOfDayBalances(ctx context.Context, arg ListEndOfDayBalancesParams) ([]ListEndOfDayBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEndOfDayBalances,
+		arg.DayEnd,
+		pq.Array(arg.Types),
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEndOfDayBalancesRow{}
+	for rows.Next() {
+		var i ListEndOfDayBalancesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Currency,
+			&i.Balance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAccountFrozen = `-- name: SetAccountFrozen :one
+UPDATE accounts
+SET is_frozen = $2
+WHERE id = $1
+RETURNING id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen
+`
+
+type SetAccountFrozenParams struct {
+	ID       int64 `json:"id"`
+	IsFrozen bool  `json:"is_frozen"`
+}
+
+func (q *Queries) SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountFrozen, arg.ID, arg.IsFrozen)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, created_at, user_id, balance, currency
+RETURNING id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen
 `
 
 type UpdateAccountParams struct {
@@ -173,6 +347,39 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.UserID,
 		&i.Balance,
 		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
+	)
+	return i, err
+}
+
+const updateAccountNickname = `-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = $2
+WHERE id = $1
+RETURNING id, created_at, user_id, balance, currency, type, nickname, account_number, is_frozen
+`
+
+type UpdateAccountNicknameParams struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+}
+
+func (q *Queries) UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountNickname, arg.ID, arg.Nickname)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.Type,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.IsFrozen,
 	)
 	return i, err
 }
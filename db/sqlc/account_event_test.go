@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// accountNotification : the payload of the notifications of the `account_events` channel
+type accountNotification struct {
+	Type      string `json:"type"`
+	AccountID int64  `json:"account_id"`
+	EntryID   int64  `json:"entry_id"`
+	Amount    int64  `json:"amount"`
+}
+
+// receiveAccountNotifications : waits for n notifications of the accounts, the notifications of the
+// accounts of the other tests are skipped
+func receiveAccountNotifications(t *testing.T, listener *pq.Listener, n int, accounts ...Account) []accountNotification {
+	notifications := make([]accountNotification, 0, n)
+	timeout := time.After(time.Second * 5)
+	for len(notifications) < n {
+		select {
+		case notification := <-listener.Notify:
+			require.NotNil(t, notification)
+
+			var event accountNotification
+			require.NoError(t, json.Unmarshal([]byte(notification.Extra), &event))
+			for _, account := range accounts {
+				if event.AccountID == account.ID {
+					notifications = append(notifications, event)
+				}
+			}
+		case <-timeout:
+			t.Fatalf("received %d of %d notifications: %v", len(notifications), n, notifications)
+		}
+	}
+	return notifications
+}
+
+func TestAccountEventNotifications(t *testing.T) {
+	q, conn, dbURL := setupTestDBWithURL(t)
+
+	store := NewStore(conn)
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+
+	listener := pq.NewListener(dbURL, time.Second, time.Second, nil)
+	defer listener.Close()
+	require.NoError(t, listener.Listen("account_events"))
+
+	result, err := store.TransferTxn(context.Background(), TransferTxnParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	notifications := receiveAccountNotifications(t, listener, 4, account1, account2)
+	require.ElementsMatch(t, []accountNotification{
+		{Type: "entry", AccountID: account1.ID, EntryID: result.FromEntry.ID, Amount: -10},
+		{Type: "entry", AccountID: account2.ID, EntryID: result.ToEntry.ID, Amount: 10},
+		{Type: "balance", AccountID: account1.ID},
+		{Type: "balance", AccountID: account2.ID},
+	}, notifications)
+
+	// a hold only changes the available balance
+	createRandomHold(t, store, account2, account1, 5)
+
+	notifications = receiveAccountNotifications(t, listener, 1, account1, account2)
+	require.Equal(t, []accountNotification{{Type: "balance", AccountID: account2.ID}}, notifications)
+}
@@ -6,26 +6,33 @@ import (
 	"testing"
 	"time"
 
+	"github.com/skamranahmed/banking-system/accountnumber"
 	"github.com/skamranahmed/banking-system/utils"
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomAccount(t *testing.T) Account {
-	user := createRandomUser(t)
+func createRandomAccount(t *testing.T, q Querier) Account {
+	user := createRandomUser(t, q)
 
 	arg := CreateAccountParams{
-		UserID:   user.ID,
-		Balance:  utils.RandomMoney(),
-		Currency: utils.RandomCurrency(),
+		UserID:        user.ID,
+		Balance:       utils.RandomMoney(),
+		Currency:      utils.RandomCurrency(),
+		AccountNumber: randomAccountNumber(t),
+		Type:          utils.RandomAccountType(),
+		Nickname:      utils.RandomString(6),
 	}
 
-	account, err := testQueries.CreateAccount(context.Background(), arg)
+	account, err := q.CreateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
 
 	require.Equal(t, arg.UserID, account.UserID)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.Type, account.Type)
+	require.Equal(t, arg.Nickname, account.Nickname)
+	require.Equal(t, arg.AccountNumber, account.AccountNumber)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -34,12 +41,16 @@ func createRandomAccount(t *testing.T) Account {
 }
 
 func TestCreateAccount(t *testing.T) {
-	createRandomAccount(t)
+	q, _ := setupTestDB(t)
+
+	createRandomAccount(t, q)
 }
 
 func TestGetAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+	account2, err := q.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -52,14 +63,16 @@ func TestGetAccount(t *testing.T) {
 }
 
 func TestUpdateAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
 
 	arg := UpdateAccountParams{
 		ID:      account1.ID,
 		Balance: utils.RandomMoney(),
 	}
 
-	account2, err := testQueries.UpdateAccount(context.Background(), arg)
+	account2, err := q.UpdateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -71,22 +84,26 @@ func TestUpdateAccount(t *testing.T) {
 }
 
 func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
 
-	err := testQueries.DeleteAccount(context.Background(), account1.ID)
+	err := q.DeleteAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := q.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, account2)
 }
 
 func TestListAccount(t *testing.T) {
+	q, _ := setupTestDB(t)
+
 	var lastAccount Account
 
 	for i := 0; i < 10; i++ {
-		lastAccount = createRandomAccount(t)
+		lastAccount = createRandomAccount(t, q)
 	}
 
 	arg := ListAccountsParams{
@@ -95,7 +112,7 @@ func TestListAccount(t *testing.T) {
 		Offset: 0,
 	}
 
-	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	accounts, err := q.ListAccounts(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, accounts)
 
@@ -105,3 +122,113 @@ func TestListAccount(t *testing.T) {
 	}
 
 }
+
+func TestListAccountFilters(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+
+	accountTypes := []string{utils.AccountTypeChecking, utils.AccountTypeSavings, utils.AccountTypeSavings}
+	for _, accountType := range accountTypes {
+		_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+			UserID:        user.ID,
+			Currency:      utils.INR,
+			AccountNumber: randomAccountNumber(t),
+			Type:          accountType,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		UserID:        user.ID,
+		Currency:      utils.USD,
+		AccountNumber: randomAccountNumber(t),
+		Type:          utils.AccountTypeSavings,
+	})
+	require.NoError(t, err)
+
+	accounts, err := q.ListAccounts(context.Background(), ListAccountsParams{
+		UserID:   user.ID,
+		Currency: sql.NullString{String: utils.INR, Valid: true},
+		Type:     sql.NullString{String: utils.AccountTypeSavings, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+
+	for _, account := range accounts {
+		require.Equal(t, utils.INR, account.Currency)
+		require.Equal(t, utils.AccountTypeSavings, account.Type)
+	}
+
+	accounts, err = q.ListAccounts(context.Background(), ListAccountsParams{
+		UserID: user.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 4)
+}
+
+func TestCreateAccountInvalidType(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+
+	_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		UserID:        user.ID,
+		Currency:      utils.INR,
+		AccountNumber: randomAccountNumber(t),
+		Type:          "brokerage",
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateAccountNickname(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+
+	account2, err := q.UpdateAccountNickname(context.Background(), UpdateAccountNicknameParams{
+		ID:       account1.ID,
+		Nickname: "Rent",
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, "Rent", account2.Nickname)
+	require.Equal(t, account1.Balance, account2.Balance)
+}
+
+func randomAccountNumber(t *testing.T) string {
+	accountNumber, err := accountnumber.Generate()
+	require.NoError(t, err)
+	return accountNumber
+}
+
+func TestGetAccountByNumber(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+
+	account2, err := q.GetAccountByNumber(context.Background(), account1.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.AccountNumber, account2.AccountNumber)
+
+	_, err = q.GetAccountByNumber(context.Background(), randomAccountNumber(t))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateAccountDuplicateNumber(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+	user := createRandomUser(t, q)
+
+	_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		UserID:        user.ID,
+		Currency:      utils.INR,
+		AccountNumber: account1.AccountNumber,
+		Type:          utils.AccountTypeChecking,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBeneficiary(t *testing.T, q Querier, user User) (Beneficiary, Account) {
+	account := createRandomAccount(t, q)
+
+	arg := CreateBeneficiaryParams{
+		UserID:    user.ID,
+		Label:     utils.RandomName(),
+		AccountID: account.ID,
+	}
+
+	beneficiary, err := q.CreateBeneficiary(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, beneficiary.ID)
+	require.Equal(t, arg.UserID, beneficiary.UserID)
+	require.Equal(t, arg.Label, beneficiary.Label)
+	require.Equal(t, arg.AccountID, beneficiary.AccountID)
+	require.NotZero(t, beneficiary.CreatedAt)
+
+	return beneficiary, account
+}
+
+func TestCreateBeneficiary(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	beneficiary, account := createRandomBeneficiary(t, q, user)
+
+	// the label and the account are unique within the address book
+	_, err := q.CreateBeneficiary(context.Background(), CreateBeneficiaryParams{
+		UserID:    user.ID,
+		Label:     beneficiary.Label,
+		AccountID: createRandomAccount(t, q).ID,
+	})
+	require.Error(t, err)
+
+	_, err = q.CreateBeneficiary(context.Background(), CreateBeneficiaryParams{
+		UserID:    user.ID,
+		Label:     utils.RandomName(),
+		AccountID: account.ID,
+	})
+	require.Error(t, err)
+}
+
+func TestGetBeneficiary(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	beneficiary, account := createRandomBeneficiary(t, q, user)
+
+	holder, err := q.GetUser(context.Background(), account.UserID)
+	require.NoError(t, err)
+
+	row, err := q.GetBeneficiary(context.Background(), beneficiary.ID)
+	require.NoError(t, err)
+	require.Equal(t, beneficiary.ID, row.ID)
+	require.Equal(t, user.ID, row.UserID)
+	require.Equal(t, beneficiary.Label, row.Label)
+	require.Equal(t, account.ID, row.AccountID)
+	require.Equal(t, account.AccountNumber, row.AccountNumber)
+	require.Equal(t, account.Currency, row.Currency)
+	require.Equal(t, holder.FullName, row.HolderName)
+}
+
+func TestListBeneficiaries(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	for i := 0; i < 3; i++ {
+		createRandomBeneficiary(t, q, user)
+	}
+
+	rows, err := q.ListBeneficiaries(context.Background(), ListBeneficiariesParams{
+		UserID: user.ID,
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	// the order of the labels depends on the collation of the database
+	for _, row := range rows {
+		require.Equal(t, user.ID, row.UserID)
+	}
+}
+
+func TestUpdateAndDeleteBeneficiary(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	beneficiary, _ := createRandomBeneficiary(t, q, user)
+
+	updated, err := q.UpdateBeneficiaryLabel(context.Background(), UpdateBeneficiaryLabelParams{
+		ID:    beneficiary.ID,
+		Label: "Landlord",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Landlord", updated.Label)
+	require.Equal(t, beneficiary.AccountID, updated.AccountID)
+
+	err = q.DeleteBeneficiary(context.Background(), beneficiary.ID)
+	require.NoError(t, err)
+
+	_, err = q.GetBeneficiary(context.Background(), beneficiary.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -9,13 +9,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomEntry(t *testing.T, account Account) Entry {
+func createRandomEntry(t *testing.T, q Querier, account Account) Entry {
 	arg := CreateEntryParams{
 		AccountID: account.ID,
 		Amount:    utils.RandomEntryAmount(), // can be both positive or negative, depening upon credit or debit
 	}
 
-	entry, err := testQueries.CreateEntry(context.Background(), arg)
+	entry, err := q.CreateEntry(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
 
@@ -29,14 +29,18 @@ func createRandomEntry(t *testing.T, account Account) Entry {
 }
 
 func TestCreateEntry(t *testing.T) {
-	account := createRandomAccount(t)
-	createRandomEntry(t, account)
+	q, _ := setupTestDB(t)
+
+	account := createRandomAccount(t, q)
+	createRandomEntry(t, q, account)
 }
 
 func TestGetEntry(t *testing.T) {
-	account := createRandomAccount(t)
-	entry1 := createRandomEntry(t, account)
-	entry2, err := testQueries.GetEntry(context.Background(), entry1.ID)
+	q, _ := setupTestDB(t)
+
+	account := createRandomAccount(t, q)
+	entry1 := createRandomEntry(t, q, account)
+	entry2, err := q.GetEntry(context.Background(), entry1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry2)
 
@@ -47,9 +51,11 @@ func TestGetEntry(t *testing.T) {
 }
 
 func TestListEntries(t *testing.T) {
-	account := createRandomAccount(t)
+	q, _ := setupTestDB(t)
+
+	account := createRandomAccount(t, q)
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t, account)
+		createRandomEntry(t, q, account)
 	}
 
 	arg := ListEntriesParams{
@@ -58,7 +64,7 @@ func TestListEntries(t *testing.T) {
 		Offset:    5,
 	}
 
-	entries, err := testQueries.ListEntries(context.Background(), arg)
+	entries, err := q.ListEntries(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomHold(t *testing.T, store Store, account Account, toAccount Account, amount int64) Hold {
+	arg := CreateHoldParams{
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	hold, err := store.CreateHoldTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, hold.ID)
+	require.Equal(t, arg.AccountID, hold.AccountID)
+	require.Equal(t, arg.ToAccountID, hold.ToAccountID)
+	require.Equal(t, arg.Amount, hold.Amount)
+	require.Equal(t, HoldStatusActive, hold.Status)
+	require.WithinDuration(t, arg.ExpiresAt, hold.ExpiresAt, time.Second)
+	require.Zero(t, hold.CapturedAmount)
+	require.False(t, hold.TransferID.Valid)
+
+	return hold
+}
+
+func TestCreateHoldTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	// the hold reduces the available balance but not the ledger balance
+	createRandomHold(t, store, account1, account2, account1.Balance-1)
+
+	held, err := q.GetHeldAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-1, held)
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	_, err = store.CreateHoldTxn(context.Background(), CreateHoldParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      2,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	hold := createRandomHold(t, store, account1, account2, 10)
+
+	_, err := store.CaptureHoldTxn(context.Background(), CaptureHoldTxnParams{HoldID: hold.ID, Amount: 11})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	// a partial capture releases the rest of the hold
+	result, err := store.CaptureHoldTxn(context.Background(), CaptureHoldTxnParams{HoldID: hold.ID, Amount: 6})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, int64(6), result.Hold.CapturedAmount)
+	require.True(t, result.Hold.TransferID.Valid)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, account1.Balance-6, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account2.Balance+6, result.Transfer.ToAccount.Balance)
+
+	held, err := q.GetHeldAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	_, err = store.CaptureHoldTxn(context.Background(), CaptureHoldTxnParams{HoldID: hold.ID, Amount: 4})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestReleaseHoldTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	hold := createRandomHold(t, store, account1, account2, 10)
+
+	released, err := store.ReleaseHoldTxn(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusReleased, released.Status)
+	require.Zero(t, released.CapturedAmount)
+
+	held, err := q.GetHeldAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	_, err = store.ReleaseHoldTxn(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	_, err = store.CaptureHoldTxn(context.Background(), CaptureHoldTxnParams{HoldID: hold.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestExpireHolds(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	hold, err := q.CreateHold(context.Background(), CreateHoldParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	// an expired hold doesn't reserve funds and can't be captured, even before it is swept
+	held, err := q.GetHeldAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	_, err = store.CaptureHoldTxn(context.Background(), CaptureHoldTxnParams{HoldID: hold.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	expired, err := q.ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	hold, err = q.GetHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusExpired, hold.Status)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomInterestAccrual(t *testing.T, q Querier, account Account, day time.Time, micros int64) InterestAccrual {
+	arg := CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		AccrualDate:  day,
+		Balance:      account.Balance,
+		RateBps:      200,
+		AmountMicros: micros,
+	}
+
+	accrual, err := q.CreateInterestAccrual(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, accrual.ID)
+	require.Equal(t, arg.AccountID, accrual.AccountID)
+	require.Equal(t, arg.Balance, accrual.Balance)
+	require.Equal(t, arg.RateBps, accrual.RateBps)
+	require.Equal(t, arg.AmountMicros, accrual.AmountMicros)
+	require.False(t, accrual.PostedAt.Valid)
+	require.False(t, accrual.TransferID.Valid)
+
+	return accrual
+}
+
+func TestCreateInterestAccrual(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	account := createRandomAccount(t, q)
+	day := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	createRandomInterestAccrual(t, q, account, day, 1000)
+
+	// the account accrues once a day
+	_, err := q.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		AccrualDate:  day,
+		Balance:      account.Balance,
+		RateBps:      200,
+		AmountMicros: 1000,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListEndOfDayBalances(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	account := createRandomAccount(t, q)
+	dayEnd := time.Now()
+
+	// entries created after the end of the day are not part of its balance
+	_, err := q.CreateEntry(context.Background(), CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	balances, err := q.ListEndOfDayBalances(context.Background(), ListEndOfDayBalancesParams{
+		DayEnd:  dayEnd,
+		Types:   []string{account.Type},
+		AfterID: account.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	require.Equal(t, account.ID, balances[0].ID)
+	require.Equal(t, account.Type, balances[0].Type)
+	require.Equal(t, account.Currency, balances[0].Currency)
+	require.Equal(t, account.Balance-10, balances[0].Balance)
+
+	// accounts of other types are left out
+	balances, err = q.ListEndOfDayBalances(context.Background(), ListEndOfDayBalancesParams{
+		DayEnd:  dayEnd,
+		Types:   []string{"unknown"},
+		AfterID: account.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Empty(t, balances)
+}
+
+func TestPostInterestTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	expenseAccount := createRandomAccount(t, q)
+	account, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		UserID:        createRandomUser(t, q).ID,
+		Balance:       utils.RandomMoney(),
+		Currency:      expenseAccount.Currency,
+		Type:          utils.AccountTypeSavings,
+		AccountNumber: randomAccountNumber(t),
+	})
+	require.NoError(t, err)
+
+	fromDate := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	toDate := fromDate.AddDate(0, 1, 0)
+
+	// 1.4 + 1.2 minor units round to 3, the accrual of the next month is left alone
+	createRandomInterestAccrual(t, q, account, fromDate, 1400000)
+	createRandomInterestAccrual(t, q, account, fromDate.AddDate(0, 0, 1), 1200000)
+	createRandomInterestAccrual(t, q, account, toDate, 5000000)
+
+	arg := PostInterestTxnParams{
+		AccountID:        account.ID,
+		ExpenseAccountID: expenseAccount.ID,
+		FromDate:         fromDate,
+		ToDate:           toDate,
+	}
+
+	result, err := store.PostInterestTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.Amount)
+	require.Equal(t, 2, result.Accruals)
+	require.Equal(t, expenseAccount.ID, result.Transfer.FromAccount.ID)
+	require.Equal(t, account.Balance+3, result.Transfer.ToAccount.Balance)
+
+	// posting the period again doesn't credit the interest twice
+	result, err = store.PostInterestTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, result.Amount)
+	require.Zero(t, result.Accruals)
+
+	updatedAccount, err := q.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+3, updatedAccount.Balance)
+
+	accountIDs, err := q.ListAccountIDsWithUnpostedInterest(context.Background(), ListAccountIDsWithUnpostedInterestParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+		AfterID:  account.ID - 1,
+		Limit:    1,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, accountIDs, account.ID)
+}
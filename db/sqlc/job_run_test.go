@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRun(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	arg := StartJobRunParams{
+		Job:    utils.RandomString(10),
+		Period: "2022-06",
+	}
+
+	run, err := q.StartJobRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, run.ID)
+	require.Equal(t, arg.Job, run.Job)
+	require.Equal(t, arg.Period, run.Period)
+	require.False(t, run.CompletedAt.Valid)
+
+	// starting an interrupted run again returns the same run
+	restarted, err := q.StartJobRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, run.ID, restarted.ID)
+
+	completed, err := q.CompleteJobRun(context.Background(), run.ID)
+	require.NoError(t, err)
+	require.True(t, completed.CompletedAt.Valid)
+
+	restarted, err = q.StartJobRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, run.ID, restarted.ID)
+	require.True(t, restarted.CompletedAt.Valid)
+}
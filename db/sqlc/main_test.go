@@ -6,24 +6,58 @@ import (
 	"os"
 	"testing"
 
-	_ "github.com/lib/pq"
-
-	"github.com/skamranahmed/banking-system/config"
+	"github.com/skamranahmed/banking-system/db/pgtest"
 )
 
-var testQueries *Queries
-var testDB *sql.DB
+// testServer : the throwaway postgres of the tests, nil if it couldn't be started
+var testServer *pgtest.Server
 
 func TestMain(m *testing.M) {
-	// load config
-	config.Load("../../config")
-
 	var err error
-	testDB, err = sql.Open(config.TestDbDriver, config.TestDbHost)
+	testServer, err = pgtest.Start()
 	if err != nil {
-		log.Fatalf("unable to connect to the db, error: %s", err)
+		// the CI must run every test
+		if os.Getenv("CI") != "" {
+			log.Fatalf("unable to start postgres, error: %s", err)
+		}
+		printSkipNotice(err)
+	}
+
+	code := m.Run()
+
+	if testServer != nil {
+		err = testServer.Stop()
+		if err != nil {
+			log.Printf("unable to stop postgres, error: %s", err)
+		}
+	}
+
+	os.Exit(code)
+}
+
+// printSkipNotice : tells once per run that the tests which need postgres are skipped, `go test` only
+// shows it along with the output of the package, e.g: with -v
+func printSkipNotice(err error) {
+	const rule = "================================================================================"
+	log.Printf("\n%s\nWARNING: POSTGRES IS NOT AVAILABLE, THE TESTS WHICH NEED IT ARE SKIPPED\n%s\n"+
+		"install postgres or set PG_BIN to its binaries, see the README, and run the tests as a non-root user\n%s",
+		rule, err, rule)
+}
+
+// setupTestDB : returns the queries and the connection of a migrated database of the test's own,
+// dropped at the end of the test. The test is skipped if postgres isn't available
+func setupTestDB(t *testing.T) (*Queries, *sql.DB) {
+	q, conn, _ := setupTestDBWithURL(t)
+	return q, conn
+}
+
+// setupTestDBWithURL : setupTestDB along with the URL of the database, for the tests which open
+// connections of their own
+func setupTestDBWithURL(t *testing.T) (*Queries, *sql.DB, string) {
+	if testServer == nil {
+		t.Skip("postgres is not available")
 	}
 
-	testQueries = New(testDB)
-	os.Exit(m.Run())
+	conn, url := testServer.NewDatabase(t)
+	return New(conn), conn, url
 }
@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -14,6 +16,37 @@ type Account struct {
 	UserID    int64     `json:"user_id"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
+	// checking, savings or wallet
+	Type string `json:"type"`
+	// chosen by the user, empty if not set
+	Nickname string `json:"nickname"`
+	// IBAN-style external number with mod-97 check digits, used for addressing the transfers
+	AccountNumber string `json:"account_number"`
+	// a frozen account can't send or receive transfers through the API
+	IsFrozen bool `json:"is_frozen"`
+}
+
+type AdminAuditLog struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// operator who ran the admin command
+	Actor string `json:"actor"`
+	// e.g: user.disable
+	Action    string        `json:"action"`
+	UserID    sql.NullInt64 `json:"user_id"`
+	AccountID sql.NullInt64 `json:"account_id"`
+	Reason    string        `json:"reason"`
+	// the changed values, e.g: the amount of an adjustment
+	Details json.RawMessage `json:"details"`
+}
+
+type Beneficiary struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int64     `json:"user_id"`
+	// chosen by the user, unique within the address book of the user
+	Label     string `json:"label"`
+	AccountID int64  `json:"account_id"`
 }
 
 type Entry struct {
@@ -24,13 +57,102 @@ type Entry struct {
 	Amount int64 `json:"amount"`
 }
 
+type Hold struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// the funds are reserved on this account
+	AccountID int64 `json:"account_id"`
+	// credited once the hold is captured
+	ToAccountID int64 `json:"to_account_id"`
+	// reserved amount, must be positive
+	Amount int64 `json:"amount"`
+	// active, captured, released or expired
+	Status string `json:"status"`
+	// an active hold stops reserving the funds once it expires
+	ExpiresAt time.Time `json:"expires_at"`
+	// at most the amount, the rest is released on capture
+	CapturedAmount int64 `json:"captured_amount"`
+	// the transfer which captured the hold
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// end of day balance the interest has been computed on
+	Balance int64 `json:"balance"`
+	// annual rate in basis points
+	RateBps int32 `json:"rate_bps"`
+	// in millionths of the minor unit, rounded once the month is posted
+	AmountMicros int64        `json:"amount_micros"`
+	PostedAt     sql.NullTime `json:"posted_at"`
+	// the transfer which credited the interest, null if it rounded to zero
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+type JobRun struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Job       string    `json:"job"`
+	// e.g: 2022-04-30 for a daily job, 2022-04 for a monthly job
+	Period      string       `json:"period"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+}
+
+type OutboxEvent struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// e.g: transfer.debited
+	EventType string `json:"event_type"`
+	AccountID int64  `json:"account_id"`
+	// owner of the account, the event is delivered to the webhooks of this user
+	UserID  int64           `json:"user_id"`
+	Payload json.RawMessage `json:"payload"`
+	// set once the deliveries of the event have been created
+	DispatchedAt sql.NullTime `json:"dispatched_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int64     `json:"user_id"`
+	// sha256 of the reset token, the token itself is only sent to the user
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int64     `json:"user_id"`
+	// sha256 of the normalized recovery code
+	CodeHash string       `json:"code_hash"`
+	UsedAt   sql.NullTime `json:"used_at"`
+}
+
+type SystemAccount struct {
+	// e.g: interest_expense
+	Purpose   string    `json:"purpose"`
+	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64     `json:"id"`
 	CreatedAt     time.Time `json:"created_at"`
 	FromAccountID int64     `json:"from_account_id"`
 	ToAccountID   int64     `json:"to_account_id"`
-	// must be positive
+	// gross amount debited from the sender, must be positive
 	Amount int64 `json:"amount"`
+	// part of the amount credited to the fee account, the recipient gets the rest
+	Fee int64 `json:"fee"`
+	// revenue account of the bank, null if no fee has been charged
+	FeeAccountID sql.NullInt64 `json:"fee_account_id"`
 }
 
 type User struct {
@@ -40,4 +162,45 @@ type User struct {
 	Password  string    `json:"password"`
 	FullName  string    `json:"full_name"`
 	Email     string    `json:"email"`
+	// consecutive failed logins, reset on a successful login
+	FailedLoginAttempts int32     `json:"failed_login_attempts"`
+	LockedUntil         time.Time `json:"locked_until"`
+	// base32 encoded, set on enrollment and only used once is_totp_enabled is true
+	TotpSecret    string `json:"totp_secret"`
+	IsTotpEnabled bool   `json:"is_totp_enabled"`
+	// time step of the last accepted code, older or equal steps are rejected to prevent a replay
+	TotpLastUsedStep int64 `json:"totp_last_used_step"`
+	// the access tokens issued before it are rejected
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	// a disabled user can't log in, the tokens are revoked when disabling
+	IsDisabled bool `json:"is_disabled"`
+}
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int64     `json:"user_id"`
+	Url       string    `json:"url"`
+	// key of the HMAC signature of the deliveries
+	Secret string `json:"secret"`
+	// the webhook receives every event type if empty
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookDelivery struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	WebhookID int64     `json:"webhook_id"`
+	EventID   int64     `json:"event_id"`
+	// pending, succeeded or dead once the attempts have been exhausted
+	Status   string `json:"status"`
+	Attempts int32  `json:"attempts"`
+	// a pending delivery is attempted once this time has passed
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error"`
+	// HTTP status of the last attempt, 0 if no response has been received
+	LastStatusCode int32        `json:"last_status_code"`
+	DeliveredAt    sql.NullTime `json:"delivered_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
 }
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, q Querier, user User, expiresAt time.Time) PasswordResetToken {
+	arg := CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: utils.RandomString(64),
+		ExpiresAt: expiresAt,
+	}
+
+	resetToken, err := q.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, resetToken)
+
+	require.Equal(t, arg.UserID, resetToken.UserID)
+	require.Equal(t, arg.TokenHash, resetToken.TokenHash)
+	require.WithinDuration(t, arg.ExpiresAt, resetToken.ExpiresAt, time.Second)
+	require.False(t, resetToken.UsedAt.Valid)
+
+	require.NotZero(t, resetToken.ID)
+	require.NotZero(t, resetToken.CreatedAt)
+
+	return resetToken
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+}
+
+func TestUsePasswordResetToken(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	resetToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+
+	usedToken, err := q.UsePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, resetToken.ID, usedToken.ID)
+	require.True(t, usedToken.UsedAt.Valid)
+
+	// a reset token can only be used once
+	_, err = q.UsePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// an expired reset token can't be used
+	expiredToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(-time.Minute))
+	_, err = q.UsePasswordResetToken(context.Background(), expiredToken.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDeletePasswordResetTokens(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	resetToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+
+	err := q.DeletePasswordResetTokens(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	_, err = q.UsePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRecoveryCode(t *testing.T, q Querier, user User) RecoveryCode {
+	arg := CreateRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: utils.RandomString(64),
+	}
+
+	recoveryCode, err := q.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, recoveryCode)
+
+	require.Equal(t, arg.UserID, recoveryCode.UserID)
+	require.Equal(t, arg.CodeHash, recoveryCode.CodeHash)
+	require.False(t, recoveryCode.UsedAt.Valid)
+
+	require.NotZero(t, recoveryCode.ID)
+	require.NotZero(t, recoveryCode.CreatedAt)
+
+	return recoveryCode
+}
+
+func TestCreateRecoveryCode(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	createRandomRecoveryCode(t, q, user)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	recoveryCode := createRandomRecoveryCode(t, q, user)
+
+	arg := UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: recoveryCode.CodeHash,
+	}
+
+	rowsAffected, err := q.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	// a recovery code can only be used once
+	rowsAffected, err = q.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+
+	// a recovery code can't be used by another user
+	anotherUser := createRandomUser(t, q)
+	anotherRecoveryCode := createRandomRecoveryCode(t, q, anotherUser)
+
+	rowsAffected, err = q.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: anotherRecoveryCode.CodeHash,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+}
+
+func TestDeleteRecoveryCodes(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	recoveryCode := createRandomRecoveryCode(t, q, user)
+
+	err := q.DeleteRecoveryCodes(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	rowsAffected, err := q.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: recoveryCode.CodeHash,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 )
 
 var txnKey = struct{}{}
@@ -13,24 +14,49 @@ var txnKey = struct{}{}
 type Store interface {
 	Querier
 	TransferTxn(ctx context.Context, arg TransferTxnParams) (TransferTxnResult, error)
+	BatchTransferTxn(ctx context.Context, arg BatchTransferTxnParams) (BatchTransferTxnResult, error)
+	CreateAccountTxn(ctx context.Context, arg CreateAccountTxnParams) (Account, error)
+	SystemAccountTxn(ctx context.Context, arg SystemAccountTxnParams) (Account, error)
+	PostInterestTxn(ctx context.Context, arg PostInterestTxnParams) (PostInterestTxnResult, error)
+	CreateHoldTxn(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CaptureHoldTxn(ctx context.Context, arg CaptureHoldTxnParams) (CaptureHoldTxnResult, error)
+	ReleaseHoldTxn(ctx context.Context, holdID int64) (Hold, error)
+	FanOutOutboxTxn(ctx context.Context, limit int32) (FanOutOutboxTxnResult, error)
+	EnableTOTPTxn(ctx context.Context, arg EnableTOTPTxnParams) (User, error)
+	ChangePasswordTxn(ctx context.Context, arg ChangePasswordTxnParams) (User, error)
+	ResetPasswordTxn(ctx context.Context, arg ResetPasswordTxnParams) (User, error)
+	AdminCreateUserTxn(ctx context.Context, arg AdminCreateUserTxnParams) (User, error)
+	AdminSetUserDisabledTxn(ctx context.Context, arg AdminSetUserDisabledTxnParams) (User, error)
+	AdminResetPasswordTxn(ctx context.Context, arg AdminResetPasswordTxnParams) (User, error)
+	AdminSetAccountFrozenTxn(ctx context.Context, arg AdminSetAccountFrozenTxnParams) (Account, error)
+	AdminAdjustAccountTxn(ctx context.Context, arg AdminAdjustAccountTxnParams) (TransferTxnResult, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transaction
 type SQLStore struct {
 	*Queries
+	txnStore
 	db *sql.DB // this is required to create a new db txn
 }
 
+// txnStore : implements the transactions of the Store with the queries of a transaction, so that every
+// Store runs the same transactions and only differs in how a transaction is executed
+type txnStore struct {
+	execTxn func(ctx context.Context, fn func(Querier) error) error
+}
+
 // NewStore : creates a new Store
 func NewStore(db *sql.DB) Store {
-	return &SQLStore{
+	store := &SQLStore{
 		db:      db,
 		Queries: New(db),
 	}
+	store.txnStore = txnStore{execTxn: store.execTxn}
+	return store
 }
 
 // execTxn : executes a function within a db transaction
-func (s *SQLStore) execTxn(ctx context.Context, fn func(*Queries) error) error {
+func (s *SQLStore) execTxn(ctx context.Context, fn func(Querier) error) error {
 	// begin the transaction
 	txn, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -52,11 +78,20 @@ func (s *SQLStore) execTxn(ctx context.Context, fn func(*Queries) error) error {
 	return txn.Commit()
 }
 
+// ErrFeeExceedsAmount : the fee of a transfer must be less than its amount
+var ErrFeeExceedsAmount = errors.New("fee must be less than the amount")
+
 // TransferTxnParams : contains the input parameters of the transfer transaction
 type TransferTxnParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	Amount        int64 `json:"amount"`         // gross amount debited from the `from account`
+	Fee           int64 `json:"fee"`            // part of the amount credited to the fee account, the `to account` gets the rest
+	FeeAccountID  int64 `json:"fee_account_id"` // required if a fee is charged
+
+	// CheckAvailable : fails the transfer with ErrInsufficientFunds if the available balance of the
+	// `from account` doesn't cover the amount, checked while its row is locked by the transaction
+	CheckAvailable bool `json:"-"`
 }
 
 // TransferTxnResult : contains the result of transfer transaction
@@ -66,201 +101,242 @@ type TransferTxnResult struct {
 	ToAccount   Account  `json:"to_account"`   // the `to account` after its balance has been updated
 	FromEntry   Entry    `json:"from_entry"`   // the entry record of the `from account`
 	ToEntry     Entry    `json:"to_entry"`     // the entry record of the `to account`
+	FeeAccount  Account  `json:"fee_account"`  // the fee account after its balance has been updated, empty if no fee has been charged
+	FeeEntry    Entry    `json:"fee_entry"`    // the entry record of the fee account, empty if no fee has been charged
 }
 
 // TransferTxn : performs money transfer from one account to the other
-func (s *SQLStore) TransferTxn(ctx context.Context, arg TransferTxnParams) (TransferTxnResult, error) {
+func (s *txnStore) TransferTxn(ctx context.Context, arg TransferTxnParams) (TransferTxnResult, error) {
 	/*
 		Steps Involved:
 		- Begin Transaction
+			- Lock the accounts and check the available balance of `from account`, if CheckAvailable is set
 			- Create a transfer record
 			- Create individual entry records for both `from account` and `to account`
 			- Update the balance of `from account`
 			- Update the balance of `to account`
+			- Create the entry record of the fee account and update its balance, if a fee is charged
+			- Write the events of the transfer to the outbox
 		- Commit
 	*/
 
 	var result TransferTxnResult
 
-	err := s.execTxn(ctx, func(q *Queries) error {
+	err := s.execTxn(ctx, func(q Querier) error {
 		var err error
-		txnName := ctx.Value(txnKey)
-
-		fmt.Println(txnName, "create transfer")
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
+		if !arg.CheckAvailable {
+			result, err = transfer(ctx, q, arg)
 			return err
 		}
 
-		fmt.Println(txnName, "create entry 1")
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // debit
-		})
+		// the accounts are locked the same way as for a batch of a single transfer
+		available, err := lockAccounts(ctx, q, []TransferTxnParams{arg})
 		if err != nil {
 			return err
 		}
 
-		fmt.Println(txnName, "create entry 2")
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount, // credit
-		})
-		if err != nil {
-			return err
+		result, err = checkedTransfer(ctx, q, available, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// transfer : performs the steps of a transfer with the queries of an ongoing transaction,
+// so that the other transactions can move money as part of their own work
+func transfer(ctx context.Context, q Querier, arg TransferTxnParams) (TransferTxnResult, error) {
+	var result TransferTxnResult
+	var err error
+	txnName := ctx.Value(txnKey)
+
+	if arg.Fee < 0 || arg.Fee >= arg.Amount {
+		return result, ErrFeeExceedsAmount
+	}
+
+	var feeAccountID sql.NullInt64
+	if arg.Fee > 0 {
+		if arg.FeeAccountID == 0 {
+			return result, errors.New("fee account is required to charge a fee")
 		}
+		feeAccountID = sql.NullInt64{Int64: arg.FeeAccountID, Valid: true}
+	}
+
+	// the `to account` is credited the net amount
+	netAmount := arg.Amount - arg.Fee
 
-		// logic for updating the account balance of `from account` and `to account`
-		/*
-			// fmt.Println(txnName, "get account 1 for update")
-			// account1, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
-			// if err != nil {
-			// 	return err
-			// }
-
-			// fmt.Println(txnName, "update account 1")
-			// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-			// 	ID:      arg.FromAccountID,
-			// 	Balance: account1.Balance - arg.Amount,
-			// })
-			// if err != nil {
-			// 	return err
-			// }
-		*/
+	fmt.Println(txnName, "create transfer")
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Fee:           arg.Fee,
+		FeeAccountID:  feeAccountID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	fmt.Println(txnName, "create entry 1")
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount, // debit
+	})
+	if err != nil {
+		return result, err
+	}
+
+	fmt.Println(txnName, "create entry 2")
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    netAmount, // credit
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// logic for updating the account balance of `from account` and `to account`
+	/*
+		// fmt.Println(txnName, "get account 1 for update")
+		// account1, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		// if err != nil {
+		// 	return result, err
+		// }
 
 		// fmt.Println(txnName, "update account 1")
-		// result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		// 	ID:     arg.FromAccountID,
-		// 	Amount: -arg.Amount,
+		// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
+		// 	ID:      arg.FromAccountID,
+		// 	Balance: account1.Balance - arg.Amount,
 		// })
 		// if err != nil {
-		// 	return err
+		// 	return result, err
 		// }
+	*/
 
-		/*
-			// fmt.Println(txnName, "get account 2 for update")
-			// account2, err := q.GetAccountForUpdate(ctx, arg.ToAccountID)
-			// if err != nil {
-			// 	return err
-			// }
-
-			// fmt.Println(txnName, "update account 2")
-			// result.ToAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-			// 	ID:      arg.ToAccountID,
-			// 	Balance: account2.Balance + arg.Amount,
-			// })
-			// if err != nil {
-			// 	return err
-			// }
-		*/
+	// fmt.Println(txnName, "update account 1")
+	// result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+	// 	ID:     arg.FromAccountID,
+	// 	Amount: -arg.Amount,
+	// })
+	// if err != nil {
+	// 	return result, err
+	// }
+
+	/*
+		// fmt.Println(txnName, "get account 2 for update")
+		// account2, err := q.GetAccountForUpdate(ctx, arg.ToAccountID)
+		// if err != nil {
+		// 	return result, err
+		// }
 
 		// fmt.Println(txnName, "update account 2")
-		// result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		// 	ID:     arg.ToAccountID,
-		// 	Amount: arg.Amount,
+		// result.ToAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
+		// 	ID:      arg.ToAccountID,
+		// 	Balance: account2.Balance + arg.Amount,
 		// })
 		// if err != nil {
-		// 	return err
+		// 	return result, err
 		// }
+	*/
 
-		/*
-			----------------------------------------------------------------------------------------------
+	// fmt.Println(txnName, "update account 2")
+	// result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+	// 	ID:     arg.ToAccountID,
+	// 	Amount: arg.Amount,
+	// })
+	// if err != nil {
+	// 	return result, err
+	// }
 
-			##################           Possible Deadlock Situation                ######################
+	/*
+		----------------------------------------------------------------------------------------------
 
-			-- Transaction 1: transfer Rs.10 from account 1 to account 2
-			BEGIN;
+		##################           Possible Deadlock Situation                ######################
 
-			UPDATE accounts SET balance = balance - 10 WHERE id = 1 RETURNING *;
-			UPDATE accounts SET balance = balance + 10 WHERE id = 2 RETURNING *;
+		-- Transaction 1: transfer Rs.10 from account 1 to account 2
+		BEGIN;
 
-			COMMIT;
+		UPDATE accounts SET balance = balance - 10 WHERE id = 1 RETURNING *;
+		UPDATE accounts SET balance = balance + 10 WHERE id = 2 RETURNING *;
 
+		COMMIT;
 
-			-- Transaction 2: transfer Rs.10 from account 2 to account 1
-			BEGIN;
 
-			UPDATE accounts SET balance = balance - 10 WHERE id = 2 RETURNING *;
-			UPDATE accounts SET balance = balance + 10 WHERE id = 1 RETURNING *;
+		-- Transaction 2: transfer Rs.10 from account 2 to account 1
+		BEGIN;
 
-			COMMIT;
+		UPDATE accounts SET balance = balance - 10 WHERE id = 2 RETURNING *;
+		UPDATE accounts SET balance = balance + 10 WHERE id = 1 RETURNING *;
 
-			----------------------------------------------------------------------------------------------
+		COMMIT;
 
-			##################           Solution for Preventing Deadlock              ###################
+		----------------------------------------------------------------------------------------------
 
+		##################           Solution for Preventing Deadlock              ###################
 
 
-			if from_account_id < to_account_id -> We update the from_account first and then the to_account
-			if the from_account_id > to_account_id -> We update the to_account first and then the from account
 
-			-- Transaction 1: transfer Rs.10 from account 1 to account 2
-			BEGIN;
+		if from_account_id < to_account_id -> We update the from_account first and then the to_account
+		if the from_account_id > to_account_id -> We update the to_account first and then the from account
 
-			UPDATE accounts SET balance = balance - 10 WHERE id = 1 RETURNING *;
-			UPDATE accounts SET balance = balance + 10 WHERE id = 2 RETURNING *;
+		-- Transaction 1: transfer Rs.10 from account 1 to account 2
+		BEGIN;
 
-			COMMIT;
+		UPDATE accounts SET balance = balance - 10 WHERE id = 1 RETURNING *;
+		UPDATE accounts SET balance = balance + 10 WHERE id = 2 RETURNING *;
 
+		COMMIT;
 
-			-- Transaction 2: transfer Rs.10 from account 2 to account 1
-			BEGIN;
 
-			UPDATE accounts SET balance = balance + 10 WHERE id = 1 RETURNING *;
-			UPDATE accounts SET balance = balance - 10 WHERE id = 2 RETURNING *;
+		-- Transaction 2: transfer Rs.10 from account 2 to account 1
+		BEGIN;
 
-			COMMIT;
+		UPDATE accounts SET balance = balance + 10 WHERE id = 1 RETURNING *;
+		UPDATE accounts SET balance = balance - 10 WHERE id = 2 RETURNING *;
 
-			----------------------------------------------------------------------------------------------
+		COMMIT;
 
-		*/
+		----------------------------------------------------------------------------------------------
 
-		if arg.FromAccountID < arg.ToAccountID {
-			fmt.Println(txnName, "updating the fromAccount")
-			result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-				ID:     arg.FromAccountID,
-				Amount: -arg.Amount, // debit
-			})
-			if err != nil {
-				return err
-			}
+	*/
 
-			fmt.Println(txnName, "updating the toAccount")
-			result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-				ID:     arg.ToAccountID,
-				Amount: arg.Amount, // credit
-			})
-			if err != nil {
-				return err
-			}
+	// the fee entry is created along with the others, before any balance is updated
+	if arg.Fee > 0 {
+		result.FeeEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.FeeAccountID,
+			Amount:    arg.Fee, // credit
+		})
+		if err != nil {
+			return result, err
+		}
+	}
 
-		} else {
-			fmt.Println(txnName, "updating the toAccount")
-			result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-				ID:     arg.ToAccountID,
-				Amount: arg.Amount, // credit
-			})
-			if err != nil {
-				return err
-			}
+	// the balances, the one of the fee account included, are updated in the order of the account IDs.
+	// lockAccounts locks the accounts of a batch in the same order, so no transfer or batch can wait
+	// for a lock held by another one which waits for a lock it holds
+	type balanceUpdate struct {
+		accountID int64
+		amount    int64
+		account   *Account // receives the account after the update
+	}
 
-			fmt.Println(txnName, "updating the fromAccount")
-			result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-				ID:     arg.FromAccountID,
-				Amount: -arg.Amount, // debit
-			})
-			if err != nil {
-				return err
-			}
+	updates := []balanceUpdate{
+		{arg.FromAccountID, -arg.Amount, &result.FromAccount}, // debit
+		{arg.ToAccountID, netAmount, &result.ToAccount},       // credit
+	}
+	if arg.Fee > 0 {
+		updates = append(updates, balanceUpdate{arg.FeeAccountID, arg.Fee, &result.FeeAccount}) // credit
+	}
+	sort.SliceStable(updates, func(i, j int) bool { return updates[i].accountID < updates[j].accountID })
 
+	for _, update := range updates {
+		*update.account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     update.accountID,
+			Amount: update.amount,
+		})
+		if err != nil {
+			return result, err
 		}
+	}
 
-		return nil
-	})
-
-	return result, err
+	return result, recordTransferEvents(ctx, q, result)
 }
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAccountLimitReached : the user already holds the maximum number of accounts of the currency
+var ErrAccountLimitReached = errors.New("account limit of the currency reached")
+
+// CreateAccountTxnParams : contains the input parameters of the create account transaction
+type CreateAccountTxnParams struct {
+	CreateAccountParams
+	MaxAccountsPerCurrency int64 `json:"max_accounts_per_currency"` // 0 means no limit
+}
+
+// CreateAccountTxn : creates the account unless the user already holds the maximum number of accounts
+// of its currency. The row of the user is locked so that concurrent requests can't exceed the limit,
+// sql.ErrNoRows is returned if the user doesn't exist
+func (s *txnStore) CreateAccountTxn(ctx context.Context, arg CreateAccountTxnParams) (Account, error) {
+	var account Account
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		_, err := q.GetUserIDForUpdate(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		if arg.MaxAccountsPerCurrency > 0 {
+			count, err := q.CountAccountsByCurrency(ctx, CountAccountsByCurrencyParams{
+				UserID:   arg.UserID,
+				Currency: arg.Currency,
+			})
+			if err != nil {
+				return err
+			}
+
+			if count >= arg.MaxAccountsPerCurrency {
+				return ErrAccountLimitReached
+			}
+		}
+
+		account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		return err
+	})
+
+	return account, err
+}
@@ -0,0 +1,270 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrAuditReasonRequired : the admin changes are only made along with who made them and why
+var ErrAuditReasonRequired = errors.New("actor and reason are required")
+
+// actions of the admin audit logs
+const (
+	AuditActionUserCreate        = "user.create"
+	AuditActionUserDisable       = "user.disable"
+	AuditActionUserEnable        = "user.enable"
+	AuditActionUserResetPassword = "user.reset_password"
+	AuditActionAccountFreeze     = "account.freeze"
+	AuditActionAccountUnfreeze   = "account.unfreeze"
+	AuditActionAccountAdjust     = "account.adjust"
+)
+
+// Audit : who made an admin change and why, recorded within the transaction of the change
+type Audit struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func (audit Audit) validate() error {
+	if strings.TrimSpace(audit.Actor) == "" || strings.TrimSpace(audit.Reason) == "" {
+		return ErrAuditReasonRequired
+	}
+	return nil
+}
+
+// recordAudit : writes the audit log of the change, the user and the account are left null if zero
+func recordAudit(ctx context.Context, q Querier, audit Audit, action string, userID int64, accountID int64, details interface{}) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateAdminAuditLog(ctx, CreateAdminAuditLogParams{
+		Actor:     strings.TrimSpace(audit.Actor),
+		Action:    action,
+		UserID:    sql.NullInt64{Int64: userID, Valid: userID != 0},
+		AccountID: sql.NullInt64{Int64: accountID, Valid: accountID != 0},
+		Reason:    strings.TrimSpace(audit.Reason),
+		Details:   payload,
+	})
+	return err
+}
+
+// AdminCreateUserTxnParams : contains the input parameters of the admin create user transaction
+type AdminCreateUserTxnParams struct {
+	CreateUserParams
+	Audit Audit `json:"audit"`
+}
+
+// AdminCreateUserTxn : creates a user on behalf of support
+func (s *txnStore) AdminCreateUserTxn(ctx context.Context, arg AdminCreateUserTxnParams) (User, error) {
+	var user User
+
+	err := arg.Audit.validate()
+	if err != nil {
+		return user, err
+	}
+
+	err = s.execTxn(ctx, func(q Querier) error {
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, AuditActionUserCreate, user.ID, 0, map[string]string{
+			"username": user.Username,
+			"email":    user.Email,
+		})
+	})
+
+	return user, err
+}
+
+// AdminSetUserDisabledTxnParams : contains the input parameters of the admin set user disabled transaction
+type AdminSetUserDisabledTxnParams struct {
+	UserID     int64     `json:"user_id"`
+	Disabled   bool      `json:"disabled"`
+	DisabledAt time.Time `json:"disabled_at"` // the access tokens issued before it get rejected, only used when disabling
+	Audit      Audit     `json:"audit"`
+}
+
+// AdminSetUserDisabledTxn : disables or enables the user, disabling revokes the access tokens of the user
+func (s *txnStore) AdminSetUserDisabledTxn(ctx context.Context, arg AdminSetUserDisabledTxnParams) (User, error) {
+	var user User
+
+	err := arg.Audit.validate()
+	if err != nil {
+		return user, err
+	}
+
+	err = s.execTxn(ctx, func(q Querier) error {
+		action := AuditActionUserEnable
+		if arg.Disabled {
+			action = AuditActionUserDisable
+			user, err = q.DisableUser(ctx, DisableUserParams{
+				ID:                arg.UserID,
+				PasswordChangedAt: arg.DisabledAt,
+			})
+		} else {
+			user, err = q.EnableUser(ctx, arg.UserID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, action, user.ID, 0, map[string]bool{"is_disabled": arg.Disabled})
+	})
+
+	return user, err
+}
+
+// AdminResetPasswordTxnParams : contains the input parameters of the admin reset password transaction
+type AdminResetPasswordTxnParams struct {
+	UserID            int64     `json:"user_id"`
+	Password          string    `json:"password"`            // the hashed new password
+	PasswordChangedAt time.Time `json:"password_changed_at"` // the access tokens issued before it get rejected
+	Audit             Audit     `json:"audit"`
+}
+
+// AdminResetPasswordTxn : sets a new password for a user who is stuck, the lockout is lifted and the
+// pending reset tokens are discarded. The password itself isn't recorded in the audit log
+func (s *txnStore) AdminResetPasswordTxn(ctx context.Context, arg AdminResetPasswordTxnParams) (User, error) {
+	var user User
+
+	err := arg.Audit.validate()
+	if err != nil {
+		return user, err
+	}
+
+	err = s.execTxn(ctx, func(q Querier) error {
+		err := q.ResetFailedLoginAttempts(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:                arg.UserID,
+			Password:          arg.Password,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeletePasswordResetTokens(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, AuditActionUserResetPassword, user.ID, 0, map[string]string{})
+	})
+
+	return user, err
+}
+
+// AdminSetAccountFrozenTxnParams : contains the input parameters of the admin set account frozen transaction
+type AdminSetAccountFrozenTxnParams struct {
+	AccountID int64 `json:"account_id"`
+	Frozen    bool  `json:"frozen"`
+	Audit     Audit `json:"audit"`
+}
+
+// AdminSetAccountFrozenTxn : freezes or unfreezes the account
+func (s *txnStore) AdminSetAccountFrozenTxn(ctx context.Context, arg AdminSetAccountFrozenTxnParams) (Account, error) {
+	var account Account
+
+	err := arg.Audit.validate()
+	if err != nil {
+		return account, err
+	}
+
+	err = s.execTxn(ctx, func(q Querier) error {
+		account, err = q.SetAccountFrozen(ctx, SetAccountFrozenParams{
+			ID:       arg.AccountID,
+			IsFrozen: arg.Frozen,
+		})
+		if err != nil {
+			return err
+		}
+
+		action := AuditActionAccountUnfreeze
+		if arg.Frozen {
+			action = AuditActionAccountFreeze
+		}
+
+		return recordAudit(ctx, q, arg.Audit, action, account.UserID, account.ID, map[string]bool{"is_frozen": arg.Frozen})
+	})
+
+	return account, err
+}
+
+// AdminAdjustAccountTxnParams : contains the input parameters of the admin adjust account transaction
+type AdminAdjustAccountTxnParams struct {
+	AccountID           int64 `json:"account_id"`
+	AdjustmentAccountID int64 `json:"adjustment_account_id"` // system account of the currency of the account
+	Amount              int64 `json:"amount"`                // credited to the account if positive, debited if negative
+	Audit               Audit `json:"audit"`
+}
+
+// AdminAdjustAccountTxn : corrects the balance of the account with a transfer from or to the adjustment
+// account, so that the ledger keeps balancing. A debit has to be covered by the available balance
+func (s *txnStore) AdminAdjustAccountTxn(ctx context.Context, arg AdminAdjustAccountTxnParams) (TransferTxnResult, error) {
+	var result TransferTxnResult
+
+	err := arg.Audit.validate()
+	if err != nil {
+		return result, err
+	}
+
+	if arg.Amount == 0 {
+		return result, errors.New("adjustment amount must not be zero")
+	}
+
+	err = s.execTxn(ctx, func(q Querier) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		transferArg := TransferTxnParams{
+			FromAccountID: arg.AdjustmentAccountID,
+			ToAccountID:   account.ID,
+			Amount:        arg.Amount,
+		}
+		if arg.Amount < 0 {
+			transferArg = TransferTxnParams{
+				FromAccountID: account.ID,
+				ToAccountID:   arg.AdjustmentAccountID,
+				Amount:        -arg.Amount,
+			}
+		}
+
+		// the account and the adjustment account are locked in the order of their IDs, like for any transfer
+		available, err := lockAccounts(ctx, q, []TransferTxnParams{transferArg})
+		if err != nil {
+			return err
+		}
+
+		// only a debit has to be covered by the available balance of the account
+		if arg.Amount < 0 {
+			result, err = checkedTransfer(ctx, q, available, transferArg)
+		} else {
+			result, err = transfer(ctx, q, transferArg)
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, AuditActionAccountAdjust, account.UserID, account.ID, map[string]interface{}{
+			"amount":      arg.Amount,
+			"currency":    account.Currency,
+			"transfer_id": result.Transfer.ID,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// requireAuditLog : checks the latest audit log of the account, or of the user if accountID is zero
+func requireAuditLog(t *testing.T, q Querier, audit Audit, action string, userID int64, accountID int64) AdminAuditLog {
+	arg := ListAdminAuditLogsParams{
+		UserID: sql.NullInt64{Int64: userID, Valid: accountID == 0},
+		Limit:  1,
+	}
+	if accountID != 0 {
+		arg.AccountID = sql.NullInt64{Int64: accountID, Valid: true}
+	}
+
+	logs, err := q.ListAdminAuditLogs(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	auditLog := logs[0]
+	require.Equal(t, audit.Actor, auditLog.Actor)
+	require.Equal(t, audit.Reason, auditLog.Reason)
+	require.Equal(t, action, auditLog.Action)
+	require.Equal(t, sql.NullInt64{Int64: userID, Valid: true}, auditLog.UserID)
+	require.WithinDuration(t, time.Now(), auditLog.CreatedAt, time.Minute)
+
+	return auditLog
+}
+
+func TestAdminCreateUserTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	audit := Audit{Actor: "support", Reason: "signed up at the branch"}
+
+	arg := AdminCreateUserTxnParams{
+		CreateUserParams: CreateUserParams{
+			Username: utils.RandomName(),
+			Password: "hashed",
+			FullName: utils.RandomName(),
+			Email:    utils.RandomEmail(),
+		},
+		Audit: audit,
+	}
+
+	user, err := store.AdminCreateUserTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+
+	requireAuditLog(t, q, audit, AuditActionUserCreate, user.ID, 0)
+
+	// nothing is created without a reason
+	arg.Username = utils.RandomName()
+	arg.Email = utils.RandomEmail()
+	arg.Audit.Reason = " "
+	_, err = store.AdminCreateUserTxn(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAuditReasonRequired)
+
+	_, err = q.GetUserByUsername(context.Background(), arg.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAdminSetUserDisabledTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	user := createRandomUser(t, q)
+	audit := Audit{Actor: "support", Reason: "reported as compromised"}
+
+	disabledAt := time.Now().Truncate(time.Microsecond)
+	disabledUser, err := store.AdminSetUserDisabledTxn(context.Background(), AdminSetUserDisabledTxnParams{
+		UserID:     user.ID,
+		Disabled:   true,
+		DisabledAt: disabledAt,
+		Audit:      audit,
+	})
+	require.NoError(t, err)
+	require.True(t, disabledUser.IsDisabled)
+	require.WithinDuration(t, disabledAt, disabledUser.PasswordChangedAt, time.Second)
+
+	auditLog := requireAuditLog(t, q, audit, AuditActionUserDisable, user.ID, 0)
+	require.JSONEq(t, `{"is_disabled": true}`, string(auditLog.Details))
+
+	enabledUser, err := store.AdminSetUserDisabledTxn(context.Background(), AdminSetUserDisabledTxnParams{
+		UserID: user.ID,
+		Audit:  audit,
+	})
+	require.NoError(t, err)
+	require.False(t, enabledUser.IsDisabled)
+
+	// enabling doesn't give the revoked tokens back
+	require.Equal(t, disabledUser.PasswordChangedAt, enabledUser.PasswordChangedAt)
+	requireAuditLog(t, q, audit, AuditActionUserEnable, user.ID, 0)
+}
+
+func TestAdminResetPasswordTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	user := createRandomUser(t, q)
+	audit := Audit{Actor: "support", Reason: "locked out"}
+
+	_, err := q.IncrementFailedLoginAttempts(context.Background(), user.ID)
+	require.NoError(t, err)
+	createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Hour))
+
+	updatedUser, err := store.AdminResetPasswordTxn(context.Background(), AdminResetPasswordTxnParams{
+		UserID:            user.ID,
+		Password:          "new hash",
+		PasswordChangedAt: time.Now(),
+		Audit:             audit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new hash", updatedUser.Password)
+	require.Zero(t, updatedUser.FailedLoginAttempts)
+
+	// the password isn't part of the details
+	auditLog := requireAuditLog(t, q, audit, AuditActionUserResetPassword, user.ID, 0)
+	require.JSONEq(t, `{}`, string(auditLog.Details))
+}
+
+func TestAdminSetAccountFrozenTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	account := createRandomAccount(t, q)
+	audit := Audit{Actor: "support", Reason: "court order"}
+
+	frozenAccount, err := store.AdminSetAccountFrozenTxn(context.Background(), AdminSetAccountFrozenTxnParams{
+		AccountID: account.ID,
+		Frozen:    true,
+		Audit:     audit,
+	})
+	require.NoError(t, err)
+	require.True(t, frozenAccount.IsFrozen)
+	requireAuditLog(t, q, audit, AuditActionAccountFreeze, account.UserID, account.ID)
+
+	unfrozenAccount, err := store.AdminSetAccountFrozenTxn(context.Background(), AdminSetAccountFrozenTxnParams{
+		AccountID: account.ID,
+		Audit:     audit,
+	})
+	require.NoError(t, err)
+	require.False(t, unfrozenAccount.IsFrozen)
+	requireAuditLog(t, q, audit, AuditActionAccountUnfreeze, account.UserID, account.ID)
+}
+
+func TestAdminAdjustAccountTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	account := createFundedAccount(t, q)
+	audit := Audit{Actor: "support", Reason: "refund of a duplicate charge"}
+
+	adjustmentAccount, err := store.SystemAccountTxn(context.Background(), SystemAccountTxnParams{
+		Purpose:       SystemAccountAdjustment,
+		Currency:      account.Currency,
+		AccountNumber: randomAccountNumber(t),
+	})
+	require.NoError(t, err)
+
+	// a credit comes from the adjustment account
+	result, err := store.AdminAdjustAccountTxn(context.Background(), AdminAdjustAccountTxnParams{
+		AccountID:           account.ID,
+		AdjustmentAccountID: adjustmentAccount.ID,
+		Amount:              25,
+		Audit:               audit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, adjustmentAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account.Balance+25, result.ToAccount.Balance)
+
+	auditLog := requireAuditLog(t, q, audit, AuditActionAccountAdjust, account.UserID, account.ID)
+	var details map[string]interface{}
+	require.NoError(t, json.Unmarshal(auditLog.Details, &details))
+	require.Equal(t, float64(25), details["amount"])
+	require.Equal(t, float64(result.Transfer.ID), details["transfer_id"])
+
+	// a debit goes to the adjustment account
+	result, err = store.AdminAdjustAccountTxn(context.Background(), AdminAdjustAccountTxnParams{
+		AccountID:           account.ID,
+		AdjustmentAccountID: adjustmentAccount.ID,
+		Amount:              -10,
+		Audit:               audit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, adjustmentAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, account.Balance+15, result.FromAccount.Balance)
+
+	// the debit has to be covered by the balance
+	_, err = store.AdminAdjustAccountTxn(context.Background(), AdminAdjustAccountTxnParams{
+		AccountID:           account.ID,
+		AdjustmentAccountID: adjustmentAccount.ID,
+		Amount:              -(account.Balance + 16),
+		Audit:               audit,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
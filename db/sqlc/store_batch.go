@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// BatchTransferTxnParams : contains the input parameters of the batch transfer transaction
+type BatchTransferTxnParams struct {
+	Transfers []TransferTxnParams `json:"transfers"`
+	Atomic    bool                `json:"atomic"` // all the transfers succeed or fail together, otherwise each one is applied on its own
+}
+
+// BatchTransferTxnResult : contains the result of the batch transfer transaction, in the order of the transfers
+type BatchTransferTxnResult struct {
+	Results []TransferTxnResult `json:"results"` // empty for the transfers which failed
+	Errors  []error             `json:"-"`       // nil for the transfers which succeeded
+}
+
+// BatchItemError : the failure of a transfer of an atomic batch, which failed the whole batch
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("transfer %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransferTxn : performs the transfers of a batch, the available balance of the `from account` is
+// checked for every transfer. An atomic batch runs in a single transaction which locks all the accounts
+// upfront in the order of their IDs, so that concurrent batches can't deadlock each other or the other
+// transfers. Otherwise every transfer runs in its own transaction and a failure doesn't affect the others
+func (s *txnStore) BatchTransferTxn(ctx context.Context, arg BatchTransferTxnParams) (BatchTransferTxnResult, error) {
+	result := BatchTransferTxnResult{
+		Results: make([]TransferTxnResult, len(arg.Transfers)),
+		Errors:  make([]error, len(arg.Transfers)),
+	}
+
+	if !arg.Atomic {
+		for i, transferArg := range arg.Transfers {
+			err := s.execTxn(ctx, func(q Querier) error {
+				available, err := lockAccounts(ctx, q, []TransferTxnParams{transferArg})
+				if err != nil {
+					return err
+				}
+
+				result.Results[i], err = checkedTransfer(ctx, q, available, transferArg)
+				return err
+			})
+			if err != nil {
+				result.Results[i] = TransferTxnResult{}
+				result.Errors[i] = err
+			}
+		}
+
+		return result, nil
+	}
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		available, err := lockAccounts(ctx, q, arg.Transfers)
+		if err != nil {
+			return err
+		}
+
+		for i, transferArg := range arg.Transfers {
+			result.Results[i], err = checkedTransfer(ctx, q, available, transferArg)
+			if err != nil {
+				return &BatchItemError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return BatchTransferTxnResult{}, err
+	}
+
+	return result, nil
+}
+
+// lockAccounts : locks all the accounts of the transfers, the fee accounts included, in the order of their
+// IDs and returns their available balances, the balance less the funds reserved by the active holds.
+// transfer updates the balances in the same order, so that every transaction acquires the locks in it
+func lockAccounts(ctx context.Context, q Querier, transfers []TransferTxnParams) (map[int64]int64, error) {
+	available := make(map[int64]int64)
+	for _, transferArg := range transfers {
+		available[transferArg.FromAccountID] = 0
+		available[transferArg.ToAccountID] = 0
+		if transferArg.Fee > 0 {
+			available[transferArg.FeeAccountID] = 0
+		}
+	}
+
+	ids := make([]int64, 0, len(available))
+	for id := range available {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		held, err := q.GetHeldAmount(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		available[id] = account.Balance - held
+	}
+
+	return available, nil
+}
+
+// checkedTransfer : performs the transfer if the available balance of the `from account` covers it,
+// the available balances are kept up to date for the next transfers of the batch
+func checkedTransfer(ctx context.Context, q Querier, available map[int64]int64, arg TransferTxnParams) (TransferTxnResult, error) {
+	if available[arg.FromAccountID] < arg.Amount {
+		return TransferTxnResult{}, ErrInsufficientFunds
+	}
+
+	result, err := transfer(ctx, q, arg)
+	if err != nil {
+		return result, err
+	}
+
+	available[arg.FromAccountID] -= arg.Amount
+	available[arg.ToAccountID] += arg.Amount - arg.Fee
+	if arg.Fee > 0 {
+		available[arg.FeeAccountID] += arg.Fee
+	}
+
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createFundedAccount : creates a random account with a balance large enough for the batches
+func createFundedAccount(t *testing.T, q Querier) Account {
+	account := createRandomAccount(t, q)
+
+	account, err := q.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 1000,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestBatchTransferTxnAtomic(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+	account3 := createFundedAccount(t, q)
+
+	result, err := store.BatchTransferTxn(context.Background(), BatchTransferTxnParams{
+		Transfers: []TransferTxnParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+			{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 20},
+		},
+		Atomic: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 2)
+	require.Equal(t, []error{nil, nil}, result.Errors)
+	require.Equal(t, account1.Balance-30, result.Results[1].FromAccount.Balance)
+	require.Equal(t, account3.Balance+20, result.Results[1].ToAccount.Balance)
+
+	// the second transfer exceeds the balance left by the first one, so neither is applied
+	_, err = store.BatchTransferTxn(context.Background(), BatchTransferTxnParams{
+		Transfers: []TransferTxnParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+			{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: account1.Balance - 30},
+		},
+		Atomic: true,
+	})
+	var itemErr *BatchItemError
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-30, updatedAccount1.Balance)
+}
+
+func TestBatchTransferTxnBestEffort(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+
+	result, err := store.BatchTransferTxn(context.Background(), BatchTransferTxnParams{
+		Transfers: []TransferTxnParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: account1.Balance},
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Errors[0])
+	require.ErrorIs(t, result.Errors[1], ErrInsufficientFunds)
+	require.Empty(t, result.Results[1])
+	require.NoError(t, result.Errors[2])
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-15, updatedAccount1.Balance)
+}
+
+func TestBatchTransferTxnDeadlock(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+	account3 := createFundedAccount(t, q)
+
+	n := 10
+	errs := make(chan error)
+
+	// the batches lock the same accounts, listed in different orders
+	for i := 0; i < n; i++ {
+		transfers := []TransferTxnParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1},
+			{FromAccountID: account2.ID, ToAccountID: account3.ID, Amount: 1},
+			{FromAccountID: account3.ID, ToAccountID: account1.ID, Amount: 1},
+		}
+		if i%2 == 1 {
+			transfers[0], transfers[2] = transfers[2], transfers[0]
+		}
+
+		go func() {
+			_, err := store.BatchTransferTxn(context.Background(), BatchTransferTxnParams{
+				Transfers: transfers,
+				Atomic:    true,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	for _, account := range []Account{account1, account2, account3} {
+		updatedAccount, err := q.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updatedAccount.Balance)
+	}
+}
+
+func TestBatchTransferTxnFeeDeadlock(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	// the fee account has the lowest ID, so it is locked first by everyone
+	feeAccount := createFundedAccount(t, q)
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+
+	n := 10
+	errs := make(chan error)
+
+	// the batches and the single transfers charge a fee to the same account
+	for i := 0; i < n; i++ {
+		arg := TransferTxnParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Fee: 1, FeeAccountID: feeAccount.ID}
+		if i%2 == 1 {
+			arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+		}
+
+		go func(i int) {
+			var err error
+			if i%4 < 2 {
+				_, err = store.BatchTransferTxn(context.Background(), BatchTransferTxnParams{
+					Transfers: []TransferTxnParams{arg},
+					Atomic:    true,
+				})
+			} else {
+				_, err = store.TransferTxn(context.Background(), arg)
+			}
+			errs <- err
+		}(i)
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	updatedFeeAccount, err := q.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance+int64(n), updatedFeeAccount.Balance)
+}
@@ -0,0 +1,494 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// testStoreConformance : the behaviour every Store must share, so that a test written against one of
+// them holds for the others. The cases only rely on the rows they create, the db might not be empty
+func testStoreConformance(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	createUser := func(t *testing.T) User {
+		user, err := store.CreateUser(ctx, CreateUserParams{
+			Username: utils.RandomName(),
+			Password: utils.RandomString(16),
+			FullName: utils.RandomName(),
+			Email:    utils.RandomEmail(),
+		})
+		require.NoError(t, err)
+		return user
+	}
+
+	createAccount := func(t *testing.T, user User, balance int64) Account {
+		account, err := store.CreateAccount(ctx, CreateAccountParams{
+			UserID:        user.ID,
+			Balance:       balance,
+			Currency:      "INR",
+			Type:          "checking",
+			AccountNumber: randomAccountNumber(t),
+		})
+		require.NoError(t, err)
+		return account
+	}
+
+	requirePqError := func(t *testing.T, err error, name string, constraint string) {
+		var pqErr *pq.Error
+		require.True(t, errors.As(err, &pqErr), "expected a *pq.Error, got %v", err)
+		require.Equal(t, name, string(pqErr.Code.Name()))
+		if constraint != "" {
+			require.Equal(t, constraint, pqErr.Constraint)
+		}
+	}
+
+	t.Run("System User", func(t *testing.T) {
+		user, err := store.GetUserByUsername(ctx, SystemUsername)
+		require.NoError(t, err)
+		require.Equal(t, SystemUsername, user.Username)
+	})
+
+	t.Run("Missing Rows", func(t *testing.T) {
+		_, err := store.GetUser(ctx, -1)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = store.GetAccount(ctx, -1)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = store.GetTransfer(ctx, -1)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = store.UpdateAccount(ctx, UpdateAccountParams{ID: -1, Balance: 10})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// :exec queries don't report a missing row
+		require.NoError(t, store.DeleteAccount(ctx, -1))
+		require.NoError(t, store.MarkOutboxEventDispatched(ctx, -1))
+
+		rows, err := store.UseRecoveryCode(ctx, UseRecoveryCodeParams{UserID: -1, CodeHash: "missing"})
+		require.NoError(t, err)
+		require.Zero(t, rows)
+	})
+
+	t.Run("Users", func(t *testing.T) {
+		user := createUser(t)
+		require.NotZero(t, user.ID)
+		require.NotZero(t, user.CreatedAt)
+		require.False(t, user.IsEmailVerified)
+
+		byEmail, err := store.GetUserByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, byEmail.ID)
+
+		_, err = store.CreateUser(ctx, CreateUserParams{
+			Username: user.Username,
+			Password: utils.RandomString(16),
+			FullName: utils.RandomName(),
+			Email:    utils.RandomEmail(),
+		})
+		requirePqError(t, err, "unique_violation", "users_username_key")
+
+		rows, err := store.MarkUserEmailVerified(ctx, MarkUserEmailVerifiedParams{ID: user.ID, Email: user.Email})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rows)
+
+		// the full name is kept and a changed email has to be verified again
+		email := utils.RandomEmail()
+		updated, err := store.UpdateUser(ctx, UpdateUserParams{ID: user.ID, Email: sql.NullString{String: email, Valid: true}})
+		require.NoError(t, err)
+		require.Equal(t, user.FullName, updated.FullName)
+		require.Equal(t, email, updated.Email)
+		require.False(t, updated.IsEmailVerified)
+
+		// a step can't be used twice
+		rows, err = store.UpdateUserTOTPLastUsedStep(ctx, UpdateUserTOTPLastUsedStepParams{ID: user.ID, Step: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rows)
+
+		rows, err = store.UpdateUserTOTPLastUsedStep(ctx, UpdateUserTOTPLastUsedStepParams{ID: user.ID, Step: 10})
+		require.NoError(t, err)
+		require.Zero(t, rows)
+	})
+
+	t.Run("Accounts", func(t *testing.T) {
+		user := createUser(t)
+
+		_, err := store.CreateAccount(ctx, CreateAccountParams{
+			UserID:        -1,
+			Currency:      "INR",
+			Type:          "checking",
+			AccountNumber: randomAccountNumber(t),
+		})
+		requirePqError(t, err, "foreign_key_violation", "accounts_user_id_fkey")
+
+		_, err = store.CreateAccount(ctx, CreateAccountParams{
+			UserID:        user.ID,
+			Currency:      "INR",
+			Type:          "current",
+			AccountNumber: randomAccountNumber(t),
+		})
+		requirePqError(t, err, "check_violation", "accounts_type_check")
+
+		accounts := make([]Account, 4)
+		for i := range accounts {
+			accounts[i] = createAccount(t, user, int64(i))
+		}
+
+		_, err = store.CreateAccount(ctx, CreateAccountParams{
+			UserID:        user.ID,
+			Currency:      "INR",
+			Type:          "checking",
+			AccountNumber: accounts[0].AccountNumber,
+		})
+		requirePqError(t, err, "unique_violation", "accounts_account_number_idx")
+
+		listed, err := store.ListAccounts(ctx, ListAccountsParams{UserID: user.ID, Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		require.Equal(t, accounts[1:3], listed)
+
+		listed, err = store.ListAccounts(ctx, ListAccountsParams{
+			UserID:   user.ID,
+			Currency: sql.NullString{String: "USD", Valid: true},
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Empty(t, listed)
+
+		count, err := store.CountAccountsByCurrency(ctx, CountAccountsByCurrencyParams{UserID: user.ID, Currency: "INR"})
+		require.NoError(t, err)
+		require.Equal(t, int64(len(accounts)), count)
+
+		account, err := store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: accounts[0].ID, Amount: 15})
+		require.NoError(t, err)
+		require.Equal(t, int64(15), account.Balance)
+
+		require.NoError(t, store.DeleteAccount(ctx, accounts[3].ID))
+		_, err = store.GetAccount(ctx, accounts[3].ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// an account with entries can't be deleted
+		_, err = store.CreateEntry(ctx, CreateEntryParams{AccountID: accounts[2].ID, Amount: 5})
+		require.NoError(t, err)
+		requirePqError(t, store.DeleteAccount(ctx, accounts[2].ID), "foreign_key_violation", "entries_account_id_fkey")
+	})
+
+	t.Run("Transfer", func(t *testing.T) {
+		account1 := createAccount(t, createUser(t), 100)
+		account2 := createAccount(t, createUser(t), 100)
+		feeAccount := createAccount(t, createUser(t), 0)
+
+		result, err := store.TransferTxn(ctx, TransferTxnParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        30,
+			Fee:           2,
+			FeeAccountID:  feeAccount.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(70), result.FromAccount.Balance)
+		require.Equal(t, int64(128), result.ToAccount.Balance)
+		require.Equal(t, int64(2), result.FeeAccount.Balance)
+		require.Equal(t, int64(-30), result.FromEntry.Amount)
+		require.Equal(t, int64(28), result.ToEntry.Amount)
+		require.Equal(t, int64(2), result.FeeEntry.Amount)
+
+		transfer, err := store.GetTransfer(ctx, result.Transfer.ID)
+		require.NoError(t, err)
+		require.Equal(t, result.Transfer, transfer)
+
+		_, err = store.TransferTxn(ctx, TransferTxnParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 5})
+		require.NoError(t, err)
+
+		transfers, err := store.ListTransfers(ctx, ListTransfersParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account1.ID,
+			Limit:         10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 2)
+		require.Equal(t, result.Transfer.ID, transfers[0].ID)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account1.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, []int64{-30, 5}, []int64{entries[0].Amount, entries[1].Amount})
+
+		_, err = store.TransferTxn(ctx, TransferTxnParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Fee: 10})
+		require.ErrorIs(t, err, ErrFeeExceedsAmount)
+
+		_, err = store.TransferTxn(ctx, TransferTxnParams{FromAccountID: account1.ID, ToAccountID: -1, Amount: 10})
+		requirePqError(t, err, "foreign_key_violation", "transfers_to_account_id_fkey")
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = store.TransferTxn(canceled, TransferTxnParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+		require.Error(t, err)
+
+		account, err := store.GetAccount(ctx, account1.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(75), account.Balance)
+	})
+
+	t.Run("Concurrent Transfers", func(t *testing.T) {
+		account1 := createAccount(t, createUser(t), 1000)
+		account2 := createAccount(t, createUser(t), 1000)
+
+		n := 10
+		errs := make(chan error)
+		for i := 0; i < n; i++ {
+			arg := TransferTxnParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10}
+			if i%2 == 1 {
+				arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+			}
+
+			go func() {
+				_, err := store.TransferTxn(ctx, arg)
+				errs <- err
+			}()
+		}
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, <-errs)
+		}
+
+		for _, account := range []Account{account1, account2} {
+			updated, err := store.GetAccount(ctx, account.ID)
+			require.NoError(t, err)
+			require.Equal(t, account.Balance, updated.Balance)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		account1 := createAccount(t, createUser(t), 100)
+		account2 := createAccount(t, createUser(t), 100)
+
+		// the first transfer is undone along with the second one
+		_, err := store.BatchTransferTxn(ctx, BatchTransferTxnParams{
+			Transfers: []TransferTxnParams{
+				{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60},
+				{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60},
+			},
+			Atomic: true,
+		})
+		var itemErr *BatchItemError
+		require.True(t, errors.As(err, &itemErr))
+		require.Equal(t, 1, itemErr.Index)
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		for _, account := range []Account{account1, account2} {
+			updated, err := store.GetAccount(ctx, account.ID)
+			require.NoError(t, err)
+			require.Equal(t, account.Balance, updated.Balance)
+
+			entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 10})
+			require.NoError(t, err)
+			require.Empty(t, entries)
+		}
+
+		transfers, err := store.ListTransfers(ctx, ListTransfersParams{FromAccountID: account1.ID, ToAccountID: account1.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+	})
+
+	t.Run("Holds", func(t *testing.T) {
+		account1 := createAccount(t, createUser(t), 100)
+		account2 := createAccount(t, createUser(t), 0)
+
+		hold, err := store.CreateHoldTxn(ctx, CreateHoldParams{
+			AccountID:   account1.ID,
+			ToAccountID: account2.ID,
+			Amount:      80,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, HoldStatusActive, hold.Status)
+
+		_, err = store.CreateHoldTxn(ctx, CreateHoldParams{
+			AccountID:   account1.ID,
+			ToAccountID: account2.ID,
+			Amount:      30,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		held, err := store.GetHeldAmount(ctx, account1.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(80), held)
+
+		_, err = store.TransferTxn(ctx, TransferTxnParams{
+			FromAccountID:  account1.ID,
+			ToAccountID:    account2.ID,
+			Amount:         30,
+			CheckAvailable: true,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		result, err := store.CaptureHoldTxn(ctx, CaptureHoldTxnParams{HoldID: hold.ID, Amount: 50})
+		require.NoError(t, err)
+		require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+		require.Equal(t, int64(50), result.Hold.CapturedAmount)
+		require.Equal(t, int64(50), result.Transfer.ToAccount.Balance)
+
+		_, err = store.ReleaseHoldTxn(ctx, hold.ID)
+		require.ErrorIs(t, err, ErrHoldNotActive)
+
+		held, err = store.GetHeldAmount(ctx, account1.ID)
+		require.NoError(t, err)
+		require.Zero(t, held)
+
+		// a hold which isn't held any more can't be captured, even before ExpireHolds marks it
+		expiredHold, err := store.CreateHold(ctx, CreateHoldParams{
+			AccountID:   account1.ID,
+			ToAccountID: account2.ID,
+			Amount:      10,
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = store.CaptureHoldTxn(ctx, CaptureHoldTxnParams{HoldID: expiredHold.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrHoldNotActive)
+
+		_, err = store.ReleaseHoldTxn(ctx, expiredHold.ID+1000)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Beneficiaries", func(t *testing.T) {
+		user := createUser(t)
+		holder := createUser(t)
+		account1 := createAccount(t, holder, 0)
+		account2 := createAccount(t, holder, 0)
+
+		for _, arg := range []CreateBeneficiaryParams{
+			{UserID: user.ID, Label: "rent", AccountID: account1.ID},
+			{UserID: user.ID, Label: "gym", AccountID: account2.ID},
+		} {
+			_, err := store.CreateBeneficiary(ctx, arg)
+			require.NoError(t, err)
+		}
+
+		_, err := store.CreateBeneficiary(ctx, CreateBeneficiaryParams{UserID: user.ID, Label: "landlord", AccountID: account1.ID})
+		requirePqError(t, err, "unique_violation", "beneficiaries_user_id_account_id_idx")
+
+		beneficiaries, err := store.ListBeneficiaries(ctx, ListBeneficiariesParams{UserID: user.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, beneficiaries, 2)
+		require.Equal(t, "gym", beneficiaries[0].Label)
+		require.Equal(t, account2.AccountNumber, beneficiaries[0].AccountNumber)
+		require.Equal(t, holder.FullName, beneficiaries[0].HolderName)
+		require.Equal(t, "rent", beneficiaries[1].Label)
+	})
+
+	t.Run("Interest Accruals", func(t *testing.T) {
+		account := createAccount(t, createUser(t), 1000)
+		arg := CreateInterestAccrualParams{
+			AccountID:    account.ID,
+			AccrualDate:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Balance:      1000,
+			RateBps:      400,
+			AmountMicros: 109589,
+		}
+
+		_, err := store.CreateInterestAccrual(ctx, arg)
+		require.NoError(t, err)
+
+		// the accrual of a day is only recorded once
+		_, err = store.CreateInterestAccrual(ctx, arg)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		accruals, err := store.ListUnpostedInterestAccrualsForUpdate(ctx, ListUnpostedInterestAccrualsForUpdateParams{
+			AccountID: account.ID,
+			FromDate:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			ToDate:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		require.Len(t, accruals, 1)
+		require.True(t, arg.AccrualDate.Equal(accruals[0].AccrualDate))
+	})
+
+	t.Run("Outbox And Webhooks", func(t *testing.T) {
+		user := createUser(t)
+		account := createAccount(t, user, 0)
+
+		webhook, err := store.CreateWebhook(ctx, CreateWebhookParams{
+			UserID:     user.ID,
+			Url:        "https://example.com/hooks",
+			Secret:     utils.RandomString(32),
+			EventTypes: []string{"transfer.credited"},
+		})
+		require.NoError(t, err)
+
+		webhooks, err := store.ListWebhooksForEvent(ctx, ListWebhooksForEventParams{UserID: user.ID, EventType: "transfer.debited"})
+		require.NoError(t, err)
+		require.Empty(t, webhooks)
+
+		event, err := store.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+			EventType: "transfer.credited",
+			AccountID: account.ID,
+			UserID:    user.ID,
+			Payload:   json.RawMessage(`{"amount":10}`),
+		})
+		require.NoError(t, err)
+
+		arg := CreateWebhookDeliveryParams{WebhookID: webhook.ID, EventID: event.ID}
+		require.NoError(t, store.CreateWebhookDelivery(ctx, arg))
+		require.NoError(t, store.CreateWebhookDelivery(ctx, arg))
+
+		deliveries, err := store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, DeliveryStatusPending, deliveries[0].Status)
+		require.Equal(t, event.EventType, deliveries[0].EventType)
+
+		require.NoError(t, store.DeleteWebhook(ctx, webhook.ID))
+		_, err = store.GetWebhookDelivery(ctx, deliveries[0].ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Admin Audit", func(t *testing.T) {
+		account := createAccount(t, createUser(t), 0)
+
+		_, err := store.AdminSetAccountFrozenTxn(ctx, AdminSetAccountFrozenTxnParams{
+			AccountID: account.ID,
+			Frozen:    true,
+			Audit:     Audit{Actor: "ops", Reason: " "},
+		})
+		require.ErrorIs(t, err, ErrAuditReasonRequired)
+
+		for _, frozen := range []bool{true, false} {
+			_, err = store.AdminSetAccountFrozenTxn(ctx, AdminSetAccountFrozenTxnParams{
+				AccountID: account.ID,
+				Frozen:    frozen,
+				Audit:     Audit{Actor: "ops", Reason: "fraud review"},
+			})
+			require.NoError(t, err)
+		}
+
+		// the latest change first
+		logs, err := store.ListAdminAuditLogs(ctx, ListAdminAuditLogsParams{
+			AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		require.Equal(t, AuditActionAccountUnfreeze, logs[0].Action)
+		require.Equal(t, AuditActionAccountFreeze, logs[1].Action)
+	})
+}
+
+func TestSQLStoreConformance(t *testing.T) {
+	_, conn := setupTestDB(t)
+
+	testStoreConformance(t, NewStore(conn))
+}
+
+func TestMemoryStoreConformance(t *testing.T) {
+	testStoreConformance(t, NewMemoryStore())
+}
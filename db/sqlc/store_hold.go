@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// statuses of a hold, only an active hold which hasn't expired reserves funds
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+var (
+	// ErrInsufficientFunds : the available balance of the account doesn't cover the amount
+	ErrInsufficientFunds = errors.New("insufficient available balance")
+
+	// ErrHoldNotActive : the hold has already been captured, released or expired
+	ErrHoldNotActive = errors.New("hold is not active")
+
+	// ErrCaptureExceedsHold : a hold can't be captured for more than its amount
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the hold")
+)
+
+// CreateHoldTxn : reserves the funds on the account if its available balance covers the amount, the row
+// of the account is locked so that concurrent holds can't reserve the same funds twice
+func (s *txnStore) CreateHoldTxn(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	var hold Hold
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		held, err := q.GetHeldAmount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Balance-held < arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		hold, err = q.CreateHold(ctx, arg)
+		return err
+	})
+
+	return hold, err
+}
+
+// CaptureHoldTxnParams : contains the input parameters of the capture hold transaction
+type CaptureHoldTxnParams struct {
+	HoldID       int64 `json:"hold_id"`
+	Amount       int64 `json:"amount"`         // at most the amount of the hold, the rest is released
+	Fee          int64 `json:"fee"`            // charged out of the captured amount
+	FeeAccountID int64 `json:"fee_account_id"` // required if a fee is charged
+}
+
+// CaptureHoldTxnResult : contains the result of the capture hold transaction
+type CaptureHoldTxnResult struct {
+	Hold     Hold              `json:"hold"`     // the hold after it has been captured
+	Transfer TransferTxnResult `json:"transfer"` // the transfer of the captured amount
+}
+
+// CaptureHoldTxn : settles an active hold with a transfer of the captured amount to the account of the
+// hold, within the same transaction. The funds are released along with the capture, so a hold can be
+// captured only once
+func (s *txnStore) CaptureHoldTxn(ctx context.Context, arg CaptureHoldTxnParams) (CaptureHoldTxnResult, error) {
+	var result CaptureHoldTxnResult
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		hold, err := lockActiveHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		if arg.Amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		result.Transfer, err = transfer(ctx, q, TransferTxnParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        arg.Amount,
+			Fee:           arg.Fee,
+			FeeAccountID:  arg.FeeAccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:             hold.ID,
+			Status:         HoldStatusCaptured,
+			CapturedAmount: arg.Amount,
+			TransferID:     sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReleaseHoldTxn : releases the funds reserved by an active hold without moving any money
+func (s *txnStore) ReleaseHoldTxn(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		_, err := lockActiveHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:     holdID,
+			Status: HoldStatusReleased,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// lockActiveHold : locks the row of the hold, a hold which has expired but hasn't been marked
+// as expired by ExpireHolds yet is no longer active either. The expiry is compared with the clock
+// of the database, like GetHeldAmount does, so that a hold is active for as long as it is held
+func lockActiveHold(ctx context.Context, q Querier, holdID int64) (Hold, error) {
+	hold, err := q.GetActiveHoldForUpdate(ctx, holdID)
+	if err != sql.ErrNoRows {
+		return hold, err
+	}
+
+	// the hold is missing or no longer active
+	hold, err = q.GetHold(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	return hold, ErrHoldNotActive
+}
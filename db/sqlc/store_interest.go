@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostInterestTxnParams : contains the input parameters of the post interest transaction
+type PostInterestTxnParams struct {
+	AccountID        int64     `json:"account_id"`
+	ExpenseAccountID int64     `json:"expense_account_id"` // the interest expense account of the bank in the currency of the account
+	FromDate         time.Time `json:"from_date"`          // the first day of the period
+	ToDate           time.Time `json:"to_date"`            // the day after the period
+}
+
+// PostInterestTxnResult : contains the result of the post interest transaction
+type PostInterestTxnResult struct {
+	Amount   int64             `json:"amount"`   // in minor units, zero if nothing has been credited
+	Accruals int               `json:"accruals"` // number of the accruals which have been posted
+	Transfer TransferTxnResult `json:"transfer"` // empty if the amount rounded to zero
+}
+
+// PostInterestTxn : credits the unposted interest accruals of the period to the account through a transfer
+// from the interest expense account. The accruals are marked as posted within the same transaction,
+// so posting the same period again doesn't credit the interest twice
+func (s *txnStore) PostInterestTxn(ctx context.Context, arg PostInterestTxnParams) (PostInterestTxnResult, error) {
+	var result PostInterestTxnResult
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		accruals, err := q.ListUnpostedInterestAccrualsForUpdate(ctx, ListUnpostedInterestAccrualsForUpdateParams{
+			AccountID: arg.AccountID,
+			FromDate:  arg.FromDate,
+			ToDate:    arg.ToDate,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(accruals) == 0 {
+			return nil
+		}
+
+		var micros int64
+		for _, accrual := range accruals {
+			micros += accrual.AmountMicros
+		}
+
+		// the fractions of a minor unit are rounded half up once per period
+		result.Amount = (micros + 500000) / 1000000
+		result.Accruals = len(accruals)
+
+		var transferID sql.NullInt64
+		if result.Amount > 0 {
+			result.Transfer, err = transfer(ctx, q, TransferTxnParams{
+				FromAccountID: arg.ExpenseAccountID,
+				ToAccountID:   arg.AccountID,
+				Amount:        result.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			transferID = sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true}
+		}
+
+		_, err = q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			TransferID: transferID,
+			AccountID:  arg.AccountID,
+			FromDate:   arg.FromDate,
+			ToDate:     arg.ToDate,
+		})
+		return err
+	})
+
+	return result, err
+}
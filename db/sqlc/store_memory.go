@@ -0,0 +1,322 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MemoryStore : a Store keeping the data in memory, for the tests and the demos which don't need a
+// database. The queries behave like the ones of the SQLStore: the rows are ordered the same way,
+// sql.ErrNoRows is returned for a missing row and a *pq.Error with the code and the name of the
+// constraint for a violated constraint. The transactions are the ones of the SQLStore, a transaction
+// holds the lock of the store until it ends, so they are serializable, and its changes are undone if
+// it fails. Like the sequences of Postgres, the ids used by a failed transaction aren't reused
+type MemoryStore struct {
+	*memoryQueries
+	txnStore
+}
+
+// NewMemoryStore : creates a new MemoryStore with the system user created by the migrations
+func NewMemoryStore() Store {
+	store := &MemoryStore{memoryQueries: &memoryQueries{db: newMemoryDB()}}
+	store.txnStore = txnStore{execTxn: store.execTxn}
+	return store
+}
+
+// execTxn : executes a function within a transaction, the changes made by the function are undone if it fails
+func (s *MemoryStore) execTxn(ctx context.Context, fn func(Querier) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	// like now() of Postgres, the time doesn't change within a transaction
+	txn := &memoryTxn{now: time.Now()}
+	err = fn(&memoryQueries{db: s.db, txn: txn})
+	if err != nil {
+		txn.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// memoryDB : the tables of the MemoryStore
+type memoryDB struct {
+	mu sync.Mutex
+
+	users               *memoryTable[User]
+	accounts            *memoryTable[Account]
+	entries             *memoryTable[Entry]
+	transfers           *memoryTable[Transfer]
+	recoveryCodes       *memoryTable[RecoveryCode]
+	passwordResetTokens *memoryTable[PasswordResetToken]
+	beneficiaries       *memoryTable[Beneficiary]
+	systemAccounts      *memoryTable[SystemAccount] // by the id of the account, which is unique
+	interestAccruals    *memoryTable[InterestAccrual]
+	jobRuns             *memoryTable[JobRun]
+	holds               *memoryTable[Hold]
+	outboxEvents        *memoryTable[OutboxEvent]
+	webhooks            *memoryTable[Webhook]
+	webhookDeliveries   *memoryTable[WebhookDelivery]
+	adminAuditLogs      *memoryTable[AdminAuditLog]
+}
+
+func newMemoryDB() *memoryDB {
+	db := &memoryDB{
+		users:               newMemoryTable[User](),
+		accounts:            newMemoryTable[Account](),
+		entries:             newMemoryTable[Entry](),
+		transfers:           newMemoryTable[Transfer](),
+		recoveryCodes:       newMemoryTable[RecoveryCode](),
+		passwordResetTokens: newMemoryTable[PasswordResetToken](),
+		beneficiaries:       newMemoryTable[Beneficiary](),
+		systemAccounts:      newMemoryTable[SystemAccount](),
+		interestAccruals:    newMemoryTable[InterestAccrual](),
+		jobRuns:             newMemoryTable[JobRun](),
+		holds:               newMemoryTable[Hold](),
+		outboxEvents:        newMemoryTable[OutboxEvent](),
+		webhooks:            newMemoryTable[Webhook](),
+		webhookDeliveries:   newMemoryTable[WebhookDelivery](),
+		adminAuditLogs:      newMemoryTable[AdminAuditLog](),
+	}
+
+	// inserted by the migration of the interest
+	id := db.users.nextID()
+	db.users.put(nil, id, User{
+		ID:              id,
+		CreatedAt:       time.Now(),
+		Username:        SystemUsername,
+		Password:        "!",
+		FullName:        "Banking System",
+		Email:           "system@banking-system.internal",
+		IsEmailVerified: true,
+	})
+
+	return db
+}
+
+// memoryTable : the rows of a table by id
+type memoryTable[T any] struct {
+	rows   map[int64]T
+	lastID int64
+}
+
+func newMemoryTable[T any]() *memoryTable[T] {
+	return &memoryTable[T]{rows: make(map[int64]T)}
+}
+
+// nextID : returns the next id of the sequence of the table, it isn't given back on rollback
+func (table *memoryTable[T]) nextID() int64 {
+	table.lastID++
+	return table.lastID
+}
+
+func (table *memoryTable[T]) get(id int64) (T, bool) {
+	row, ok := table.rows[id]
+	return row, ok
+}
+
+// put : inserts or replaces the row, the previous one is restored if the transaction is rolled back
+func (table *memoryTable[T]) put(txn *memoryTxn, id int64, row T) {
+	previous, existed := table.rows[id]
+	table.rows[id] = row
+
+	txn.onRollback(func() {
+		if existed {
+			table.rows[id] = previous
+			return
+		}
+		delete(table.rows, id)
+	})
+}
+
+// update : changes the row with the function, false if there is no row with the id
+func (table *memoryTable[T]) update(txn *memoryTxn, id int64, fn func(row *T)) (T, bool) {
+	row, ok := table.rows[id]
+	if !ok {
+		return row, false
+	}
+
+	fn(&row)
+	table.put(txn, id, row)
+	return row, true
+}
+
+// delete : deletes the row, it is restored if the transaction is rolled back
+func (table *memoryTable[T]) delete(txn *memoryTxn, id int64) {
+	previous, existed := table.rows[id]
+	if !existed {
+		return
+	}
+	delete(table.rows, id)
+
+	txn.onRollback(func() {
+		table.rows[id] = previous
+	})
+}
+
+// ids : returns the ids of the rows matching the filter in ascending order
+func (table *memoryTable[T]) ids(match func(row T) bool) []int64 {
+	ids := make([]int64, 0)
+	for id, row := range table.rows {
+		if match(row) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// list : returns the rows matching the filter ordered by id
+func (table *memoryTable[T]) list(match func(row T) bool) []T {
+	ids := table.ids(match)
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		rows[i] = table.rows[id]
+	}
+	return rows
+}
+
+// find : returns the first row matching the filter by id
+func (table *memoryTable[T]) find(match func(row T) bool) (T, bool) {
+	var found T
+	foundID := int64(0)
+	for id, row := range table.rows {
+		if match(row) && (foundID == 0 || id < foundID) {
+			found, foundID = row, id
+		}
+	}
+	return found, foundID != 0
+}
+
+// exists : whether there is a row with the id, for checking the foreign keys
+func (table *memoryTable[T]) exists(id int64) bool {
+	_, ok := table.rows[id]
+	return ok
+}
+
+// page : applies the LIMIT and the OFFSET of a query to the ordered rows
+func page[T any](rows []T, limit int32, offset int32) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if int(offset) >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+
+	if limit >= 0 && int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// memoryTxn : the changes of a transaction are undone in the reverse order on rollback
+type memoryTxn struct {
+	now  time.Time
+	undo []func()
+}
+
+// onRollback : records how to undo a change, the changes made outside of a transaction can't be undone
+func (txn *memoryTxn) onRollback(fn func()) {
+	if txn == nil {
+		return
+	}
+	txn.undo = append(txn.undo, fn)
+}
+
+func (txn *memoryTxn) rollback() {
+	for i := len(txn.undo) - 1; i >= 0; i-- {
+		txn.undo[i]()
+	}
+	txn.undo = nil
+}
+
+// memoryQueries : implements the Querier on the tables, within a transaction if txn isn't nil
+type memoryQueries struct {
+	db  *memoryDB
+	txn *memoryTxn
+}
+
+var _ Querier = (*memoryQueries)(nil)
+
+// lock : locks the tables for a query run on its own and returns the unlock function, the queries of
+// a transaction run under the lock of the transaction
+func (q *memoryQueries) lock() func() {
+	if q.txn != nil {
+		return func() {}
+	}
+
+	q.db.mu.Lock()
+	return q.db.mu.Unlock
+}
+
+// now : the time of the transaction, or the current time for a query run on its own
+func (q *memoryQueries) now() time.Time {
+	if q.txn != nil {
+		return q.txn.now
+	}
+	return time.Now()
+}
+
+// the errors of lib/pq for the violated constraints, so that the callers handle them the same way
+
+func uniqueViolation(table string, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// referencedViolation : a referenced row can't be deleted
+func referencedViolation(table string, constraint string, referencingTable string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23503",
+		Message:    fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q", table, constraint, referencingTable),
+		Table:      referencingTable,
+		Constraint: constraint,
+	}
+}
+
+func checkViolation(table string, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23514",
+		Message:    fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func notNullViolation(table string, column string) error {
+	return &pq.Error{
+		Severity: "ERROR",
+		Code:     "23502",
+		Message:  fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", column, table),
+		Table:    table,
+		Column:   column,
+	}
+}
@@ -0,0 +1,1273 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the queries of the MemoryStore, in the order of the files of db/query
+
+// accounts
+
+// accountTypes : allowed by the accounts_type_check constraint
+var accountTypes = []string{"checking", "savings", "wallet"}
+
+func (q *memoryQueries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	defer q.lock()()
+
+	if !q.db.users.exists(arg.UserID) {
+		return Account{}, foreignKeyViolation("accounts", "accounts_user_id_fkey")
+	}
+	if !containsString(accountTypes, arg.Type) {
+		return Account{}, checkViolation("accounts", "accounts_type_check")
+	}
+	if _, ok := q.db.accounts.find(func(a Account) bool { return a.AccountNumber == arg.AccountNumber }); ok {
+		return Account{}, uniqueViolation("accounts", "accounts_account_number_idx")
+	}
+
+	account := Account{
+		ID:            q.db.accounts.nextID(),
+		CreatedAt:     q.now(),
+		UserID:        arg.UserID,
+		Balance:       arg.Balance,
+		Currency:      arg.Currency,
+		Type:          arg.Type,
+		Nickname:      arg.Nickname,
+		AccountNumber: arg.AccountNumber,
+	}
+	q.db.accounts.put(q.txn, account.ID, account)
+	return account, nil
+}
+
+func (q *memoryQueries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	defer q.lock()()
+
+	account, ok := q.db.accounts.get(id)
+	if !ok {
+		return account, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (q *memoryQueries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	defer q.lock()()
+
+	account, ok := q.db.accounts.find(func(a Account) bool { return a.AccountNumber == accountNumber })
+	if !ok {
+		return account, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+// GetAccountForUpdate : the transactions are serializable, so the row doesn't have to be locked
+func (q *memoryQueries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *memoryQueries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	defer q.lock()()
+
+	accounts := q.db.accounts.list(func(a Account) bool {
+		return a.UserID == arg.UserID &&
+			(!arg.Currency.Valid || a.Currency == arg.Currency.String) &&
+			(!arg.Type.Valid || a.Type == arg.Type.String)
+	})
+	return page(accounts, arg.Limit, arg.Offset), nil
+}
+
+func (q *memoryQueries) CountAccountsByCurrency(ctx context.Context, arg CountAccountsByCurrencyParams) (int64, error) {
+	defer q.lock()()
+
+	accounts := q.db.accounts.ids(func(a Account) bool {
+		return a.UserID == arg.UserID && a.Currency == arg.Currency
+	})
+	return int64(len(accounts)), nil
+}
+
+func (q *memoryQueries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	defer q.lock()()
+
+	return q.updateAccount(arg.ID, func(a *Account) { a.Balance = arg.Balance })
+}
+
+func (q *memoryQueries) UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error) {
+	defer q.lock()()
+
+	return q.updateAccount(arg.ID, func(a *Account) { a.Nickname = arg.Nickname })
+}
+
+func (q *memoryQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	defer q.lock()()
+
+	return q.updateAccount(arg.ID, func(a *Account) { a.Balance += arg.Amount })
+}
+
+func (q *memoryQueries) SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error) {
+	defer q.lock()()
+
+	return q.updateAccount(arg.ID, func(a *Account) { a.IsFrozen = arg.IsFrozen })
+}
+
+// updateAccount : updates the account and returns it, sql.ErrNoRows if it doesn't exist
+func (q *memoryQueries) updateAccount(id int64, fn func(a *Account)) (Account, error) {
+	account, ok := q.db.accounts.update(q.txn, id, fn)
+	if !ok {
+		return account, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (q *memoryQueries) DeleteAccount(ctx context.Context, id int64) error {
+	defer q.lock()()
+
+	references := []struct {
+		table      string
+		constraint string
+		referenced bool
+	}{
+		{"beneficiaries", "beneficiaries_account_id_fkey", q.referencedBy(q.db.beneficiaries.ids(func(b Beneficiary) bool { return b.AccountID == id }))},
+		{"entries", "entries_account_id_fkey", q.referencedBy(q.db.entries.ids(func(e Entry) bool { return e.AccountID == id }))},
+		{"transfers", "transfers_from_account_id_fkey", q.referencedBy(q.db.transfers.ids(func(t Transfer) bool { return t.FromAccountID == id }))},
+		{"transfers", "transfers_to_account_id_fkey", q.referencedBy(q.db.transfers.ids(func(t Transfer) bool { return t.ToAccountID == id }))},
+		{"transfers", "transfers_fee_account_id_fkey", q.referencedBy(q.db.transfers.ids(func(t Transfer) bool { return t.FeeAccountID.Valid && t.FeeAccountID.Int64 == id }))},
+		{"system_accounts", "system_accounts_account_id_fkey", q.db.systemAccounts.exists(id)},
+		{"interest_accruals", "interest_accruals_account_id_fkey", q.referencedBy(q.db.interestAccruals.ids(func(i InterestAccrual) bool { return i.AccountID == id }))},
+		{"holds", "holds_account_id_fkey", q.referencedBy(q.db.holds.ids(func(h Hold) bool { return h.AccountID == id }))},
+		{"holds", "holds_to_account_id_fkey", q.referencedBy(q.db.holds.ids(func(h Hold) bool { return h.ToAccountID == id }))},
+		{"outbox_events", "outbox_events_account_id_fkey", q.referencedBy(q.db.outboxEvents.ids(func(e OutboxEvent) bool { return e.AccountID == id }))},
+		{"admin_audit_logs", "admin_audit_logs_account_id_fkey", q.referencedBy(q.db.adminAuditLogs.ids(func(l AdminAuditLog) bool { return l.AccountID.Valid && l.AccountID.Int64 == id }))},
+	}
+
+	for _, reference := range references {
+		if reference.referenced {
+			return referencedViolation("accounts", reference.constraint, reference.table)
+		}
+	}
+
+	q.db.accounts.delete(q.txn, id)
+	return nil
+}
+
+func (q *memoryQueries) referencedBy(ids []int64) bool {
+	return len(ids) > 0
+}
+
+func (q *memoryQueries) ListEndOfDayBalances(ctx context.Context, arg ListEndOfDayBalancesParams) ([]ListEndOfDayBalancesRow, error) {
+	defer q.lock()()
+
+	accounts := q.db.accounts.list(func(a Account) bool {
+		return containsString(arg.Types, a.Type) &&
+			a.CreatedAt.Before(arg.DayEnd) &&
+			a.ID > arg.AfterID &&
+			!q.db.systemAccounts.exists(a.ID)
+	})
+
+	rows := make([]ListEndOfDayBalancesRow, 0)
+	for _, account := range page(accounts, arg.Limit, 0) {
+		balance := account.Balance
+		for _, entry := range q.db.entries.list(func(e Entry) bool {
+			return e.AccountID == account.ID && !e.CreatedAt.Before(arg.DayEnd)
+		}) {
+			balance -= entry.Amount
+		}
+
+		rows = append(rows, ListEndOfDayBalancesRow{
+			ID:       account.ID,
+			Type:     account.Type,
+			Currency: account.Currency,
+			Balance:  balance,
+		})
+	}
+	return rows, nil
+}
+
+// admin audit logs
+
+func (q *memoryQueries) CreateAdminAuditLog(ctx context.Context, arg CreateAdminAuditLogParams) (AdminAuditLog, error) {
+	defer q.lock()()
+
+	if arg.UserID.Valid && !q.db.users.exists(arg.UserID.Int64) {
+		return AdminAuditLog{}, foreignKeyViolation("admin_audit_logs", "admin_audit_logs_user_id_fkey")
+	}
+	if arg.AccountID.Valid && !q.db.accounts.exists(arg.AccountID.Int64) {
+		return AdminAuditLog{}, foreignKeyViolation("admin_audit_logs", "admin_audit_logs_account_id_fkey")
+	}
+	if strings.TrimSpace(arg.Reason) == "" {
+		return AdminAuditLog{}, checkViolation("admin_audit_logs", "admin_audit_logs_reason_check")
+	}
+	if arg.Details == nil {
+		return AdminAuditLog{}, notNullViolation("admin_audit_logs", "details")
+	}
+
+	log := AdminAuditLog{
+		ID:        q.db.adminAuditLogs.nextID(),
+		CreatedAt: q.now(),
+		Actor:     arg.Actor,
+		Action:    arg.Action,
+		UserID:    arg.UserID,
+		AccountID: arg.AccountID,
+		Reason:    arg.Reason,
+		Details:   cloneJSON(arg.Details),
+	}
+	q.db.adminAuditLogs.put(q.txn, log.ID, log)
+	return log, nil
+}
+
+func (q *memoryQueries) ListAdminAuditLogs(ctx context.Context, arg ListAdminAuditLogsParams) ([]AdminAuditLog, error) {
+	defer q.lock()()
+
+	logs := q.db.adminAuditLogs.list(func(l AdminAuditLog) bool {
+		return (!arg.UserID.Valid || (l.UserID.Valid && l.UserID.Int64 == arg.UserID.Int64)) &&
+			(!arg.AccountID.Valid || (l.AccountID.Valid && l.AccountID.Int64 == arg.AccountID.Int64))
+	})
+	reverse(logs)
+	return page(logs, arg.Limit, arg.Offset), nil
+}
+
+// beneficiaries
+
+func (q *memoryQueries) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	defer q.lock()()
+
+	if !q.db.users.exists(arg.UserID) {
+		return Beneficiary{}, foreignKeyViolation("beneficiaries", "beneficiaries_user_id_fkey")
+	}
+	if !q.db.accounts.exists(arg.AccountID) {
+		return Beneficiary{}, foreignKeyViolation("beneficiaries", "beneficiaries_account_id_fkey")
+	}
+	err := q.checkBeneficiaryUnique(0, arg.UserID, arg.Label, arg.AccountID)
+	if err != nil {
+		return Beneficiary{}, err
+	}
+
+	beneficiary := Beneficiary{
+		ID:        q.db.beneficiaries.nextID(),
+		CreatedAt: q.now(),
+		UserID:    arg.UserID,
+		Label:     arg.Label,
+		AccountID: arg.AccountID,
+	}
+	q.db.beneficiaries.put(q.txn, beneficiary.ID, beneficiary)
+	return beneficiary, nil
+}
+
+// checkBeneficiaryUnique : the label and the account are unique within the address book of the user
+func (q *memoryQueries) checkBeneficiaryUnique(id int64, userID int64, label string, accountID int64) error {
+	for _, other := range q.db.beneficiaries.list(func(b Beneficiary) bool { return b.ID != id && b.UserID == userID }) {
+		if other.Label == label {
+			return uniqueViolation("beneficiaries", "beneficiaries_user_id_label_idx")
+		}
+		if other.AccountID == accountID {
+			return uniqueViolation("beneficiaries", "beneficiaries_user_id_account_id_idx")
+		}
+	}
+	return nil
+}
+
+func (q *memoryQueries) GetBeneficiary(ctx context.Context, id int64) (GetBeneficiaryRow, error) {
+	defer q.lock()()
+
+	beneficiary, ok := q.db.beneficiaries.get(id)
+	if !ok {
+		return GetBeneficiaryRow{}, sql.ErrNoRows
+	}
+	return GetBeneficiaryRow(q.beneficiaryRow(beneficiary)), nil
+}
+
+func (q *memoryQueries) ListBeneficiaries(ctx context.Context, arg ListBeneficiariesParams) ([]ListBeneficiariesRow, error) {
+	defer q.lock()()
+
+	beneficiaries := q.db.beneficiaries.list(func(b Beneficiary) bool { return b.UserID == arg.UserID })
+	sort.SliceStable(beneficiaries, func(i, j int) bool { return beneficiaries[i].Label < beneficiaries[j].Label })
+
+	rows := make([]ListBeneficiariesRow, 0)
+	for _, beneficiary := range page(beneficiaries, arg.Limit, arg.Offset) {
+		rows = append(rows, q.beneficiaryRow(beneficiary))
+	}
+	return rows, nil
+}
+
+// beneficiaryRow : joins the account and the holder of the account of the beneficiary
+func (q *memoryQueries) beneficiaryRow(beneficiary Beneficiary) ListBeneficiariesRow {
+	account, _ := q.db.accounts.get(beneficiary.AccountID)
+	holder, _ := q.db.users.get(account.UserID)
+
+	return ListBeneficiariesRow{
+		ID:            beneficiary.ID,
+		CreatedAt:     beneficiary.CreatedAt,
+		UserID:        beneficiary.UserID,
+		Label:         beneficiary.Label,
+		AccountID:     beneficiary.AccountID,
+		AccountNumber: account.AccountNumber,
+		Currency:      account.Currency,
+		HolderName:    holder.FullName,
+	}
+}
+
+func (q *memoryQueries) UpdateBeneficiaryLabel(ctx context.Context, arg UpdateBeneficiaryLabelParams) (Beneficiary, error) {
+	defer q.lock()()
+
+	beneficiary, ok := q.db.beneficiaries.get(arg.ID)
+	if !ok {
+		return beneficiary, sql.ErrNoRows
+	}
+
+	err := q.checkBeneficiaryUnique(beneficiary.ID, beneficiary.UserID, arg.Label, 0)
+	if err != nil {
+		return Beneficiary{}, err
+	}
+
+	beneficiary.Label = arg.Label
+	q.db.beneficiaries.put(q.txn, beneficiary.ID, beneficiary)
+	return beneficiary, nil
+}
+
+func (q *memoryQueries) DeleteBeneficiary(ctx context.Context, id int64) error {
+	defer q.lock()()
+
+	q.db.beneficiaries.delete(q.txn, id)
+	return nil
+}
+
+// entries
+
+func (q *memoryQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.AccountID) {
+		return Entry{}, foreignKeyViolation("entries", "entries_account_id_fkey")
+	}
+
+	entry := Entry{
+		ID:        q.db.entries.nextID(),
+		CreatedAt: q.now(),
+		AccountID: arg.AccountID,
+		Amount:    arg.Amount,
+	}
+	q.db.entries.put(q.txn, entry.ID, entry)
+	return entry, nil
+}
+
+func (q *memoryQueries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	defer q.lock()()
+
+	entry, ok := q.db.entries.get(id)
+	if !ok {
+		return entry, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (q *memoryQueries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	defer q.lock()()
+
+	entries := q.db.entries.list(func(e Entry) bool { return e.AccountID == arg.AccountID })
+	return page(entries, arg.Limit, arg.Offset), nil
+}
+
+// holds
+
+// holdStatuses : allowed by the holds_status_check constraint
+var holdStatuses = []string{HoldStatusActive, HoldStatusCaptured, HoldStatusReleased, HoldStatusExpired}
+
+func (q *memoryQueries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.AccountID) {
+		return Hold{}, foreignKeyViolation("holds", "holds_account_id_fkey")
+	}
+	if !q.db.accounts.exists(arg.ToAccountID) {
+		return Hold{}, foreignKeyViolation("holds", "holds_to_account_id_fkey")
+	}
+	if arg.Amount <= 0 {
+		return Hold{}, checkViolation("holds", "holds_amount_check")
+	}
+
+	now := q.now()
+	hold := Hold{
+		ID:          q.db.holds.nextID(),
+		CreatedAt:   now,
+		AccountID:   arg.AccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount:      arg.Amount,
+		Status:      HoldStatusActive,
+		ExpiresAt:   arg.ExpiresAt,
+		UpdatedAt:   now,
+	}
+	q.db.holds.put(q.txn, hold.ID, hold)
+	return hold, nil
+}
+
+func (q *memoryQueries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	defer q.lock()()
+
+	hold, ok := q.db.holds.get(id)
+	if !ok {
+		return hold, sql.ErrNoRows
+	}
+	return hold, nil
+}
+
+// GetActiveHoldForUpdate : the transactions are serializable, so the row doesn't have to be locked
+func (q *memoryQueries) GetActiveHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	defer q.lock()()
+
+	hold, ok := q.db.holds.get(id)
+	if !ok || hold.Status != HoldStatusActive || !hold.ExpiresAt.After(q.now()) {
+		return Hold{}, sql.ErrNoRows
+	}
+	return hold, nil
+}
+
+func (q *memoryQueries) GetHeldAmount(ctx context.Context, accountID int64) (int64, error) {
+	defer q.lock()()
+
+	now := q.now()
+	var held int64
+	for _, hold := range q.db.holds.list(func(h Hold) bool {
+		return h.AccountID == accountID && h.Status == HoldStatusActive && h.ExpiresAt.After(now)
+	}) {
+		held += hold.Amount
+	}
+	return held, nil
+}
+
+func (q *memoryQueries) UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error) {
+	defer q.lock()()
+
+	hold, ok := q.db.holds.get(arg.ID)
+	if !ok {
+		return hold, sql.ErrNoRows
+	}
+	if !containsString(holdStatuses, arg.Status) {
+		return Hold{}, checkViolation("holds", "holds_status_check")
+	}
+	if arg.CapturedAmount < 0 || arg.CapturedAmount > hold.Amount {
+		return Hold{}, checkViolation("holds", "holds_amount_check")
+	}
+	if arg.TransferID.Valid && !q.db.transfers.exists(arg.TransferID.Int64) {
+		return Hold{}, foreignKeyViolation("holds", "holds_transfer_id_fkey")
+	}
+
+	hold.Status = arg.Status
+	hold.CapturedAmount = arg.CapturedAmount
+	hold.TransferID = arg.TransferID
+	hold.UpdatedAt = q.now()
+	q.db.holds.put(q.txn, hold.ID, hold)
+	return hold, nil
+}
+
+func (q *memoryQueries) ExpireHolds(ctx context.Context) (int64, error) {
+	defer q.lock()()
+
+	now := q.now()
+	ids := q.db.holds.ids(func(h Hold) bool {
+		return h.Status == HoldStatusActive && !h.ExpiresAt.After(now)
+	})
+	for _, id := range ids {
+		q.db.holds.update(q.txn, id, func(h *Hold) {
+			h.Status = HoldStatusExpired
+			h.UpdatedAt = now
+		})
+	}
+	return int64(len(ids)), nil
+}
+
+// interest accruals
+
+func (q *memoryQueries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.AccountID) {
+		return InterestAccrual{}, foreignKeyViolation("interest_accruals", "interest_accruals_account_id_fkey")
+	}
+
+	// ON CONFLICT DO NOTHING returns no row
+	accrualDate := toDate(arg.AccrualDate)
+	_, exists := q.db.interestAccruals.find(func(i InterestAccrual) bool {
+		return i.AccountID == arg.AccountID && i.AccrualDate.Equal(accrualDate)
+	})
+	if exists {
+		return InterestAccrual{}, sql.ErrNoRows
+	}
+
+	accrual := InterestAccrual{
+		ID:           q.db.interestAccruals.nextID(),
+		CreatedAt:    q.now(),
+		AccountID:    arg.AccountID,
+		AccrualDate:  accrualDate,
+		Balance:      arg.Balance,
+		RateBps:      arg.RateBps,
+		AmountMicros: arg.AmountMicros,
+	}
+	q.db.interestAccruals.put(q.txn, accrual.ID, accrual)
+	return accrual, nil
+}
+
+// unpostedAccrual : whether the accrual of the account is unposted and within the dates
+func unpostedAccrual(accrual InterestAccrual, fromDate time.Time, toDate time.Time) bool {
+	return !accrual.AccrualDate.Before(fromDate) && accrual.AccrualDate.Before(toDate) && !accrual.PostedAt.Valid
+}
+
+func (q *memoryQueries) ListUnpostedInterestAccrualsForUpdate(ctx context.Context, arg ListUnpostedInterestAccrualsForUpdateParams) ([]InterestAccrual, error) {
+	defer q.lock()()
+
+	fromDate, toDate := toDate(arg.FromDate), toDate(arg.ToDate)
+	accruals := q.db.interestAccruals.list(func(i InterestAccrual) bool {
+		return i.AccountID == arg.AccountID && unpostedAccrual(i, fromDate, toDate)
+	})
+	sort.SliceStable(accruals, func(i, j int) bool { return accruals[i].AccrualDate.Before(accruals[j].AccrualDate) })
+	return accruals, nil
+}
+
+func (q *memoryQueries) ListAccountIDsWithUnpostedInterest(ctx context.Context, arg ListAccountIDsWithUnpostedInterestParams) ([]int64, error) {
+	defer q.lock()()
+
+	fromDate, toDate := toDate(arg.FromDate), toDate(arg.ToDate)
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0)
+	for _, accrual := range q.db.interestAccruals.list(func(i InterestAccrual) bool {
+		return i.AccountID > arg.AfterID && unpostedAccrual(i, fromDate, toDate)
+	}) {
+		if !seen[accrual.AccountID] {
+			seen[accrual.AccountID] = true
+			ids = append(ids, accrual.AccountID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return page(ids, arg.Limit, 0), nil
+}
+
+func (q *memoryQueries) MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error) {
+	defer q.lock()()
+
+	fromDate, toDate := toDate(arg.FromDate), toDate(arg.ToDate)
+	ids := q.db.interestAccruals.ids(func(i InterestAccrual) bool {
+		return i.AccountID == arg.AccountID && unpostedAccrual(i, fromDate, toDate)
+	})
+	if len(ids) > 0 && arg.TransferID.Valid && !q.db.transfers.exists(arg.TransferID.Int64) {
+		return 0, foreignKeyViolation("interest_accruals", "interest_accruals_transfer_id_fkey")
+	}
+
+	now := q.now()
+	for _, id := range ids {
+		q.db.interestAccruals.update(q.txn, id, func(i *InterestAccrual) {
+			i.PostedAt = sql.NullTime{Time: now, Valid: true}
+			i.TransferID = arg.TransferID
+		})
+	}
+	return int64(len(ids)), nil
+}
+
+// job runs
+
+func (q *memoryQueries) StartJobRun(ctx context.Context, arg StartJobRunParams) (JobRun, error) {
+	defer q.lock()()
+
+	// ON CONFLICT DO UPDATE returns the existing row
+	run, exists := q.db.jobRuns.find(func(r JobRun) bool { return r.Job == arg.Job && r.Period == arg.Period })
+	if exists {
+		return run, nil
+	}
+
+	run = JobRun{
+		ID:        q.db.jobRuns.nextID(),
+		CreatedAt: q.now(),
+		Job:       arg.Job,
+		Period:    arg.Period,
+	}
+	q.db.jobRuns.put(q.txn, run.ID, run)
+	return run, nil
+}
+
+func (q *memoryQueries) CompleteJobRun(ctx context.Context, id int64) (JobRun, error) {
+	defer q.lock()()
+
+	now := q.now()
+	run, ok := q.db.jobRuns.update(q.txn, id, func(r *JobRun) {
+		r.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	})
+	if !ok {
+		return run, sql.ErrNoRows
+	}
+	return run, nil
+}
+
+// outbox events
+
+func (q *memoryQueries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.AccountID) {
+		return OutboxEvent{}, foreignKeyViolation("outbox_events", "outbox_events_account_id_fkey")
+	}
+	if !q.db.users.exists(arg.UserID) {
+		return OutboxEvent{}, foreignKeyViolation("outbox_events", "outbox_events_user_id_fkey")
+	}
+	if arg.Payload == nil {
+		return OutboxEvent{}, notNullViolation("outbox_events", "payload")
+	}
+
+	event := OutboxEvent{
+		ID:        q.db.outboxEvents.nextID(),
+		CreatedAt: q.now(),
+		EventType: arg.EventType,
+		AccountID: arg.AccountID,
+		UserID:    arg.UserID,
+		Payload:   cloneJSON(arg.Payload),
+	}
+	q.db.outboxEvents.put(q.txn, event.ID, event)
+	return event, nil
+}
+
+func (q *memoryQueries) ListUndispatchedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	defer q.lock()()
+
+	events := q.db.outboxEvents.list(func(e OutboxEvent) bool { return !e.DispatchedAt.Valid })
+	return page(events, limit, 0), nil
+}
+
+func (q *memoryQueries) MarkOutboxEventDispatched(ctx context.Context, id int64) error {
+	defer q.lock()()
+
+	now := q.now()
+	q.db.outboxEvents.update(q.txn, id, func(e *OutboxEvent) {
+		e.DispatchedAt = sql.NullTime{Time: now, Valid: true}
+	})
+	return nil
+}
+
+// password reset tokens
+
+func (q *memoryQueries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	defer q.lock()()
+
+	if !q.db.users.exists(arg.UserID) {
+		return PasswordResetToken{}, foreignKeyViolation("password_reset_tokens", "password_reset_tokens_user_id_fkey")
+	}
+	if _, ok := q.db.passwordResetTokens.find(func(t PasswordResetToken) bool { return t.TokenHash == arg.TokenHash }); ok {
+		return PasswordResetToken{}, uniqueViolation("password_reset_tokens", "password_reset_tokens_token_hash_key")
+	}
+
+	resetToken := PasswordResetToken{
+		ID:        q.db.passwordResetTokens.nextID(),
+		CreatedAt: q.now(),
+		UserID:    arg.UserID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	q.db.passwordResetTokens.put(q.txn, resetToken.ID, resetToken)
+	return resetToken, nil
+}
+
+func (q *memoryQueries) DeletePasswordResetTokens(ctx context.Context, userID int64) error {
+	defer q.lock()()
+
+	for _, id := range q.db.passwordResetTokens.ids(func(t PasswordResetToken) bool { return t.UserID == userID }) {
+		q.db.passwordResetTokens.delete(q.txn, id)
+	}
+	return nil
+}
+
+func (q *memoryQueries) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	defer q.lock()()
+
+	now := q.now()
+	resetToken, ok := q.db.passwordResetTokens.find(func(t PasswordResetToken) bool {
+		return t.TokenHash == tokenHash && !t.UsedAt.Valid && t.ExpiresAt.After(now)
+	})
+	if !ok {
+		return resetToken, sql.ErrNoRows
+	}
+
+	resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+	q.db.passwordResetTokens.put(q.txn, resetToken.ID, resetToken)
+	return resetToken, nil
+}
+
+// recovery codes
+
+func (q *memoryQueries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	defer q.lock()()
+
+	if !q.db.users.exists(arg.UserID) {
+		return RecoveryCode{}, foreignKeyViolation("recovery_codes", "recovery_codes_user_id_fkey")
+	}
+	if _, ok := q.db.recoveryCodes.find(func(c RecoveryCode) bool { return c.UserID == arg.UserID && c.CodeHash == arg.CodeHash }); ok {
+		return RecoveryCode{}, uniqueViolation("recovery_codes", "recovery_codes_user_id_code_hash_idx")
+	}
+
+	code := RecoveryCode{
+		ID:        q.db.recoveryCodes.nextID(),
+		CreatedAt: q.now(),
+		UserID:    arg.UserID,
+		CodeHash:  arg.CodeHash,
+	}
+	q.db.recoveryCodes.put(q.txn, code.ID, code)
+	return code, nil
+}
+
+func (q *memoryQueries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	defer q.lock()()
+
+	for _, id := range q.db.recoveryCodes.ids(func(c RecoveryCode) bool { return c.UserID == userID }) {
+		q.db.recoveryCodes.delete(q.txn, id)
+	}
+	return nil
+}
+
+func (q *memoryQueries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	defer q.lock()()
+
+	now := q.now()
+	ids := q.db.recoveryCodes.ids(func(c RecoveryCode) bool {
+		return c.UserID == arg.UserID && c.CodeHash == arg.CodeHash && !c.UsedAt.Valid
+	})
+	for _, id := range ids {
+		q.db.recoveryCodes.update(q.txn, id, func(c *RecoveryCode) {
+			c.UsedAt = sql.NullTime{Time: now, Valid: true}
+		})
+	}
+	return int64(len(ids)), nil
+}
+
+// system accounts
+
+func (q *memoryQueries) CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) (SystemAccount, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.AccountID) {
+		return SystemAccount{}, foreignKeyViolation("system_accounts", "system_accounts_account_id_fkey")
+	}
+	if _, ok := q.db.systemAccounts.find(func(s SystemAccount) bool { return s.Purpose == arg.Purpose && s.Currency == arg.Currency }); ok {
+		return SystemAccount{}, uniqueViolation("system_accounts", "system_accounts_pkey")
+	}
+	if q.db.systemAccounts.exists(arg.AccountID) {
+		return SystemAccount{}, uniqueViolation("system_accounts", "system_accounts_account_id_key")
+	}
+
+	systemAccount := SystemAccount{
+		Purpose:   arg.Purpose,
+		Currency:  arg.Currency,
+		AccountID: arg.AccountID,
+		CreatedAt: q.now(),
+	}
+	q.db.systemAccounts.put(q.txn, systemAccount.AccountID, systemAccount)
+	return systemAccount, nil
+}
+
+func (q *memoryQueries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (SystemAccount, error) {
+	defer q.lock()()
+
+	systemAccount, ok := q.db.systemAccounts.find(func(s SystemAccount) bool {
+		return s.Purpose == arg.Purpose && s.Currency == arg.Currency
+	})
+	if !ok {
+		return systemAccount, sql.ErrNoRows
+	}
+	return systemAccount, nil
+}
+
+// transfers
+
+func (q *memoryQueries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	defer q.lock()()
+
+	if !q.db.accounts.exists(arg.FromAccountID) {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+	}
+	if !q.db.accounts.exists(arg.ToAccountID) {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+	}
+	if arg.FeeAccountID.Valid && !q.db.accounts.exists(arg.FeeAccountID.Int64) {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_fee_account_id_fkey")
+	}
+	if arg.Fee < 0 || arg.Fee >= arg.Amount {
+		return Transfer{}, checkViolation("transfers", "transfers_fee_check")
+	}
+
+	transfer := Transfer{
+		ID:            q.db.transfers.nextID(),
+		CreatedAt:     q.now(),
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Fee:           arg.Fee,
+		FeeAccountID:  arg.FeeAccountID,
+	}
+	q.db.transfers.put(q.txn, transfer.ID, transfer)
+	return transfer, nil
+}
+
+func (q *memoryQueries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	defer q.lock()()
+
+	transfer, ok := q.db.transfers.get(id)
+	if !ok {
+		return transfer, sql.ErrNoRows
+	}
+	return transfer, nil
+}
+
+func (q *memoryQueries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	defer q.lock()()
+
+	transfers := q.db.transfers.list(func(t Transfer) bool {
+		return t.FromAccountID == arg.FromAccountID || t.ToAccountID == arg.ToAccountID
+	})
+	return page(transfers, arg.Limit, arg.Offset), nil
+}
+
+// users
+
+func (q *memoryQueries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	defer q.lock()()
+
+	if _, ok := q.db.users.find(func(u User) bool { return u.Username == arg.Username }); ok {
+		return User{}, uniqueViolation("users", "users_username_key")
+	}
+	if _, ok := q.db.users.find(func(u User) bool { return u.Email == arg.Email }); ok {
+		return User{}, uniqueViolation("users", "users_email_key")
+	}
+
+	user := User{
+		ID:        q.db.users.nextID(),
+		CreatedAt: q.now(),
+		Username:  arg.Username,
+		Password:  arg.Password,
+		FullName:  arg.FullName,
+		Email:     arg.Email,
+	}
+	q.db.users.put(q.txn, user.ID, user)
+	return user, nil
+}
+
+func (q *memoryQueries) GetUser(ctx context.Context, id int64) (User, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.get(id)
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// GetUserIDForUpdate : the transactions are serializable, so the row doesn't have to be locked
+func (q *memoryQueries) GetUserIDForUpdate(ctx context.Context, id int64) (int64, error) {
+	user, err := q.GetUser(ctx, id)
+	return user.ID, err
+}
+
+func (q *memoryQueries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.find(func(u User) bool { return u.Username == username })
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *memoryQueries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.find(func(u User) bool { return u.Email == email })
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *memoryQueries) GetUserPasswordChangedAt(ctx context.Context, id int64) (time.Time, error) {
+	user, err := q.GetUser(ctx, id)
+	return user.PasswordChangedAt, err
+}
+
+func (q *memoryQueries) IncrementFailedLoginAttempts(ctx context.Context, id int64) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(id, func(u *User) { u.FailedLoginAttempts++ })
+}
+
+func (q *memoryQueries) LockUser(ctx context.Context, arg LockUserParams) error {
+	defer q.lock()()
+
+	q.db.users.update(q.txn, arg.ID, func(u *User) { u.LockedUntil = arg.LockedUntil })
+	return nil
+}
+
+func (q *memoryQueries) ResetFailedLoginAttempts(ctx context.Context, id int64) error {
+	defer q.lock()()
+
+	q.db.users.update(q.txn, id, func(u *User) {
+		u.FailedLoginAttempts = 0
+		u.LockedUntil = time.Time{}
+	})
+	return nil
+}
+
+func (q *memoryQueries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(arg.ID, func(u *User) {
+		u.TotpSecret = arg.TotpSecret
+		u.IsTotpEnabled = false
+	})
+}
+
+func (q *memoryQueries) EnableUserTOTP(ctx context.Context, id int64) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(id, func(u *User) { u.IsTotpEnabled = true })
+}
+
+func (q *memoryQueries) UpdateUserTOTPLastUsedStep(ctx context.Context, arg UpdateUserTOTPLastUsedStepParams) (int64, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.get(arg.ID)
+	if !ok || user.TotpLastUsedStep >= arg.Step {
+		return 0, nil
+	}
+
+	user.TotpLastUsedStep = arg.Step
+	q.db.users.put(q.txn, user.ID, user)
+	return 1, nil
+}
+
+func (q *memoryQueries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(arg.ID, func(u *User) {
+		u.Password = arg.Password
+		u.PasswordChangedAt = arg.PasswordChangedAt
+	})
+}
+
+func (q *memoryQueries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.get(arg.ID)
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+
+	if arg.Email.Valid {
+		if _, taken := q.db.users.find(func(u User) bool { return u.ID != user.ID && u.Email == arg.Email.String }); taken {
+			return User{}, uniqueViolation("users", "users_email_key")
+		}
+
+		// a changed email has to be verified again
+		user.IsEmailVerified = user.IsEmailVerified && arg.Email.String == user.Email
+		user.Email = arg.Email.String
+	}
+	if arg.FullName.Valid {
+		user.FullName = arg.FullName.String
+	}
+
+	q.db.users.put(q.txn, user.ID, user)
+	return user, nil
+}
+
+func (q *memoryQueries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	defer q.lock()()
+
+	user, ok := q.db.users.get(arg.ID)
+	if !ok || user.Email != arg.Email {
+		return 0, nil
+	}
+
+	user.IsEmailVerified = true
+	q.db.users.put(q.txn, user.ID, user)
+	return 1, nil
+}
+
+func (q *memoryQueries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error {
+	defer q.lock()()
+
+	q.db.users.update(q.txn, arg.ID, func(u *User) { u.Password = arg.Password })
+	return nil
+}
+
+func (q *memoryQueries) DisableUser(ctx context.Context, arg DisableUserParams) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(arg.ID, func(u *User) {
+		u.IsDisabled = true
+		u.PasswordChangedAt = arg.PasswordChangedAt
+	})
+}
+
+func (q *memoryQueries) EnableUser(ctx context.Context, id int64) (User, error) {
+	defer q.lock()()
+
+	return q.updateUser(id, func(u *User) { u.IsDisabled = false })
+}
+
+// updateUser : updates the user and returns it, sql.ErrNoRows if it doesn't exist
+func (q *memoryQueries) updateUser(id int64, fn func(u *User)) (User, error) {
+	user, ok := q.db.users.update(q.txn, id, fn)
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// webhooks
+
+func (q *memoryQueries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	defer q.lock()()
+
+	if !q.db.users.exists(arg.UserID) {
+		return Webhook{}, foreignKeyViolation("webhooks", "webhooks_user_id_fkey")
+	}
+	if arg.EventTypes == nil {
+		return Webhook{}, notNullViolation("webhooks", "event_types")
+	}
+
+	webhook := Webhook{
+		ID:         q.db.webhooks.nextID(),
+		CreatedAt:  q.now(),
+		UserID:     arg.UserID,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: append([]string{}, arg.EventTypes...),
+	}
+	q.db.webhooks.put(q.txn, webhook.ID, webhook)
+	return webhook, nil
+}
+
+func (q *memoryQueries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	defer q.lock()()
+
+	webhook, ok := q.db.webhooks.get(id)
+	if !ok {
+		return webhook, sql.ErrNoRows
+	}
+	return webhook, nil
+}
+
+func (q *memoryQueries) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	defer q.lock()()
+
+	webhooks := q.db.webhooks.list(func(w Webhook) bool { return w.UserID == arg.UserID })
+	return page(webhooks, arg.Limit, arg.Offset), nil
+}
+
+func (q *memoryQueries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	defer q.lock()()
+
+	return q.db.webhooks.list(func(w Webhook) bool {
+		return w.UserID == arg.UserID && (len(w.EventTypes) == 0 || containsString(w.EventTypes, arg.EventType))
+	}), nil
+}
+
+// DeleteWebhook : the deliveries of the webhook are deleted along with it
+func (q *memoryQueries) DeleteWebhook(ctx context.Context, id int64) error {
+	defer q.lock()()
+
+	for _, deliveryID := range q.db.webhookDeliveries.ids(func(d WebhookDelivery) bool { return d.WebhookID == id }) {
+		q.db.webhookDeliveries.delete(q.txn, deliveryID)
+	}
+	q.db.webhooks.delete(q.txn, id)
+	return nil
+}
+
+// webhook deliveries
+
+// deliveryStatuses : allowed by the webhook_deliveries_status_check constraint
+var deliveryStatuses = []string{DeliveryStatusPending, DeliveryStatusSucceeded, DeliveryStatusDead}
+
+func (q *memoryQueries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	defer q.lock()()
+
+	if !q.db.webhooks.exists(arg.WebhookID) {
+		return foreignKeyViolation("webhook_deliveries", "webhook_deliveries_webhook_id_fkey")
+	}
+	if !q.db.outboxEvents.exists(arg.EventID) {
+		return foreignKeyViolation("webhook_deliveries", "webhook_deliveries_event_id_fkey")
+	}
+
+	// ON CONFLICT DO NOTHING
+	_, exists := q.db.webhookDeliveries.find(func(d WebhookDelivery) bool {
+		return d.WebhookID == arg.WebhookID && d.EventID == arg.EventID
+	})
+	if exists {
+		return nil
+	}
+
+	now := q.now()
+	delivery := WebhookDelivery{
+		ID:            q.db.webhookDeliveries.nextID(),
+		CreatedAt:     now,
+		WebhookID:     arg.WebhookID,
+		EventID:       arg.EventID,
+		Status:        DeliveryStatusPending,
+		NextAttemptAt: now,
+		UpdatedAt:     now,
+	}
+	q.db.webhookDeliveries.put(q.txn, delivery.ID, delivery)
+	return nil
+}
+
+func (q *memoryQueries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	defer q.lock()()
+
+	delivery, ok := q.db.webhookDeliveries.get(id)
+	if !ok {
+		return delivery, sql.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (q *memoryQueries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error) {
+	defer q.lock()()
+
+	deliveries := q.db.webhookDeliveries.list(func(d WebhookDelivery) bool { return d.WebhookID == arg.WebhookID })
+	reverse(deliveries)
+
+	rows := make([]ListWebhookDeliveriesRow, 0)
+	for _, delivery := range page(deliveries, arg.Limit, arg.Offset) {
+		event, _ := q.db.outboxEvents.get(delivery.EventID)
+		rows = append(rows, ListWebhookDeliveriesRow{
+			ID:             delivery.ID,
+			CreatedAt:      delivery.CreatedAt,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastError:      delivery.LastError,
+			LastStatusCode: delivery.LastStatusCode,
+			DeliveredAt:    delivery.DeliveredAt,
+			UpdatedAt:      delivery.UpdatedAt,
+			EventType:      event.EventType,
+		})
+	}
+	return rows, nil
+}
+
+// ClaimWebhookDeliveries : leases the due deliveries, the earliest due first, and returns them by id
+func (q *memoryQueries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	defer q.lock()()
+
+	now := q.now()
+	due := q.db.webhookDeliveries.list(func(d WebhookDelivery) bool {
+		return d.Status == DeliveryStatusPending && !d.NextAttemptAt.After(now)
+	})
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	due = page(due, arg.Limit, 0)
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+
+	rows := make([]ClaimWebhookDeliveriesRow, 0)
+	for _, delivery := range due {
+		q.db.webhookDeliveries.update(q.txn, delivery.ID, func(d *WebhookDelivery) {
+			d.NextAttemptAt = now.Add(time.Duration(arg.LeaseSeconds) * time.Second)
+			d.UpdatedAt = now
+		})
+
+		webhook, _ := q.db.webhooks.get(delivery.WebhookID)
+		event, _ := q.db.outboxEvents.get(delivery.EventID)
+		rows = append(rows, ClaimWebhookDeliveriesRow{
+			ID:             delivery.ID,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			Attempts:       delivery.Attempts,
+			Url:            webhook.Url,
+			Secret:         webhook.Secret,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+			EventCreatedAt: event.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (q *memoryQueries) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	defer q.lock()()
+
+	delivery, ok := q.db.webhookDeliveries.get(arg.ID)
+	if !ok {
+		return delivery, sql.ErrNoRows
+	}
+	if !containsString(deliveryStatuses, arg.Status) {
+		return WebhookDelivery{}, checkViolation("webhook_deliveries", "webhook_deliveries_status_check")
+	}
+
+	delivery.Status = arg.Status
+	delivery.Attempts = arg.Attempts
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.LastError = arg.LastError
+	delivery.LastStatusCode = arg.LastStatusCode
+	delivery.DeliveredAt = arg.DeliveredAt
+	delivery.UpdatedAt = q.now()
+	q.db.webhookDeliveries.put(q.txn, delivery.ID, delivery)
+	return delivery, nil
+}
+
+func (q *memoryQueries) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	defer q.lock()()
+
+	now := q.now()
+	delivery, ok := q.db.webhookDeliveries.update(q.txn, id, func(d *WebhookDelivery) {
+		replayDelivery(d, now)
+		d.DeliveredAt = sql.NullTime{}
+	})
+	if !ok {
+		return delivery, sql.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (q *memoryQueries) ReplayDeadWebhookDeliveries(ctx context.Context, webhookID int64) (int64, error) {
+	defer q.lock()()
+
+	now := q.now()
+	ids := q.db.webhookDeliveries.ids(func(d WebhookDelivery) bool {
+		return d.WebhookID == webhookID && d.Status == DeliveryStatusDead
+	})
+	for _, id := range ids {
+		q.db.webhookDeliveries.update(q.txn, id, func(d *WebhookDelivery) { replayDelivery(d, now) })
+	}
+	return int64(len(ids)), nil
+}
+
+// replayDelivery : makes the delivery pending again with all its attempts
+func replayDelivery(delivery *WebhookDelivery, now time.Time) {
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.LastError = ""
+	delivery.LastStatusCode = 0
+	delivery.UpdatedAt = now
+}
+
+// helpers
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func reverse[T any](rows []T) {
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+}
+
+// toDate : the date of a `date` column, the time is truncated in UTC
+func toDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func cloneJSON(data json.RawMessage) json.RawMessage {
+	return append(json.RawMessage{}, data...)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// types of the events written to the outbox
+const (
+	EventTransferDebited  = "transfer.debited"  // money left the account
+	EventTransferCredited = "transfer.credited" // money arrived on the account
+)
+
+// statuses of a webhook delivery, a pending delivery is retried until it succeeds or runs out of attempts
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusDead      = "dead"
+)
+
+// EventTypes : every event type a webhook can subscribe to
+var EventTypes = []string{EventTransferDebited, EventTransferCredited}
+
+// TransferEventPayload : the payload of the transfer events, the amounts are in the minor unit of the currency
+type TransferEventPayload struct {
+	TransferID            int64     `json:"transfer_id"`
+	AccountID             int64     `json:"account_id"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id"`
+	Currency              string    `json:"currency"`
+	Amount                int64     `json:"amount"`  // gross amount for a debit, net amount for a credit
+	Fee                   int64     `json:"fee"`     // fee charged on the transfer
+	Balance               int64     `json:"balance"` // balance of the account after the transfer
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// recordTransferEvents : writes the events of a transfer to the outbox with the queries of the transaction
+// which moved the money, so the events are published if and only if the transfer commits. The fee account
+// belongs to the bank and doesn't get an event
+func recordTransferEvents(ctx context.Context, q Querier, result TransferTxnResult) error {
+	events := []struct {
+		eventType    string
+		account      Account
+		counterparty int64
+		amount       int64
+	}{
+		{EventTransferDebited, result.FromAccount, result.ToAccount.ID, result.Transfer.Amount},
+		{EventTransferCredited, result.ToAccount, result.FromAccount.ID, result.ToEntry.Amount},
+	}
+
+	for _, event := range events {
+		payload, err := json.Marshal(TransferEventPayload{
+			TransferID:            result.Transfer.ID,
+			AccountID:             event.account.ID,
+			CounterpartyAccountID: event.counterparty,
+			Currency:              event.account.Currency,
+			Amount:                event.amount,
+			Fee:                   result.Transfer.Fee,
+			Balance:               event.account.Balance,
+			CreatedAt:             result.Transfer.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+			EventType: event.eventType,
+			AccountID: event.account.ID,
+			UserID:    event.account.UserID,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FanOutOutboxTxnResult : contains the result of the fan out outbox transaction
+type FanOutOutboxTxnResult struct {
+	Events     int `json:"events"`     // outbox events which have been dispatched
+	Deliveries int `json:"deliveries"` // deliveries created for the webhooks of the events
+}
+
+// FanOutOutboxTxn : creates a pending delivery of each undispatched outbox event for every webhook of the
+// owner of the account which is subscribed to its type, and marks the event as dispatched. The events are
+// locked with SKIP LOCKED so that concurrent dispatchers work on different events
+func (s *txnStore) FanOutOutboxTxn(ctx context.Context, limit int32) (FanOutOutboxTxnResult, error) {
+	var result FanOutOutboxTxnResult
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		events, err := q.ListUndispatchedOutboxEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			webhooks, err := q.ListWebhooksForEvent(ctx, ListWebhooksForEventParams{
+				UserID:    event.UserID,
+				EventType: event.EventType,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, webhook := range webhooks {
+				err = q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+					WebhookID: webhook.ID,
+					EventID:   event.ID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			err = q.MarkOutboxEventDispatched(ctx, event.ID)
+			if err != nil {
+				return err
+			}
+
+			result.Events++
+			result.Deliveries += len(webhooks)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ChangePasswordTxnParams : contains the input parameters of the change password transaction
+type ChangePasswordTxnParams struct {
+	UserID            int64     `json:"user_id"`
+	Password          string    `json:"password"`            // the hashed new password
+	PasswordChangedAt time.Time `json:"password_changed_at"` // the access tokens issued before it get rejected
+}
+
+// ChangePasswordTxn : updates the password of the user and discards the pending reset tokens
+func (s *txnStore) ChangePasswordTxn(ctx context.Context, arg ChangePasswordTxnParams) (User, error) {
+	var user User
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		var err error
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:                arg.UserID,
+			Password:          arg.Password,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeletePasswordResetTokens(ctx, arg.UserID)
+	})
+
+	return user, err
+}
+
+// ResetPasswordTxnParams : contains the input parameters of the reset password transaction
+type ResetPasswordTxnParams struct {
+	TokenHash         string    `json:"token_hash"`
+	Password          string    `json:"password"`            // the hashed new password
+	PasswordChangedAt time.Time `json:"password_changed_at"` // the access tokens issued before it get rejected
+}
+
+// ResetPasswordTxn : uses the reset token and updates the password of its user, the pending reset tokens
+// are discarded and the failed login attempts are reset. sql.ErrNoRows is returned if the token is unknown,
+// expired or has already been used
+func (s *txnStore) ResetPasswordTxn(ctx context.Context, arg ResetPasswordTxnParams) (User, error) {
+	var user User
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		err = q.ResetFailedLoginAttempts(ctx, resetToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:                resetToken.UserID,
+			Password:          arg.Password,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeletePasswordResetTokens(ctx, resetToken.UserID)
+	})
+
+	return user, err
+}
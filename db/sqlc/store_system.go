@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SystemUsername : owns the accounts of the bank itself, created by the migrations
+const SystemUsername = "_system"
+
+// purposes of the system accounts, each purpose has one account per currency
+const (
+	SystemAccountInterestExpense = "interest_expense"
+	SystemAccountFeeRevenue      = "fee_revenue"
+	SystemAccountAdjustment      = "adjustment"
+	SystemAccountOpeningBalance  = "opening_balance"
+)
+
+// SystemAccountTxnParams : contains the input parameters of the system account transaction
+type SystemAccountTxnParams struct {
+	Purpose       string `json:"purpose"`
+	Currency      string `json:"currency"`
+	AccountNumber string `json:"account_number"` // only used if the account has to be created
+}
+
+// SystemAccountTxn : returns the system account of the purpose and the currency, the account is opened
+// for the system user on first use. The row of the system user is locked so that concurrent callers
+// can't open two accounts for the same purpose
+func (s *txnStore) SystemAccountTxn(ctx context.Context, arg SystemAccountTxnParams) (Account, error) {
+	var account Account
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		systemUser, err := q.GetUserByUsername(ctx, SystemUsername)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetUserIDForUpdate(ctx, systemUser.ID)
+		if err != nil {
+			return err
+		}
+
+		systemAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  arg.Purpose,
+			Currency: arg.Currency,
+		})
+		if err == nil {
+			account, err = q.GetAccount(ctx, systemAccount.AccountID)
+			return err
+		}
+
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		account, err = q.CreateAccount(ctx, CreateAccountParams{
+			UserID:        systemUser.ID,
+			Balance:       0,
+			Currency:      arg.Currency,
+			Type:          "checking",
+			Nickname:      arg.Purpose,
+			AccountNumber: arg.AccountNumber,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateSystemAccount(ctx, CreateSystemAccountParams{
+			Purpose:   arg.Purpose,
+			Currency:  arg.Currency,
+			AccountID: account.ID,
+		})
+		return err
+	})
+
+	return account, err
+}
@@ -2,17 +2,22 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/skamranahmed/banking-system/utils"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTxn(t *testing.T) {
-	store := NewStore(testDB)
+	q, conn := setupTestDB(t)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
 
 	fmt.Println("-----------------------------------------------------------")
 	fmt.Println("Account1 Balance Before Transaction(s): ", account1.Balance)
@@ -115,10 +120,10 @@ func TestTransferTxn(t *testing.T) {
 	}
 
 	// check the final updated balance of both the accounts
-	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	updatedAccount2, err := q.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	fmt.Println("-----------------------------------------------------------")
@@ -132,10 +137,12 @@ func TestTransferTxn(t *testing.T) {
 }
 
 func TestTransferTxnDeadlock(t *testing.T) {
-	store := NewStore(testDB)
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
 
 	fmt.Println("-----------------------------------------------------------")
 	fmt.Println("Account1 Balance Before Transaction(s): ", account1.Balance)
@@ -179,10 +186,10 @@ func TestTransferTxnDeadlock(t *testing.T) {
 	}
 
 	// check the final updated balance of both the accounts
-	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	updatedAccount2, err := q.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	fmt.Println("-----------------------------------------------------------")
@@ -194,3 +201,267 @@ func TestTransferTxnDeadlock(t *testing.T) {
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 
 }
+
+func TestTransferTxnWithFee(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	feeAccount := createRandomAccount(t, q)
+
+	n := 5
+	amount := int64(10)
+	fee := int64(2)
+
+	errs := make(chan error)
+	results := make(chan TransferTxnResult)
+
+	// the transfers in both directions charge a fee to the same account
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			result, err := store.TransferTxn(context.Background(), TransferTxnParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+				Fee:           fee,
+				FeeAccountID:  feeAccount.ID,
+			})
+
+			errs <- err
+			results <- result
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+
+		result := <-results
+		require.Equal(t, amount, result.Transfer.Amount)
+		require.Equal(t, fee, result.Transfer.Fee)
+		require.Equal(t, sql.NullInt64{Int64: feeAccount.ID, Valid: true}, result.Transfer.FeeAccountID)
+
+		require.Equal(t, -amount, result.FromEntry.Amount)
+		require.Equal(t, amount-fee, result.ToEntry.Amount)
+		require.Equal(t, feeAccount.ID, result.FeeEntry.AccountID)
+		require.Equal(t, fee, result.FeeEntry.Amount)
+		require.Equal(t, feeAccount.ID, result.FeeAccount.ID)
+	}
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	updatedAccount2, err := q.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	updatedFeeAccount, err := q.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+
+	// 3 transfers from account1 and 2 transfers from account2
+	require.Equal(t, account1.Balance-3*amount+2*(amount-fee), updatedAccount1.Balance)
+	require.Equal(t, account2.Balance-2*amount+3*(amount-fee), updatedAccount2.Balance)
+	require.Equal(t, feeAccount.Balance+int64(n)*fee, updatedFeeAccount.Balance)
+}
+
+func TestTransferTxnInvalidFee(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	testCases := []TransferTxnParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Fee: 10, FeeAccountID: account2.ID},
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Fee: -1, FeeAccountID: account2.ID},
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Fee: 1},
+	}
+
+	for _, arg := range testCases {
+		_, err := store.TransferTxn(context.Background(), arg)
+		require.Error(t, err)
+	}
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestEnableTOTPTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	user := createRandomUser(t, q)
+	require.False(t, user.IsTotpEnabled)
+
+	user, err := q.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: utils.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.False(t, user.IsTotpEnabled)
+
+	recoveryCodeHashes := []string{utils.RandomString(64), utils.RandomString(64)}
+
+	enabledUser, err := store.EnableTOTPTxn(context.Background(), EnableTOTPTxnParams{
+		UserID:             user.ID,
+		Step:               42,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	require.NoError(t, err)
+	require.True(t, enabledUser.IsTotpEnabled)
+	require.Equal(t, int64(42), enabledUser.TotpLastUsedStep)
+	require.Equal(t, user.TotpSecret, enabledUser.TotpSecret)
+
+	for _, codeHash := range recoveryCodeHashes {
+		rowsAffected, err := q.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: codeHash,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rowsAffected)
+	}
+}
+
+func TestChangePasswordTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	user := createRandomUser(t, q)
+	resetToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+
+	arg := ChangePasswordTxnParams{
+		UserID:            user.ID,
+		Password:          utils.RandomString(60),
+		PasswordChangedAt: time.Now(),
+	}
+
+	updatedUser, err := store.ChangePasswordTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, updatedUser.Password)
+	require.WithinDuration(t, arg.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Second)
+
+	// the pending reset tokens are discarded
+	_, err = q.UsePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResetPasswordTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	user := createRandomUser(t, q)
+	_, err := q.IncrementFailedLoginAttempts(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	resetToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+	otherResetToken := createRandomPasswordResetToken(t, q, user, time.Now().Add(time.Minute))
+
+	arg := ResetPasswordTxnParams{
+		TokenHash:         resetToken.TokenHash,
+		Password:          utils.RandomString(60),
+		PasswordChangedAt: time.Now(),
+	}
+
+	updatedUser, err := store.ResetPasswordTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, updatedUser.ID)
+	require.Equal(t, arg.Password, updatedUser.Password)
+	require.Zero(t, updatedUser.FailedLoginAttempts)
+
+	// neither the used token nor the other pending tokens can be used again
+	_, err = store.ResetPasswordTxn(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.TokenHash = otherResetToken.TokenHash
+	_, err = store.ResetPasswordTxn(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateAccountTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	user := createRandomUser(t, q)
+
+	// concurrent requests must not exceed the limit
+	n := 5
+	limit := int64(2)
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		accountNumber := randomAccountNumber(t)
+
+		go func() {
+			_, err := store.CreateAccountTxn(context.Background(), CreateAccountTxnParams{
+				CreateAccountParams: CreateAccountParams{
+					UserID:        user.ID,
+					Currency:      utils.INR,
+					AccountNumber: accountNumber,
+					Type:          utils.AccountTypeChecking,
+				},
+				MaxAccountsPerCurrency: limit,
+			})
+			errs <- err
+		}()
+	}
+
+	created := int64(0)
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+			continue
+		}
+		require.ErrorIs(t, err, ErrAccountLimitReached)
+	}
+	require.Equal(t, limit, created)
+
+	// the limit applies per currency
+	account, err := store.CreateAccountTxn(context.Background(), CreateAccountTxnParams{
+		CreateAccountParams: CreateAccountParams{
+			UserID:        user.ID,
+			Currency:      utils.USD,
+			AccountNumber: randomAccountNumber(t),
+			Type:          utils.AccountTypeWallet,
+		},
+		MaxAccountsPerCurrency: limit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.AccountTypeWallet, account.Type)
+
+	// no limit
+	_, err = store.CreateAccountTxn(context.Background(), CreateAccountTxnParams{
+		CreateAccountParams: CreateAccountParams{
+			UserID:        user.ID,
+			Currency:      utils.INR,
+			AccountNumber: randomAccountNumber(t),
+			Type:          utils.AccountTypeSavings,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateAccountTxn(context.Background(), CreateAccountTxnParams{
+		CreateAccountParams: CreateAccountParams{
+			UserID:        user.ID + 1000000,
+			Currency:      utils.INR,
+			AccountNumber: randomAccountNumber(t),
+			Type:          utils.AccountTypeChecking,
+		},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,45 @@
+package db
+
+import "context"
+
+// EnableTOTPTxnParams : contains the input parameters of the enable totp transaction
+type EnableTOTPTxnParams struct {
+	UserID             int64    `json:"user_id"`
+	Step               int64    `json:"step"`                 // the time step of the code that confirmed the enrollment
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"` // replace the previously issued recovery codes
+}
+
+// EnableTOTPTxn : enables the two factor authentication of the user and replaces the recovery codes
+func (s *txnStore) EnableTOTPTxn(ctx context.Context, arg EnableTOTPTxnParams) (User, error) {
+	var user User
+
+	err := s.execTxn(ctx, func(q Querier) error {
+		_, err := q.UpdateUserTOTPLastUsedStep(ctx, UpdateUserTOTPLastUsedStepParams{
+			ID:   arg.UserID,
+			Step: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				UserID:   arg.UserID,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err = q.EnableUserTOTP(ctx, arg.UserID)
+		return err
+	})
+
+	return user, err
+}
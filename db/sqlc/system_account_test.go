@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemAccountTxn(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	arg := SystemAccountTxnParams{
+		Purpose:       SystemAccountInterestExpense,
+		Currency:      utils.RandomCurrency(),
+		AccountNumber: randomAccountNumber(t),
+	}
+
+	account, err := store.SystemAccountTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.Purpose, account.Nickname)
+
+	systemUser, err := q.GetUserByUsername(context.Background(), SystemUsername)
+	require.NoError(t, err)
+	require.Equal(t, systemUser.ID, account.UserID)
+
+	// the account is opened once per purpose and currency
+	arg.AccountNumber = randomAccountNumber(t)
+	sameAccount, err := store.SystemAccountTxn(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, sameAccount.ID)
+}
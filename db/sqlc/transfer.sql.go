@@ -7,26 +7,37 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  fee,
+  fee_account_id
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, created_at, from_account_id, to_account_id, amount
+  $1, $2, $3, $4, $5
+) RETURNING id, created_at, from_account_id, to_account_id, amount, fee, fee_account_id
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	Fee           int64         `json:"fee"`
+	FeeAccountID  sql.NullInt64 `json:"fee_account_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Fee,
+		arg.FeeAccountID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +45,14 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.FromAccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.Fee,
+		&i.FeeAccountID,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, created_at, from_account_id, to_account_id, amount FROM transfers
+SELECT id, created_at, from_account_id, to_account_id, amount, fee, fee_account_id FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +65,14 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.FromAccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.Fee,
+		&i.FeeAccountID,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, created_at, from_account_id, to_account_id, amount FROM transfers
+SELECT id, created_at, from_account_id, to_account_id, amount, fee, fee_account_id FROM transfers
 WHERE 
     from_account_id = $1 OR
     to_account_id = $2
@@ -93,6 +108,8 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.FromAccountID,
 			&i.ToAccountID,
 			&i.Amount,
+			&i.Fee,
+			&i.FeeAccountID,
 		); err != nil {
 			return nil, err
 		}
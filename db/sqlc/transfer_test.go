@@ -9,14 +9,14 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomTransfer(t *testing.T, fromAccount, toAccount Account) Transfer {
+func createRandomTransfer(t *testing.T, q Querier, fromAccount, toAccount Account) Transfer {
 	arg := CreateTransferParams{
 		FromAccountID: fromAccount.ID,
 		ToAccountID:   toAccount.ID,
 		Amount:        utils.RandomMoney(),
 	}
 
-	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
+	transfer, err := q.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer)
 
@@ -31,17 +31,21 @@ func createRandomTransfer(t *testing.T, fromAccount, toAccount Account) Transfer
 }
 
 func TestCreateTransfer(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-	createRandomTransfer(t, account1, account2)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	createRandomTransfer(t, q, account1, account2)
 }
 
 func TestGetTransfer(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-	transfer1 := createRandomTransfer(t, account1, account2)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	transfer1 := createRandomTransfer(t, q, account1, account2)
 
-	transfer2, err := testQueries.GetTransfer(context.Background(), transfer1.ID)
+	transfer2, err := q.GetTransfer(context.Background(), transfer1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer2)
 
@@ -53,15 +57,17 @@ func TestGetTransfer(t *testing.T) {
 }
 
 func TestListTransfer(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	q, _ := setupTestDB(t)
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
 
 	for i := 0; i < 5; i++ {
 		// transfer from account1 to account2
-		createRandomTransfer(t, account1, account2)
+		createRandomTransfer(t, q, account1, account2)
 
 		// transfer from account2 to account1
-		createRandomTransfer(t, account2, account1)
+		createRandomTransfer(t, q, account2, account1)
 	}
 
 	arg := ListTransfersParams{
@@ -71,7 +77,7 @@ func TestListTransfer(t *testing.T) {
 		Offset:        5,
 	}
 
-	transfers, err := testQueries.ListTransfers(context.Background(), arg)
+	transfers, err := q.ListTransfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
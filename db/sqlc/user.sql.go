@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -17,7 +19,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING id, created_at, username, password, full_name, email
+) RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
 `
 
 type CreateUserParams struct {
@@ -42,12 +44,114 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.FullName,
 		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET
+  is_disabled = true,
+  password_changed_at = $2
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+type DisableUserParams struct {
+	ID                int64     `json:"id"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func (q *Queries) DisableUser(ctx context.Context, arg DisableUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUser, arg.ID, arg.PasswordChangedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET is_disabled = false
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, username, password, full_name, email FROM users
+SELECT id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -61,12 +165,47 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.Password,
 		&i.FullName,
 		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, created_at, username, password, full_name, email FROM users
+SELECT id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -80,6 +219,261 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Password,
 		&i.FullName,
 		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
 	)
 	return i, err
 }
+
+const getUserIDForUpdate = `-- name: GetUserIDForUpdate :one
+SELECT id FROM users
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserIDForUpdate(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUserIDForUpdate, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUserPasswordChangedAt = `-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserPasswordChangedAt(ctx context.Context, id int64) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getUserPasswordChangedAt, id)
+	var password_changed_at time.Time
+	err := row.Scan(&password_changed_at)
+	return password_changed_at, err
+}
+
+const incrementFailedLoginAttempts = `-- name: IncrementFailedLoginAttempts :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+func (q *Queries) IncrementFailedLoginAttempts(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, incrementFailedLoginAttempts, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET locked_until = $2
+WHERE id = $1
+`
+
+type LockUserParams struct {
+	ID          int64     `json:"id"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.ExecContext(ctx, lockUser, arg.ID, arg.LockedUntil)
+	return err
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET is_email_verified = true
+WHERE id = $1 AND email = $2
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markUserEmailVerified, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetFailedLoginAttempts = `-- name: ResetFailedLoginAttempts :exec
+UPDATE users
+SET
+  failed_login_attempts = 0,
+  locked_until = '0001-01-01 00:00:00Z'
+WHERE id = $1
+`
+
+func (q *Queries) ResetFailedLoginAttempts(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, resetFailedLoginAttempts, id)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = $2,
+  is_totp_enabled = false
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+type SetUserTOTPSecretParams struct {
+	ID         int64  `json:"id"`
+	TotpSecret string `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserTOTPSecret, arg.ID, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+  full_name = COALESCE($1, full_name),
+  email = COALESCE($2, email),
+  is_email_verified = is_email_verified AND COALESCE($2, email) = email
+WHERE id = $3
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+type UpdateUserParams struct {
+	FullName sql.NullString `json:"full_name"`
+	Email    sql.NullString `json:"email"`
+	ID       int64          `json:"id"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser, arg.FullName, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET
+  password = $2,
+  password_changed_at = $3
+WHERE id = $1
+RETURNING id, created_at, username, password, full_name, email, failed_login_attempts, locked_until, totp_secret, is_totp_enabled, totp_last_used_step, password_changed_at, is_email_verified, is_disabled
+`
+
+type UpdateUserPasswordParams struct {
+	ID                int64     `json:"id"`
+	Password          string    `json:"password"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.ID, arg.Password, arg.PasswordChangedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.PasswordChangedAt,
+		&i.IsEmailVerified,
+		&i.IsDisabled,
+	)
+	return i, err
+}
+
+const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET password = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordHashParams struct {
+	ID       int64  `json:"id"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPasswordHash, arg.ID, arg.Password)
+	return err
+}
+
+const updateUserTOTPLastUsedStep = `-- name: UpdateUserTOTPLastUsedStep :execrows
+UPDATE users
+SET totp_last_used_step = $1
+WHERE id = $2 AND totp_last_used_step < $1
+`
+
+type UpdateUserTOTPLastUsedStepParams struct {
+	Step int64 `json:"step"`
+	ID   int64 `json:"id"`
+}
+
+func (q *Queries) UpdateUserTOTPLastUsedStep(ctx context.Context, arg UpdateUserTOTPLastUsedStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserTOTPLastUsedStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
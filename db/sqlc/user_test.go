@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -9,7 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomUser(t *testing.T) User {
+func createRandomUser(t *testing.T, q Querier) User {
 	hashedPassword, err := utils.HashPassword(utils.RandomString(6))
 	require.NoError(t, err)
 
@@ -20,7 +21,7 @@ func createRandomUser(t *testing.T) User {
 		Email:    utils.RandomEmail(),
 	}
 
-	user, err := testQueries.CreateUser(context.Background(), arg)
+	user, err := q.CreateUser(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, user)
 
@@ -35,12 +36,16 @@ func createRandomUser(t *testing.T) User {
 }
 
 func TestCreateUser(t *testing.T) {
-	createRandomUser(t)
+	q, _ := setupTestDB(t)
+
+	createRandomUser(t, q)
 }
 
 func TestGetUser(t *testing.T) {
-	user1 := createRandomUser(t)
-	user2, err := testQueries.GetUser(context.Background(), user1.ID)
+	q, _ := setupTestDB(t)
+
+	user1 := createRandomUser(t, q)
+	user2, err := q.GetUser(context.Background(), user1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, user2)
 
@@ -51,3 +56,135 @@ func TestGetUser(t *testing.T) {
 
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestGetUserByEmail(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user1 := createRandomUser(t, q)
+	user2, err := q.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	require.True(t, user.PasswordChangedAt.Before(user.CreatedAt))
+
+	hashedPassword, err := utils.HashPassword(utils.RandomString(6))
+	require.NoError(t, err)
+
+	arg := UpdateUserPasswordParams{
+		ID:                user.ID,
+		Password:          hashedPassword,
+		PasswordChangedAt: time.Now(),
+	}
+
+	updatedUser, err := q.UpdateUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, updatedUser.Password)
+	require.WithinDuration(t, arg.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Second)
+
+	passwordChangedAt, err := q.GetUserPasswordChangedAt(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, passwordChangedAt.Equal(updatedUser.PasswordChangedAt))
+}
+
+func TestUpdateUserPasswordHash(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+
+	hashedPassword, err := utils.HashPassword(utils.RandomString(6))
+	require.NoError(t, err)
+
+	err = q.UpdateUserPasswordHash(context.Background(), UpdateUserPasswordHashParams{
+		ID:       user.ID,
+		Password: hashedPassword,
+	})
+	require.NoError(t, err)
+
+	// a rehash doesn't revoke the issued tokens
+	updatedUser, err := q.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updatedUser.Password)
+	require.True(t, updatedUser.PasswordChangedAt.Equal(user.PasswordChangedAt))
+}
+
+func TestFailedLoginAttempts(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	require.Zero(t, user.FailedLoginAttempts)
+	require.True(t, user.LockedUntil.Before(time.Now()))
+
+	for i := 1; i <= 3; i++ {
+		updatedUser, err := q.IncrementFailedLoginAttempts(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), updatedUser.FailedLoginAttempts)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute)
+	err := q.LockUser(context.Background(), LockUserParams{
+		ID:          user.ID,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+
+	lockedUser, err := q.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, lockedUser.LockedUntil, time.Second)
+
+	err = q.ResetFailedLoginAttempts(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	unlockedUser, err := q.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Zero(t, unlockedUser.FailedLoginAttempts)
+	require.True(t, unlockedUser.LockedUntil.Before(time.Now()))
+}
+
+func TestUpdateUser(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+
+	rowsAffected, err := q.MarkUserEmailVerified(context.Background(), MarkUserEmailVerifiedParams{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	// updating only the full name keeps the email verified
+	newFullName := utils.RandomName()
+	updatedUser, err := q.UpdateUser(context.Background(), UpdateUserParams{
+		ID:       user.ID,
+		FullName: sql.NullString{String: newFullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, user.Email, updatedUser.Email)
+	require.True(t, updatedUser.IsEmailVerified)
+
+	// changing the email has to be verified again
+	newEmail := utils.RandomEmail()
+	updatedUser, err = q.UpdateUser(context.Background(), UpdateUserParams{
+		ID:    user.ID,
+		Email: sql.NullString{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.False(t, updatedUser.IsEmailVerified)
+
+	// the old email can't be verified anymore
+	rowsAffected, err = q.MarkUserEmailVerified(context.Background(), MarkUserEmailVerifiedParams{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+}
@@ -0,0 +1,238 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/skamranahmed/banking-system/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, q Querier, userID int64, eventTypes []string) Webhook {
+	arg := CreateWebhookParams{
+		UserID:     userID,
+		Url:        "https://" + utils.RandomString(8) + ".example.com/hooks",
+		Secret:     utils.RandomString(32),
+		EventTypes: eventTypes,
+	}
+
+	webhook, err := q.CreateWebhook(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, webhook.ID)
+	require.Equal(t, arg.UserID, webhook.UserID)
+	require.Equal(t, arg.Url, webhook.Url)
+	require.Equal(t, arg.Secret, webhook.Secret)
+	require.ElementsMatch(t, arg.EventTypes, webhook.EventTypes)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+// fanOutAll : dispatches every pending outbox event, including the ones left over by the other tests
+func fanOutAll(t *testing.T, store Store) {
+	for {
+		result, err := store.FanOutOutboxTxn(context.Background(), 1000)
+		require.NoError(t, err)
+		if result.Events == 0 {
+			return
+		}
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	for i := 0; i < 3; i++ {
+		createRandomWebhook(t, q, user.ID, []string{})
+	}
+
+	webhooks, err := q.ListWebhooks(context.Background(), ListWebhooksParams{
+		UserID: user.ID,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 3)
+	for _, webhook := range webhooks {
+		require.Equal(t, user.ID, webhook.UserID)
+	}
+}
+
+func TestListWebhooksForEvent(t *testing.T) {
+	q, _ := setupTestDB(t)
+
+	user := createRandomUser(t, q)
+	all := createRandomWebhook(t, q, user.ID, []string{})
+	credited := createRandomWebhook(t, q, user.ID, []string{EventTransferCredited})
+
+	webhooks, err := q.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		UserID:    user.ID,
+		EventType: EventTransferCredited,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+
+	webhooks, err = q.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		UserID:    user.ID,
+		EventType: EventTransferDebited,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	require.Equal(t, all.ID, webhooks[0].ID)
+	require.NotEqual(t, credited.ID, webhooks[0].ID)
+}
+
+func TestTransferTxnOutbox(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+	fanOutAll(t, store)
+
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+	webhook1 := createRandomWebhook(t, q, account1.UserID, []string{EventTransferCredited})
+	webhook2 := createRandomWebhook(t, q, account2.UserID, []string{})
+
+	result, err := store.TransferTxn(context.Background(), TransferTxnParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// a failed transfer doesn't leave any event behind
+	_, err = store.TransferTxn(context.Background(), TransferTxnParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Fee:           10,
+	})
+	require.ErrorIs(t, err, ErrFeeExceedsAmount)
+
+	events, err := q.ListUndispatchedOutboxEvents(context.Background(), 1000)
+	require.NoError(t, err)
+
+	var credited TransferEventPayload
+	types := make(map[string]int64)
+	for _, event := range events {
+		if event.AccountID != account1.ID && event.AccountID != account2.ID {
+			continue
+		}
+		types[event.EventType] = event.AccountID
+
+		if event.EventType == EventTransferCredited {
+			require.Equal(t, account2.UserID, event.UserID)
+			require.NoError(t, json.Unmarshal(event.Payload, &credited))
+		}
+	}
+	require.Equal(t, map[string]int64{EventTransferDebited: account1.ID, EventTransferCredited: account2.ID}, types)
+	require.Equal(t, result.Transfer.ID, credited.TransferID)
+	require.Equal(t, account1.ID, credited.CounterpartyAccountID)
+	require.Equal(t, int64(10), credited.Amount)
+	require.Equal(t, result.ToAccount.Balance, credited.Balance)
+	require.Equal(t, account2.Currency, credited.Currency)
+
+	// only the webhook of the recipient is subscribed to its event, the sender only wants credits
+	fanOutAll(t, store)
+
+	deliveries1, err := q.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{WebhookID: webhook1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, deliveries1)
+
+	deliveries2, err := q.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{WebhookID: webhook2.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, deliveries2, 1)
+	require.Equal(t, EventTransferCredited, deliveries2[0].EventType)
+	require.Equal(t, DeliveryStatusPending, deliveries2[0].Status)
+	require.Zero(t, deliveries2[0].Attempts)
+}
+
+func TestWebhookDeliveryLifecycle(t *testing.T) {
+	q, conn := setupTestDB(t)
+
+	store := NewStore(conn)
+
+	account1 := createFundedAccount(t, q)
+	account2 := createFundedAccount(t, q)
+	webhook := createRandomWebhook(t, q, account2.UserID, []string{EventTransferCredited})
+
+	_, err := store.TransferTxn(context.Background(), TransferTxnParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	fanOutAll(t, store)
+
+	// the claimed deliveries are leased, a second claim doesn't return them again
+	claimed, err := store.ClaimWebhookDeliveries(context.Background(), ClaimWebhookDeliveriesParams{Limit: 1000, LeaseSeconds: 60})
+	require.NoError(t, err)
+
+	var delivery ClaimWebhookDeliveriesRow
+	for _, row := range claimed {
+		if row.WebhookID == webhook.ID {
+			delivery = row
+		}
+	}
+	require.NotZero(t, delivery.ID)
+	require.Equal(t, webhook.Url, delivery.Url)
+	require.Equal(t, webhook.Secret, delivery.Secret)
+	require.Equal(t, EventTransferCredited, delivery.EventType)
+	require.NotEmpty(t, delivery.Payload)
+
+	claimed, err = store.ClaimWebhookDeliveries(context.Background(), ClaimWebhookDeliveriesParams{Limit: 1000, LeaseSeconds: 60})
+	require.NoError(t, err)
+	for _, row := range claimed {
+		require.NotEqual(t, delivery.ID, row.ID)
+	}
+
+	dead, err := store.RecordWebhookDeliveryAttempt(context.Background(), RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         DeliveryStatusDead,
+		Attempts:       delivery.Attempts + 1,
+		NextAttemptAt:  time.Now(),
+		LastError:      "unexpected response status 500",
+		LastStatusCode: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusDead, dead.Status)
+	require.Equal(t, int32(1), dead.Attempts)
+
+	replayed, err := store.ReplayDeadWebhookDeliveries(context.Background(), webhook.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), replayed)
+
+	pending, err := store.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusPending, pending.Status)
+	require.Zero(t, pending.Attempts)
+	require.Empty(t, pending.LastError)
+
+	succeeded, err := store.RecordWebhookDeliveryAttempt(context.Background(), RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         DeliveryStatusSucceeded,
+		Attempts:       1,
+		NextAttemptAt:  time.Now(),
+		LastStatusCode: 200,
+		DeliveredAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, succeeded.DeliveredAt.Valid)
+
+	// a single delivery can be replayed even once it has succeeded
+	pending, err = store.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusPending, pending.Status)
+	require.False(t, pending.DeliveredAt.Valid)
+
+	// the deliveries are deleted along with the webhook
+	err = store.DeleteWebhook(context.Background(), webhook.ID)
+	require.NoError(t, err)
+
+	_, err = store.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -4,7 +4,7 @@
 set -e
 
 echo "🏃‍♂️ Running database migrations"
-/app/migrate -path /app/migration -database "$DB_HOST" -verbose up
+/app/main migrate up
 
 echo "🤞 Starting the app..."
 # take all parameter passed to the script and run it
@@ -0,0 +1,189 @@
+package fee
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/spf13/viper"
+)
+
+// AnyCurrency : the currency of the rule which applies to the currencies without a rule of their own
+const AnyCurrency = "*"
+
+// maxPercentBps : 100% in basis points
+const maxPercentBps = 10000
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Tier : the fee of the amounts up to a bound, the amounts are in minor units
+type Tier struct {
+	UpTo       int64 `mapstructure:"up_to"`       // inclusive upper bound of the amount, 0 for the last tier
+	Flat       int64 `mapstructure:"flat"`        // charged on every transfer
+	PercentBps int64 `mapstructure:"percent_bps"` // share of the amount in basis points, e.g: 50 is 0.5%
+}
+
+// Rule : the fee of the transfers in a currency, either a single flat and percentage fee or tiers
+// by amount. The result is kept within min and max, the amounts are in minor units
+type Rule struct {
+	Currency   string `mapstructure:"currency"`
+	Flat       int64  `mapstructure:"flat"`
+	PercentBps int64  `mapstructure:"percent_bps"`
+	Tiers      []Tier `mapstructure:"tiers"`
+	Min        int64  `mapstructure:"min"`
+	Max        int64  `mapstructure:"max"` // 0 means no cap
+}
+
+// Schedule : the fee rules of the transfers, the transfers in the currencies without a rule are free
+type Schedule struct {
+	rules map[string]Rule
+}
+
+// NewSchedule : creates a Schedule of the provided rules
+func NewSchedule(rules []Rule) (*Schedule, error) {
+	schedule := &Schedule{rules: make(map[string]Rule, len(rules))}
+	for _, rule := range rules {
+		if rule.Currency != AnyCurrency && !currencyCodeRegex.MatchString(rule.Currency) {
+			return nil, fmt.Errorf("invalid currency %q: must be 3 uppercase letters or %s", rule.Currency, AnyCurrency)
+		}
+
+		_, exists := schedule.rules[rule.Currency]
+		if exists {
+			return nil, fmt.Errorf("duplicate fee rule for %s", rule.Currency)
+		}
+
+		err := validateRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee rule for %s: %v", rule.Currency, err)
+		}
+
+		schedule.rules[rule.Currency] = rule
+	}
+
+	return schedule, nil
+}
+
+func validateRule(rule Rule) error {
+	if rule.Min < 0 || rule.Max < 0 {
+		return fmt.Errorf("min and max can't be negative")
+	}
+
+	if rule.Max > 0 && rule.Max < rule.Min {
+		return fmt.Errorf("max can't be less than min")
+	}
+
+	if len(rule.Tiers) == 0 {
+		return validateCharge(rule.Flat, rule.PercentBps)
+	}
+
+	if rule.Flat != 0 || rule.PercentBps != 0 {
+		return fmt.Errorf("flat and percent_bps are set on the tiers of a tiered rule")
+	}
+
+	for i, tier := range rule.Tiers {
+		err := validateCharge(tier.Flat, tier.PercentBps)
+		if err != nil {
+			return fmt.Errorf("tier %d: %v", i+1, err)
+		}
+
+		last := i == len(rule.Tiers)-1
+		switch {
+		case last && tier.UpTo != 0:
+			return fmt.Errorf("the last tier must not have an upper bound")
+		case !last && tier.UpTo <= 0:
+			return fmt.Errorf("tier %d: up_to must be positive", i+1)
+		case !last && i > 0 && tier.UpTo <= rule.Tiers[i-1].UpTo:
+			return fmt.Errorf("tier %d: up_to must be greater than the previous tier", i+1)
+		}
+	}
+
+	return nil
+}
+
+func validateCharge(flat int64, percentBps int64) error {
+	if flat < 0 {
+		return fmt.Errorf("flat can't be negative")
+	}
+
+	if percentBps < 0 || percentBps > maxPercentBps {
+		return fmt.Errorf("percent_bps must be between 0 and %d", maxPercentBps)
+	}
+
+	return nil
+}
+
+// NoFees : returns the schedule under which every transfer is free
+func NoFees() *Schedule {
+	return &Schedule{rules: map[string]Rule{}}
+}
+
+// LoadSchedule : reads the rules from a yaml file with a top level `fees` list,
+// no fees are charged if the path is empty
+func LoadSchedule(path string) (*Schedule, error) {
+	if path == "" {
+		return NoFees(), nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the fee schedule, err: %v", err)
+	}
+
+	var file struct {
+		Fees []Rule `mapstructure:"fees"`
+	}
+
+	err = v.Unmarshal(&file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the fee schedule, err: %v", err)
+	}
+
+	return NewSchedule(file.Fees)
+}
+
+// Fee : returns the fee charged on a transfer of the amount, in the currency of the amount.
+// The percentage is rounded half up to the minor unit
+func (schedule *Schedule) Fee(amount money.Money) money.Money {
+	rule, ok := schedule.rules[amount.Currency.Code]
+	if !ok {
+		rule, ok = schedule.rules[AnyCurrency]
+	}
+
+	if !ok || !amount.IsPositive() {
+		return money.New(0, amount.Currency)
+	}
+
+	flat, percentBps := rule.Flat, rule.PercentBps
+	for _, tier := range rule.Tiers {
+		if tier.UpTo == 0 || amount.Amount <= tier.UpTo {
+			flat, percentBps = tier.Flat, tier.PercentBps
+			break
+		}
+	}
+
+	// flat + amount * percent / 10000, computed with big integers so that large amounts can't overflow
+	fee := new(big.Int).Mul(big.NewInt(amount.Amount), big.NewInt(percentBps))
+	fee.Add(fee, big.NewInt(maxPercentBps/2))
+	fee.Quo(fee, big.NewInt(maxPercentBps))
+	fee.Add(fee, big.NewInt(flat))
+
+	if fee.Cmp(big.NewInt(rule.Min)) < 0 {
+		fee.SetInt64(rule.Min)
+	}
+
+	if rule.Max > 0 && fee.Cmp(big.NewInt(rule.Max)) > 0 {
+		fee.SetInt64(rule.Max)
+	}
+
+	// a fee which doesn't fit is more than the amount, such a transfer is rejected anyway
+	if !fee.IsInt64() {
+		return money.New(amount.Amount, amount.Currency)
+	}
+
+	return money.New(fee.Int64(), amount.Currency)
+}
@@ -0,0 +1,117 @@
+package fee
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usd = money.Currency{Code: "USD", MinorUnits: 2}
+	eur = money.Currency{Code: "EUR", MinorUnits: 2}
+	inr = money.Currency{Code: "INR", MinorUnits: 2}
+)
+
+func TestFee(t *testing.T) {
+	schedule, err := NewSchedule([]Rule{
+		{Currency: "USD", Flat: 25, PercentBps: 50, Min: 50, Max: 1000},
+		{Currency: "EUR", Tiers: []Tier{
+			{UpTo: 10000},
+			{UpTo: 500000, PercentBps: 100},
+			{Flat: 2000},
+		}},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		amount money.Money
+		fee    int64
+	}{
+		{name: "Flat And Percentage", amount: money.New(10000, usd), fee: 75},
+		{name: "Percentage Rounded Half Up", amount: money.New(10100, usd), fee: 76},
+		{name: "Min", amount: money.New(100, usd), fee: 50},
+		{name: "Max", amount: money.New(1000000, usd), fee: 1000},
+		{name: "First Tier", amount: money.New(10000, eur), fee: 0},
+		{name: "Second Tier", amount: money.New(10001, eur), fee: 100},
+		{name: "Last Tier", amount: money.New(500001, eur), fee: 2000},
+		{name: "Currency Without Rule", amount: money.New(10000, inr), fee: 0},
+		{name: "Zero Amount", amount: money.New(0, usd), fee: 0},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee := schedule.Fee(tc.amount)
+			require.Equal(t, tc.amount.Currency, fee.Currency)
+			require.Equal(t, tc.fee, fee.Amount)
+		})
+	}
+}
+
+func TestFeeAnyCurrency(t *testing.T) {
+	schedule, err := NewSchedule([]Rule{
+		{Currency: "USD", Flat: 10},
+		{Currency: AnyCurrency, PercentBps: 10000},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, int64(10), schedule.Fee(money.New(100, usd)).Amount)
+	require.Equal(t, int64(100), schedule.Fee(money.New(100, inr)).Amount)
+
+	// large amounts don't overflow
+	require.Equal(t, int64(1<<62), schedule.Fee(money.New(1<<62, inr)).Amount)
+
+	require.True(t, NoFees().Fee(money.New(100, usd)).IsZero())
+}
+
+func TestNewSchedule(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules []Rule
+	}{
+		{name: "Invalid Currency", rules: []Rule{{Currency: "usd"}}},
+		{name: "Duplicate Currency", rules: []Rule{{Currency: "USD"}, {Currency: "USD"}}},
+		{name: "Negative Flat", rules: []Rule{{Currency: "USD", Flat: -1}}},
+		{name: "Percentage Over 100%", rules: []Rule{{Currency: "USD", PercentBps: 10001}}},
+		{name: "Max Less Than Min", rules: []Rule{{Currency: "USD", Min: 10, Max: 5}}},
+		{name: "Tiers With Flat", rules: []Rule{{Currency: "USD", Flat: 1, Tiers: []Tier{{}}}}},
+		{name: "Bounded Last Tier", rules: []Rule{{Currency: "USD", Tiers: []Tier{{UpTo: 100}}}}},
+		{name: "Unbounded Tier", rules: []Rule{{Currency: "USD", Tiers: []Tier{{}, {}}}}},
+		{name: "Unordered Tiers", rules: []Rule{{Currency: "USD", Tiers: []Tier{{UpTo: 100}, {UpTo: 100}, {}}}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSchedule(tc.rules)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadSchedule(t *testing.T) {
+	schedule, err := LoadSchedule("../config/fees.yaml")
+	require.NoError(t, err)
+	require.Equal(t, int64(75), schedule.Fee(money.New(10000, usd)).Amount)
+	require.Equal(t, int64(100), schedule.Fee(money.New(10001, eur)).Amount)
+
+	schedule, err = LoadSchedule("")
+	require.NoError(t, err)
+	require.True(t, schedule.Fee(money.New(10000, usd)).IsZero())
+
+	_, err = LoadSchedule(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	err = os.WriteFile(path, []byte("fees:\n  - currency: USD\n    flat: -1\n"), 0600)
+	require.NoError(t, err)
+
+	_, err = LoadSchedule(path)
+	require.Error(t, err)
+}
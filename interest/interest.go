@@ -0,0 +1,259 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/skamranahmed/banking-system/accountnumber"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+)
+
+// names of the jobs recorded in `job_runs`, a job runs at most once per period
+const (
+	AccrualJob = "interest_accrual"
+	PostingJob = "interest_posting"
+
+	accrualPeriodLayout = "2006-01-02"
+	postingPeriodLayout = "2006-01"
+
+	// daysInYear : the daily rate is the annual rate divided by a fixed 365 days
+	daysInYear = 365
+
+	batchSize = 100
+)
+
+// ErrAlreadyCompleted : the job has already been completed for the period
+var ErrAlreadyCompleted = errors.New("job already completed for the period")
+
+// Rates : annual interest rates in basis points per account type, the types without a rate don't earn interest
+type Rates map[string]int
+
+// Engine : accrues the daily interest of the accounts and posts it monthly
+type Engine struct {
+	store db.Store
+	rates Rates
+}
+
+// NewEngine : creates a new Engine
+func NewEngine(store db.Store, rates Rates) *Engine {
+	return &Engine{
+		store: store,
+		rates: rates,
+	}
+}
+
+// AccrualReport : the outcome of an accrual run
+type AccrualReport struct {
+	Period   string
+	Accounts int // accounts which earned interest for the day
+	Skipped  int // accounts without a positive balance or accrued by an earlier, interrupted run
+}
+
+// PostingReport : the outcome of a posting run
+type PostingReport struct {
+	Period   string
+	Accounts int              // accounts which have been credited
+	Totals   map[string]int64 // the credited minor units per currency
+}
+
+// DailyInterestMicros : returns the interest earned in a day on the balance at the annual rate,
+// in millionths of the minor unit rounded down
+func DailyInterestMicros(balance int64, rateBps int) int64 {
+	if balance <= 0 || rateBps <= 0 {
+		return 0
+	}
+
+	// balance * rate / 10000 / 365 * 1000000, computed with big integers so that large balances can't overflow
+	micros := new(big.Int).Mul(big.NewInt(balance), big.NewInt(int64(rateBps)))
+	micros.Mul(micros, big.NewInt(100))
+	micros.Quo(micros, big.NewInt(daysInYear))
+	if !micros.IsInt64() {
+		return 0
+	}
+	return micros.Int64()
+}
+
+// Accrue : computes the interest of the day on the end of day balances of the accounts, the day is in UTC.
+// The accruals are keyed by account and day, so an interrupted run can be resumed safely
+func (engine *Engine) Accrue(ctx context.Context, day time.Time) (AccrualReport, error) {
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	report := AccrualReport{Period: dayStart.Format(accrualPeriodLayout)}
+
+	types := engine.accountTypes()
+	if len(types) == 0 {
+		return report, nil
+	}
+
+	err := engine.runJob(ctx, AccrualJob, report.Period, func() error {
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		var afterID int64
+		for {
+			balances, err := engine.store.ListEndOfDayBalances(ctx, db.ListEndOfDayBalancesParams{
+				DayEnd:  dayEnd,
+				Types:   types,
+				AfterID: afterID,
+				Limit:   batchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, balance := range balances {
+				afterID = balance.ID
+
+				rate := engine.rates[balance.Type]
+				amount := DailyInterestMicros(balance.Balance, rate)
+				if amount == 0 {
+					report.Skipped++
+					continue
+				}
+
+				_, err = engine.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+					AccountID:    balance.ID,
+					AccrualDate:  dayStart,
+					Balance:      balance.Balance,
+					RateBps:      int32(rate),
+					AmountMicros: amount,
+				})
+				if err != nil {
+					if err == sql.ErrNoRows {
+						report.Skipped++
+						continue
+					}
+					return fmt.Errorf("unable to accrue the interest of account %d, err: %v", balance.ID, err)
+				}
+				report.Accounts++
+			}
+
+			if len(balances) < batchSize {
+				return nil
+			}
+		}
+	})
+
+	return report, err
+}
+
+// Post : credits the interest accrued during the month to the accounts, the month is in UTC. Each account
+// is credited in its own transaction from the interest expense account of its currency
+func (engine *Engine) Post(ctx context.Context, month time.Time) (PostingReport, error) {
+	fromDate := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	toDate := fromDate.AddDate(0, 1, 0)
+	report := PostingReport{
+		Period: fromDate.Format(postingPeriodLayout),
+		Totals: make(map[string]int64),
+	}
+
+	err := engine.runJob(ctx, PostingJob, report.Period, func() error {
+		expenseAccounts := make(map[string]int64)
+
+		var afterID int64
+		for {
+			accountIDs, err := engine.store.ListAccountIDsWithUnpostedInterest(ctx, db.ListAccountIDsWithUnpostedInterestParams{
+				FromDate: fromDate,
+				ToDate:   toDate,
+				AfterID:  afterID,
+				Limit:    batchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, accountID := range accountIDs {
+				afterID = accountID
+
+				account, err := engine.store.GetAccount(ctx, accountID)
+				if err != nil {
+					return err
+				}
+
+				expenseAccountID, ok := expenseAccounts[account.Currency]
+				if !ok {
+					expenseAccountID, err = engine.expenseAccount(ctx, account.Currency)
+					if err != nil {
+						return fmt.Errorf("unable to get the interest expense account of %s, err: %v", account.Currency, err)
+					}
+					expenseAccounts[account.Currency] = expenseAccountID
+				}
+
+				result, err := engine.store.PostInterestTxn(ctx, db.PostInterestTxnParams{
+					AccountID:        accountID,
+					ExpenseAccountID: expenseAccountID,
+					FromDate:         fromDate,
+					ToDate:           toDate,
+				})
+				if err != nil {
+					return fmt.Errorf("unable to post the interest of account %d, err: %v", accountID, err)
+				}
+
+				if result.Amount > 0 {
+					report.Accounts++
+					report.Totals[account.Currency] += result.Amount
+				}
+			}
+
+			if len(accountIDs) < batchSize {
+				return nil
+			}
+		}
+	})
+
+	return report, err
+}
+
+// expenseAccount : returns the ID of the interest expense account of the currency
+func (engine *Engine) expenseAccount(ctx context.Context, currency string) (int64, error) {
+	accountNumber, err := accountnumber.Generate()
+	if err != nil {
+		return 0, err
+	}
+
+	account, err := engine.store.SystemAccountTxn(ctx, db.SystemAccountTxnParams{
+		Purpose:       db.SystemAccountInterestExpense,
+		Currency:      currency,
+		AccountNumber: accountNumber,
+	})
+	return account.ID, err
+}
+
+// runJob : runs the job unless it has already been completed for the period, a run which
+// failed midway is retried from the start and relies on the job being idempotent
+func (engine *Engine) runJob(ctx context.Context, job string, period string, fn func() error) error {
+	run, err := engine.store.StartJobRun(ctx, db.StartJobRunParams{
+		Job:    job,
+		Period: period,
+	})
+	if err != nil {
+		return err
+	}
+
+	if run.CompletedAt.Valid {
+		return ErrAlreadyCompleted
+	}
+
+	err = fn()
+	if err != nil {
+		return err
+	}
+
+	_, err = engine.store.CompleteJobRun(ctx, run.ID)
+	return err
+}
+
+// accountTypes : returns the account types which earn interest
+func (engine *Engine) accountTypes() []string {
+	types := make([]string, 0, len(engine.rates))
+	for accountType, rate := range engine.rates {
+		if rate > 0 {
+			types = append(types, accountType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
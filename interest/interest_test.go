@@ -0,0 +1,245 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var testRates = Rates{
+	"checking": 0,
+	"savings":  200,
+}
+
+func TestDailyInterestMicros(t *testing.T) {
+	testCases := []struct {
+		balance int64
+		rateBps int
+		micros  int64
+	}{
+		{balance: 36500, rateBps: 100, micros: 1000000},
+		{balance: 100000, rateBps: 200, micros: 5479452},
+		{balance: 1, rateBps: 1, micros: 0},
+		{balance: 0, rateBps: 200, micros: 0},
+		{balance: -100000, rateBps: 200, micros: 0},
+		{balance: 100000, rateBps: 0, micros: 0},
+		{balance: 1 << 62, rateBps: 10000, micros: 0}, // doesn't fit in micros
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.micros, DailyInterestMicros(tc.balance, tc.rateBps), "balance %d, rate %d", tc.balance, tc.rateBps)
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	day := time.Date(2022, time.June, 15, 18, 30, 0, 0, time.UTC)
+	dayStart := time.Date(2022, time.June, 15, 0, 0, 0, 0, time.UTC)
+	run := db.JobRun{ID: 1, Job: AccrualJob, Period: "2022-06-15"}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, report AccrualReport, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StartJobRun(gomock.Any(), gomock.Eq(db.StartJobRunParams{Job: AccrualJob, Period: "2022-06-15"})).
+					Times(1).
+					Return(run, nil)
+				store.EXPECT().
+					ListEndOfDayBalances(gomock.Any(), gomock.Eq(db.ListEndOfDayBalancesParams{
+						DayEnd:  dayStart.AddDate(0, 0, 1),
+						Types:   []string{"savings"},
+						AfterID: 0,
+						Limit:   batchSize,
+					})).
+					Times(1).
+					Return([]db.ListEndOfDayBalancesRow{
+						{ID: 1, Type: "savings", Currency: "USD", Balance: 36500},
+						{ID: 2, Type: "savings", Currency: "USD", Balance: 0},
+						{ID: 3, Type: "savings", Currency: "USD", Balance: 73000},
+					}, nil)
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:    1,
+						AccrualDate:  dayStart,
+						Balance:      36500,
+						RateBps:      200,
+						AmountMicros: 2000000,
+					})).
+					Times(1).
+					Return(db.InterestAccrual{ID: 1}, nil)
+				// accrued by an earlier run which has been interrupted
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:    3,
+						AccrualDate:  dayStart,
+						Balance:      73000,
+						RateBps:      200,
+						AmountMicros: 4000000,
+					})).
+					Times(1).
+					Return(db.InterestAccrual{}, sql.ErrNoRows)
+				store.EXPECT().
+					CompleteJobRun(gomock.Any(), gomock.Eq(run.ID)).
+					Times(1).
+					Return(run, nil)
+			},
+			checkResponse: func(t *testing.T, report AccrualReport, err error) {
+				require.NoError(t, err)
+				require.Equal(t, AccrualReport{Period: "2022-06-15", Accounts: 1, Skipped: 2}, report)
+			},
+		},
+		{
+			name: "AlreadyCompleted",
+			buildStubs: func(store *mockdb.MockStore) {
+				completed := run
+				completed.CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					StartJobRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(completed, nil)
+				store.EXPECT().
+					ListEndOfDayBalances(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CompleteJobRun(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, report AccrualReport, err error) {
+				require.ErrorIs(t, err, ErrAlreadyCompleted)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StartJobRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(run, nil)
+				store.EXPECT().
+					ListEndOfDayBalances(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					CompleteJobRun(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, report AccrualReport, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			report, err := NewEngine(store, testRates).Accrue(context.Background(), day)
+			tc.checkResponse(t, report, err)
+		})
+	}
+}
+
+func TestAccrueWithoutRates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// nothing earns interest, so the job isn't recorded
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		StartJobRun(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	report, err := NewEngine(store, Rates{"checking": 0}).Accrue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Zero(t, report.Accounts)
+}
+
+func TestPost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fromDate := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(2022, time.July, 1, 0, 0, 0, 0, time.UTC)
+	run := db.JobRun{ID: 1, Job: PostingJob, Period: "2022-06"}
+	expenseAccount := db.Account{ID: 100, Currency: "USD"}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		StartJobRun(gomock.Any(), gomock.Eq(db.StartJobRunParams{Job: PostingJob, Period: "2022-06"})).
+		Times(1).
+		Return(run, nil)
+	store.EXPECT().
+		ListAccountIDsWithUnpostedInterest(gomock.Any(), gomock.Eq(db.ListAccountIDsWithUnpostedInterestParams{
+			FromDate: fromDate,
+			ToDate:   toDate,
+			AfterID:  0,
+			Limit:    batchSize,
+		})).
+		Times(1).
+		Return([]int64{1, 2}, nil)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(int64(1))).
+		Times(1).
+		Return(db.Account{ID: 1, Currency: "USD"}, nil)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(int64(2))).
+		Times(1).
+		Return(db.Account{ID: 2, Currency: "USD"}, nil)
+
+	// the expense account is looked up once per currency
+	store.EXPECT().
+		SystemAccountTxn(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.SystemAccountTxnParams) (db.Account, error) {
+			require.Equal(t, db.SystemAccountInterestExpense, arg.Purpose)
+			require.Equal(t, "USD", arg.Currency)
+			require.NotEmpty(t, arg.AccountNumber)
+			return expenseAccount, nil
+		})
+
+	store.EXPECT().
+		PostInterestTxn(gomock.Any(), gomock.Eq(db.PostInterestTxnParams{
+			AccountID:        1,
+			ExpenseAccountID: expenseAccount.ID,
+			FromDate:         fromDate,
+			ToDate:           toDate,
+		})).
+		Times(1).
+		Return(db.PostInterestTxnResult{Amount: 12, Accruals: 30}, nil)
+	// the accruals rounded to zero
+	store.EXPECT().
+		PostInterestTxn(gomock.Any(), gomock.Eq(db.PostInterestTxnParams{
+			AccountID:        2,
+			ExpenseAccountID: expenseAccount.ID,
+			FromDate:         fromDate,
+			ToDate:           toDate,
+		})).
+		Times(1).
+		Return(db.PostInterestTxnResult{Amount: 0, Accruals: 30}, nil)
+	store.EXPECT().
+		CompleteJobRun(gomock.Any(), gomock.Eq(run.ID)).
+		Times(1).
+		Return(run, nil)
+
+	report, err := NewEngine(store, testRates).Post(context.Background(), time.Date(2022, time.June, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "2022-06", report.Period)
+	require.Equal(t, 1, report.Accounts)
+	require.Equal(t, map[string]int64{"USD": 12}, report.Totals)
+}
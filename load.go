@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skamranahmed/banking-system/client"
+	"github.com/skamranahmed/banking-system/config"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/loadtest"
+	"github.com/skamranahmed/banking-system/money"
+)
+
+const loadUsage = `usage: main load -accounts FIRST-LAST -currency CODE [-target store|http] [-concurrency N] [-duration 30s]
+                 [-hotness S] [-amount AMOUNT] [-seed N] [-server URL -password PASSWORD]
+the accounts of the range in the currency take part, the system and the frozen ones excluded.
+-target http logs in every owner with the shared -password, e.g: the one of the seed command`
+
+// maxLoadErrors : the number of distinct errors printed by the load command
+const maxLoadErrors = 5
+
+// runLoadCommand : handles `load`, hammers the transfers between a range of accounts and verifies the
+// ledger afterward. Nothing else must move the money of the accounts meanwhile
+func runLoadCommand(conn *sql.DB, args []string) error {
+	flags := flag.NewFlagSet("load", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	targetName := flags.String("target", "store", "store to call TransferTxn, http to call POST /transfers")
+	accountRange := flags.String("accounts", "", "range of the account ids, e.g: 1-200")
+	currencyCode := flags.String("currency", "", "currency of the accounts")
+	concurrency := flags.Int("concurrency", 16, "transfers in flight at any time")
+	duration := flags.Duration("duration", 30*time.Second, "how long the transfers are made")
+	hotness := flags.Float64("hotness", 0, "exponent of the Zipf distribution of the accounts, 0 for uniform, otherwise greater than 1")
+	amount := flags.String("amount", "0.01", "decimal amount of every transfer")
+	seedValue := flags.Int64("seed", 1, "seed of the picks of the accounts")
+	server := flags.String("server", fmt.Sprintf("http://localhost:%s", config.ServerPort), "base URL of the API for -target http")
+	password := flags.String("password", "", "password of the owners for -target http")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *accountRange == "" || *currencyCode == "" {
+		return errors.New(loadUsage)
+	}
+
+	first, last, err := parseAccountRange(*accountRange)
+	if err != nil {
+		return fmt.Errorf("%v, %s", err, loadUsage)
+	}
+
+	currencies, err := money.LoadRegistry(config.CurrenciesFile)
+	if err != nil {
+		return err
+	}
+
+	currency, ok := currencies.Lookup(strings.ToUpper(*currencyCode))
+	if !ok {
+		return fmt.Errorf("unsupported currency %q, %s", *currencyCode, loadUsage)
+	}
+
+	transferAmount, err := money.Parse(*amount, currency)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q: %v", *amount, err)
+	}
+
+	ctx := context.Background()
+	store := db.NewStore(conn)
+
+	accounts, err := loadAccounts(ctx, store, first, last, currency.Code)
+	if err != nil {
+		return err
+	}
+
+	var target loadtest.Target
+	switch *targetName {
+	case "store":
+		target = loadtest.NewStoreTarget(store)
+	case "http":
+		if *password == "" {
+			return fmt.Errorf("-password is required, %s", loadUsage)
+		}
+		target, err = loadtest.NewHTTPTarget(ctx, store, client.New(*server, nil), currency, accounts, *password)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown target %q, %s", *targetName, loadUsage)
+	}
+
+	fmt.Printf("making %s transfers of %s between %d account(s) with %d goroutine(s) for %s\n",
+		*targetName, money.New(transferAmount.Amount, currency), len(accounts), *concurrency, *duration)
+
+	report, err := loadtest.Run(ctx, store, target, accounts, loadtest.Config{
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Amount:      transferAmount.Amount,
+		Seed:        *seedValue,
+		Hotness:     *hotness,
+		NoOverdraft: *targetName == "http",
+	})
+	if err != nil {
+		return err
+	}
+
+	printLoadReport(report)
+
+	if len(report.Violations) > 0 {
+		return fmt.Errorf("%d ledger invariant(s) broken", len(report.Violations))
+	}
+	return nil
+}
+
+// parseAccountRange : parses a range of account ids, e.g: 1-200
+func parseAccountRange(value string) (int64, int64, error) {
+	firstValue, lastValue, found := strings.Cut(value, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid account range %q", value)
+	}
+
+	first, err := strconv.ParseInt(firstValue, 10, 64)
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid account range %q", value)
+	}
+
+	last, err := strconv.ParseInt(lastValue, 10, 64)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid account range %q", value)
+	}
+
+	return first, last, nil
+}
+
+// loadAccounts : returns the accounts of the range which can take part in the load test
+func loadAccounts(ctx context.Context, store db.Store, first int64, last int64, currency string) ([]db.Account, error) {
+	systemUser, err := store.GetUserByUsername(ctx, db.SystemUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []db.Account
+	for id := first; id <= last; id++ {
+		account, err := store.GetAccount(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, err
+		}
+
+		if account.Currency != currency || account.UserID == systemUser.ID || account.IsFrozen {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// printLoadReport : prints the throughput, the latencies, the most frequent errors and the broken invariants
+func printLoadReport(report loadtest.Report) {
+	fmt.Printf("%d succeeded, %d failed and %d unknown in %s, %.1f transfers/s\n",
+		report.Succeeded, report.Failed, report.Unknown, report.Elapsed.Round(time.Millisecond), report.Throughput)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "P50\tP90\tP99\tMAX")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", report.P50, report.P90, report.P99, report.Max)
+	w.Flush()
+
+	messages := make([]string, 0, len(report.Errors))
+	for message := range report.Errors {
+		messages = append(messages, message)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return report.Errors[messages[i]] > report.Errors[messages[j]]
+	})
+	if len(messages) > maxLoadErrors {
+		messages = messages[:maxLoadErrors]
+	}
+	for _, message := range messages {
+		fmt.Printf("  %d x %s\n", report.Errors[message], message)
+	}
+
+	if len(report.Violations) == 0 {
+		fmt.Println("ledger invariants hold")
+		return
+	}
+	for _, violation := range report.Violations {
+		fmt.Printf("  violation: %s\n", violation)
+	}
+}
@@ -0,0 +1,321 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+)
+
+// entriesPageSize : the entries are summed page by page when the ledger is verified
+const entriesPageSize = 1000
+
+var (
+	ErrInvalidConfig = errors.New("the concurrency, the duration and the amount must be positive and the hotness 0 or greater than 1")
+
+	ErrNotEnoughAccounts = errors.New("at least two accounts are required")
+)
+
+// Config : how hard the accounts are hammered
+type Config struct {
+	Concurrency int           // transfers in flight at any time
+	Duration    time.Duration // no transfer is started after it, the ones in flight are completed
+	Amount      int64         // of every transfer, in minor units
+	Seed        int64         // of the picks of the accounts
+
+	// Hotness : the exponent of the Zipf distribution the accounts are picked with, 0 picks them
+	// uniformly. The higher it is, the more the transfers contend for the same few accounts
+	Hotness float64
+
+	// NoOverdraft : the target rejects the transfers which would overdraw an account, e.g: the API,
+	// so a negative balance breaks the invariants
+	NoOverdraft bool
+}
+
+// Report : the outcome of a load test
+type Report struct {
+	Elapsed    time.Duration
+	Succeeded  int
+	Failed     int
+	Errors     map[string]int // the number of failed and unknown transfers per error
+	Throughput float64        // succeeded transfers per second
+
+	// Unknown : the transfers which failed with ErrUnknownOutcome, e.g: timed out, they might have been
+	// committed so the balances of their accounts are only checked to be within their amounts
+	Unknown int
+
+	// the latencies of all the transfers, failed ones included
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+
+	// Violations : the broken ledger invariants, empty if the ledger is consistent
+	Violations []string
+}
+
+// worker : the outcome of the transfers made by a single goroutine, merged into the report at the end
+type worker struct {
+	latencies []time.Duration
+	deltas    map[int64]int64 // the expected change of the balance per account id
+	fees      int64
+	succeeded int
+	errors    map[string]int
+	unknown   int
+	unsettled unsettled
+}
+
+// unsettled : the transfers with an unknown outcome, which may or may not have moved the money
+type unsettled struct {
+	debits  map[int64]int64 // the most the balance may have been debited per account id
+	credits map[int64]int64 // the most the balance may have been credited per account id
+	fees    int64           // the most the bank may have been credited in fees
+}
+
+func newUnsettled() unsettled {
+	return unsettled{debits: make(map[int64]int64), credits: make(map[int64]int64)}
+}
+
+// Run : makes transfers between the accounts with the target for the duration of the config and then
+// verifies the ledger with the store:
+//   - the balance of every account changed by exactly the amounts of its succeeded transfers, i.e. no update was lost,
+//     give or take the amounts of its transfers with an unknown outcome
+//   - the balances of the accounts add up to the same total as before, less the fees credited to the bank
+//     and at most the amounts of the transfers with an unknown outcome
+//   - the entries of every account add up to its balance
+//   - no balance is negative if the target doesn't allow overdrafts
+//
+// The accounts must all have the same currency and nothing else must move their money during the test
+func Run(ctx context.Context, store db.Store, target Target, accounts []db.Account, config Config) (Report, error) {
+	report := Report{Errors: make(map[string]int)}
+
+	if config.Concurrency <= 0 || config.Duration <= 0 || config.Amount <= 0 || config.Hotness < 0 || (config.Hotness > 0 && config.Hotness <= 1) {
+		return report, ErrInvalidConfig
+	}
+	if len(accounts) < 2 {
+		return report, ErrNotEnoughAccounts
+	}
+
+	// the balances are read again so that the expected ones start from the latest state
+	before := make([]db.Account, len(accounts))
+	for i, account := range accounts {
+		var err error
+		before[i], err = store.GetAccount(ctx, account.ID)
+		if err != nil {
+			return report, fmt.Errorf("account %d: %w", account.ID, err)
+		}
+	}
+
+	// the hot accounts are picked at random, otherwise they would be the first ones of the list
+	rng := rand.New(rand.NewSource(config.Seed))
+	hotOrder := make([]db.Account, len(before))
+	for i, j := range rng.Perm(len(before)) {
+		hotOrder[i] = before[j]
+	}
+
+	workers := make([]*worker, config.Concurrency)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	deadline := start.Add(config.Duration)
+
+	for i := range workers {
+		workers[i] = &worker{deltas: make(map[int64]int64), errors: make(map[string]int), unsettled: newUnsettled()}
+		picker := newPicker(hotOrder, config.Hotness, config.Seed+int64(i)+1)
+
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+
+			// the transfers aren't cancelled at the deadline, a cancelled transfer might have been
+			// committed and the expected balances wouldn't be known
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				from, to := picker.pair()
+
+				transferStart := time.Now()
+				fee, err := target.Transfer(ctx, from, to, config.Amount)
+				w.latencies = append(w.latencies, time.Since(transferStart))
+
+				if errors.Is(err, ErrUnknownOutcome) {
+					w.errors[err.Error()]++
+					w.unknown++
+					w.unsettled.debits[from.ID] += config.Amount
+					w.unsettled.credits[to.ID] += config.Amount
+					w.unsettled.fees += config.Amount
+					continue
+				}
+				if err != nil {
+					w.errors[err.Error()]++
+					continue
+				}
+
+				w.deltas[from.ID] -= config.Amount
+				w.deltas[to.ID] += config.Amount - fee
+				w.fees += fee
+				w.succeeded++
+			}
+		}(workers[i])
+	}
+
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+
+	deltas := make(map[int64]int64)
+	pending := newUnsettled()
+	var latencies []time.Duration
+	var fees int64
+	for _, w := range workers {
+		latencies = append(latencies, w.latencies...)
+		for id, delta := range w.deltas {
+			deltas[id] += delta
+		}
+		for id, amount := range w.unsettled.debits {
+			pending.debits[id] += amount
+		}
+		for id, amount := range w.unsettled.credits {
+			pending.credits[id] += amount
+		}
+		pending.fees += w.unsettled.fees
+		for message, count := range w.errors {
+			report.Errors[message] += count
+			report.Failed += count
+		}
+		fees += w.fees
+		report.Succeeded += w.succeeded
+		report.Failed -= w.unknown
+		report.Unknown += w.unknown
+	}
+
+	report.Throughput = float64(report.Succeeded) / report.Elapsed.Seconds()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = Percentile(latencies, 50)
+	report.P90 = Percentile(latencies, 90)
+	report.P99 = Percentile(latencies, 99)
+	report.Max = Percentile(latencies, 100)
+
+	var err error
+	report.Violations, err = verify(ctx, store, before, deltas, fees, pending, config.NoOverdraft)
+	return report, err
+}
+
+// verify : checks the ledger invariants of the accounts after the load test, see Run
+func verify(ctx context.Context, store db.Store, before []db.Account, deltas map[int64]int64, fees int64, pending unsettled, noOverdraft bool) ([]string, error) {
+	var violations []string
+	var totalBefore, totalAfter int64
+
+	for _, account := range before {
+		after, err := store.GetAccount(ctx, account.ID)
+		if err != nil {
+			return violations, fmt.Errorf("account %d: %w", account.ID, err)
+		}
+
+		totalBefore += account.Balance
+		totalAfter += after.Balance
+
+		expected := account.Balance + deltas[account.ID]
+		lowest, highest := expected-pending.debits[account.ID], expected+pending.credits[account.ID]
+		if lowest == highest && after.Balance != expected {
+			violations = append(violations, fmt.Sprintf("account %d: balance is %d, expected %d", account.ID, after.Balance, expected))
+		} else if after.Balance < lowest || after.Balance > highest {
+			violations = append(violations, fmt.Sprintf("account %d: balance is %d, expected between %d and %d", account.ID, after.Balance, lowest, highest))
+		}
+
+		if noOverdraft && after.Balance < 0 {
+			violations = append(violations, fmt.Sprintf("account %d: balance %d is negative", account.ID, after.Balance))
+		}
+
+		entries, err := sumEntries(ctx, store, account.ID)
+		if err != nil {
+			return violations, fmt.Errorf("entries of account %d: %w", account.ID, err)
+		}
+		if entries != after.Balance {
+			violations = append(violations, fmt.Sprintf("account %d: entries add up to %d, balance is %d", account.ID, entries, after.Balance))
+		}
+	}
+
+	if pending.fees == 0 && totalAfter != totalBefore-fees {
+		violations = append(violations, fmt.Sprintf("balances add up to %d, expected %d less %d of fees", totalAfter, totalBefore, fees))
+	} else if totalAfter > totalBefore-fees || totalAfter < totalBefore-fees-pending.fees {
+		violations = append(violations, fmt.Sprintf("balances add up to %d, expected %d less %d of fees and at most %d of unknown ones", totalAfter, totalBefore, fees, pending.fees))
+	}
+
+	return violations, nil
+}
+
+// sumEntries : returns the sum of all the entries of the account
+func sumEntries(ctx context.Context, store db.Store, accountID int64) (int64, error) {
+	var sum int64
+	for offset := int32(0); ; offset += entriesPageSize {
+		entries, err := store.ListEntries(ctx, db.ListEntriesParams{
+			AccountID: accountID,
+			Limit:     entriesPageSize,
+			Offset:    offset,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			sum += entry.Amount
+		}
+		if len(entries) < entriesPageSize {
+			return sum, nil
+		}
+	}
+}
+
+// Percentile : returns the nearest-rank percentile of the sorted latencies, 0 if there are none
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// picker : picks the accounts of the transfers of a worker, a rand.Rand can't be shared between goroutines
+type picker struct {
+	accounts []db.Account
+	rng      *rand.Rand
+	zipf     *rand.Zipf // nil if the accounts are picked uniformly
+}
+
+func newPicker(accounts []db.Account, hotness float64, seed int64) *picker {
+	p := &picker{accounts: accounts, rng: rand.New(rand.NewSource(seed))}
+	if hotness > 0 {
+		p.zipf = rand.NewZipf(p.rng, hotness, 1, uint64(len(accounts)-1))
+	}
+	return p
+}
+
+func (p *picker) pick() db.Account {
+	if p.zipf == nil {
+		return p.accounts[p.rng.Intn(len(p.accounts))]
+	}
+	return p.accounts[p.zipf.Uint64()]
+}
+
+// pair : returns two different accounts
+func (p *picker) pair() (db.Account, db.Account) {
+	from := p.pick()
+	for {
+		to := p.pick()
+		if to.ID != from.ID {
+			return from, to
+		}
+	}
+}
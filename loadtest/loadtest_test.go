@@ -0,0 +1,226 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLedger : the accounts and the entries moved by the fake target, served to the mock store
+type fakeLedger struct {
+	mu       sync.Mutex
+	balances map[int64]int64
+	entries  map[int64][]db.Entry
+
+	fee         int64 // charged on every transfer
+	lostEach    int   // every n-th credit is lost, to break the invariants
+	unknownEach int   // every n-th transfer returns ErrUnknownOutcome, every other one of them is committed
+	calls       int
+}
+
+func newFakeLedger(accounts []db.Account) *fakeLedger {
+	ledger := &fakeLedger{balances: make(map[int64]int64), entries: make(map[int64][]db.Entry)}
+	for _, account := range accounts {
+		ledger.balances[account.ID] = account.Balance
+		ledger.entries[account.ID] = []db.Entry{{AccountID: account.ID, Amount: account.Balance}}
+	}
+	return ledger
+}
+
+func (ledger *fakeLedger) Transfer(ctx context.Context, from db.Account, to db.Account, amount int64) (fee int64, err error) {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	ledger.calls++
+	if ledger.calls%7 == 0 || ledger.balances[from.ID] < amount {
+		return 0, errors.New("insufficient balance")
+	}
+
+	if ledger.unknownEach != 0 && ledger.calls%ledger.unknownEach == 0 {
+		if ledger.calls%(2*ledger.unknownEach) == 0 {
+			return 0, ErrUnknownOutcome
+		}
+		defer func() { err = fmt.Errorf("%w: timeout", ErrUnknownOutcome) }()
+	}
+
+	ledger.balances[from.ID] -= amount
+	ledger.entries[from.ID] = append(ledger.entries[from.ID], db.Entry{AccountID: from.ID, Amount: -amount})
+	ledger.entries[to.ID] = append(ledger.entries[to.ID], db.Entry{AccountID: to.ID, Amount: amount - ledger.fee})
+	if ledger.lostEach == 0 || ledger.calls%ledger.lostEach != 0 {
+		ledger.balances[to.ID] += amount - ledger.fee
+	}
+	return ledger.fee, nil
+}
+
+// buildStore : serves the accounts and the entries of the ledger
+func (ledger *fakeLedger) buildStore(store *mockdb.MockStore, accounts []db.Account) {
+	byID := make(map[int64]db.Account)
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, id int64) (db.Account, error) {
+			ledger.mu.Lock()
+			defer ledger.mu.Unlock()
+
+			account := byID[id]
+			account.Balance = ledger.balances[id]
+			return account, nil
+		})
+	store.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+			ledger.mu.Lock()
+			defer ledger.mu.Unlock()
+
+			entries := ledger.entries[arg.AccountID]
+			if int(arg.Offset) >= len(entries) {
+				return nil, nil
+			}
+			entries = entries[arg.Offset:]
+			if len(entries) > int(arg.Limit) {
+				entries = entries[:arg.Limit]
+			}
+			return entries, nil
+		})
+}
+
+func testAccounts(n int) []db.Account {
+	accounts := make([]db.Account, n)
+	for i := range accounts {
+		accounts[i] = db.Account{ID: int64(i + 1), UserID: int64(i + 1), Balance: 100000, Currency: "INR"}
+	}
+	return accounts
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        Config
+		ledger        func(ledger *fakeLedger)
+		checkResponse func(t *testing.T, report Report, err error)
+	}{
+		{
+			name:   "Happy Case - Uniform",
+			config: Config{Concurrency: 4, Duration: 50 * time.Millisecond, Amount: 10},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Positive(t, report.Succeeded)
+				require.Positive(t, report.Failed)
+				require.Equal(t, report.Failed, report.Errors["insufficient balance"])
+				require.Positive(t, report.Throughput)
+				require.LessOrEqual(t, report.P50, report.P90)
+				require.LessOrEqual(t, report.P90, report.P99)
+				require.LessOrEqual(t, report.P99, report.Max)
+				require.Empty(t, report.Violations)
+			},
+		},
+		{
+			name:   "Happy Case - Hot Accounts With Fees",
+			config: Config{Concurrency: 8, Duration: 50 * time.Millisecond, Amount: 10, Hotness: 1.5, NoOverdraft: true},
+			ledger: func(ledger *fakeLedger) {
+				ledger.fee = 1
+			},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Positive(t, report.Succeeded)
+				require.Empty(t, report.Violations)
+			},
+		},
+		{
+			name:   "Happy Case - Unknown Outcomes",
+			config: Config{Concurrency: 4, Duration: 50 * time.Millisecond, Amount: 10, NoOverdraft: true},
+			ledger: func(ledger *fakeLedger) {
+				ledger.fee = 1
+				ledger.unknownEach = 5
+			},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Positive(t, report.Unknown)
+				require.Equal(t, report.Failed, report.Errors["insufficient balance"])
+				require.Equal(t, report.Unknown, report.Errors[ErrUnknownOutcome.Error()]+report.Errors[ErrUnknownOutcome.Error()+": timeout"])
+				require.Empty(t, report.Violations)
+			},
+		},
+		{
+			name:   "Failure Case - Lost Updates",
+			config: Config{Concurrency: 4, Duration: 50 * time.Millisecond, Amount: 10},
+			ledger: func(ledger *fakeLedger) {
+				ledger.lostEach = 5
+			},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, report.Violations)
+				require.Contains(t, report.Violations[len(report.Violations)-1], "balances add up to")
+			},
+		},
+		{
+			name:   "Failure Case - Invalid Hotness",
+			config: Config{Concurrency: 4, Duration: time.Second, Amount: 10, Hotness: 0.5},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, ErrInvalidConfig)
+			},
+		},
+		{
+			name:   "Failure Case - No Concurrency",
+			config: Config{Duration: time.Second, Amount: 10},
+			checkResponse: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, ErrInvalidConfig)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := testAccounts(10)
+			ledger := newFakeLedger(accounts)
+			if tc.ledger != nil {
+				tc.ledger(ledger)
+			}
+
+			store := mockdb.NewMockStore(ctrl)
+			ledger.buildStore(store, accounts)
+
+			report, err := Run(context.Background(), store, ledger, accounts, tc.config)
+			tc.checkResponse(t, report, err)
+		})
+	}
+}
+
+func TestRunNotEnoughAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	_, err := Run(context.Background(), store, NewStoreTarget(store), testAccounts(1), Config{Concurrency: 1, Duration: time.Second, Amount: 1})
+	require.ErrorIs(t, err, ErrNotEnoughAccounts)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	require.Equal(t, 50*time.Millisecond, Percentile(sorted, 50))
+	require.Equal(t, 99*time.Millisecond, Percentile(sorted, 99))
+	require.Equal(t, 100*time.Millisecond, Percentile(sorted, 100))
+	require.Equal(t, time.Millisecond, Percentile(sorted, 0))
+	require.Equal(t, time.Duration(0), Percentile(nil, 50))
+	require.Equal(t, 3*time.Millisecond, Percentile(sorted[2:3], 90))
+}
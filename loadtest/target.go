@@ -0,0 +1,132 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/skamranahmed/banking-system/client"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+)
+
+// ErrUnknownOutcome : the transfer might have been committed even though it returned an error, e.g: the
+// connection was lost or timed out before the response was read
+var ErrUnknownOutcome = errors.New("outcome of the transfer is unknown")
+
+// Target : makes the transfers of a load test, it returns the fee charged out of the amount. The error
+// wraps ErrUnknownOutcome if the transfer isn't known to have been rejected
+type Target interface {
+	Transfer(ctx context.Context, from db.Account, to db.Account, amount int64) (int64, error)
+}
+
+// storeTarget : transfers with Store.TransferTxn, without the checks and the fees of the API
+type storeTarget struct {
+	store db.Store
+}
+
+// NewStoreTarget : returns a Target calling TransferTxn of the store directly, so that the database is
+// measured on its own. TransferTxn doesn't check the balance, the accounts can be overdrawn
+func NewStoreTarget(store db.Store) Target {
+	return &storeTarget{store: store}
+}
+
+func (target *storeTarget) Transfer(ctx context.Context, from db.Account, to db.Account, amount int64) (int64, error) {
+	_, err := target.store.TransferTxn(ctx, db.TransferTxnParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+	})
+	return 0, err
+}
+
+// httpTarget : transfers with POST /transfers, authorized as the owner of the `from account`
+type httpTarget struct {
+	currency money.Currency
+	clients  map[int64]*client.Client // per user id
+}
+
+// NewHTTPTarget : returns a Target calling POST /transfers of the API, every owner of the accounts is
+// logged in with the password beforehand. The logins are retried as long as they are rate limited, so
+// more owners than LOGIN_RATE_LIMIT_PER_IP take a window each. The users with two factor authentication
+// can't take part
+func NewHTTPTarget(ctx context.Context, store db.Store, apiClient *client.Client, currency money.Currency, accounts []db.Account, password string) (Target, error) {
+	target := &httpTarget{
+		currency: currency,
+		clients:  make(map[int64]*client.Client),
+	}
+
+	for _, account := range accounts {
+		if _, ok := target.clients[account.UserID]; ok {
+			continue
+		}
+
+		user, err := store.GetUser(ctx, account.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("owner of account %d: %w", account.ID, err)
+		}
+
+		login, err := loginUser(ctx, apiClient, client.LoginUserRequest{Username: user.Username, Password: password})
+		if err != nil {
+			return nil, fmt.Errorf("login of %s: %w", user.Username, err)
+		}
+		if login.TwoFactorRequired {
+			return nil, fmt.Errorf("login of %s: %w", user.Username, errTwoFactorRequired)
+		}
+
+		target.clients[account.UserID] = apiClient.WithToken(login.AccessToken)
+	}
+
+	return target, nil
+}
+
+var errTwoFactorRequired = errors.New("two factor authentication is enabled")
+
+// defaultLoginRetryDelay : waited before retrying a rate limited login responded without Retry-After
+const defaultLoginRetryDelay = time.Second
+
+// loginUser : logs the user in, waiting for as long as the API asks whenever the login is rate limited
+func loginUser(ctx context.Context, apiClient *client.Client, req client.LoginUserRequest) (client.LoginUserResponse, error) {
+	for {
+		login, err := apiClient.LoginUser(ctx, req)
+
+		var apiErr *client.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+			return login, err
+		}
+
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = defaultLoginRetryDelay
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return login, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (target *httpTarget) Transfer(ctx context.Context, from db.Account, to db.Account, amount int64) (int64, error) {
+	result, err := target.clients[from.UserID].CreateTransfer(ctx, client.TransferRequest{
+		FromAccountID:   from.ID,
+		ToAccountNumber: to.AccountNumber,
+		Amount:          money.New(amount, target.currency).Decimal(),
+		Currency:        target.currency.Code,
+	})
+	if err != nil {
+		// only a response with a client error status is known to have been rejected, the server might
+		// have committed the transfer before the connection was lost or before failing the response
+		var apiErr *client.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode >= http.StatusInternalServerError {
+			return 0, fmt.Errorf("%w: %v", ErrUnknownOutcome, err)
+		}
+		return 0, err
+	}
+	return result.Transfer.Fee.Amount, nil
+}
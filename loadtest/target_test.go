@@ -0,0 +1,125 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/skamranahmed/banking-system/client"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAPI : serves the logins, rate limited once, and the transfers, responded according to the
+// `from account`: 1 is rejected, 2 fails on the server, 3 loses the connection and any other succeeds
+func newTestAPI(t *testing.T) (*httptest.Server, *int) {
+	logins := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/login", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		if logins == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": "too many requests, try again later"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "token"}`))
+	})
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		var req client.TransferRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch req.FromAccountID {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "accountID: 1, insufficient balance"}`))
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "connection reset"}`))
+		case 3:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+		default:
+			w.Write([]byte(`{"transfer": {"fee": {"amount": "0.25", "currency": "USD"}}}`))
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &logins
+}
+
+func TestHTTPTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server, logins := newTestAPI(t)
+	currency := money.Currency{Code: "USD", MinorUnits: 2}
+
+	accounts := make([]db.Account, 4)
+	for i := range accounts {
+		accounts[i] = db.Account{ID: int64(i + 1), UserID: 1, Currency: currency.Code}
+	}
+	to := db.Account{ID: 5, UserID: 2, AccountNumber: "1234567890", Currency: currency.Code}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(int64(1))).
+		Times(1).
+		Return(db.User{ID: 1, Username: "alice"}, nil)
+
+	target, err := NewHTTPTarget(context.Background(), store, client.New(server.URL, server.Client()), currency, accounts, "secret123")
+	require.NoError(t, err)
+	require.Equal(t, 2, *logins)
+
+	testCases := []struct {
+		name          string
+		from          db.Account
+		checkResponse func(t *testing.T, fee int64, err error)
+	}{
+		{
+			name: "Happy Case",
+			from: accounts[3],
+			checkResponse: func(t *testing.T, fee int64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(25), fee)
+			},
+		},
+		{
+			name: "Failure Case - Rejected",
+			from: accounts[0],
+			checkResponse: func(t *testing.T, fee int64, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrUnknownOutcome)
+			},
+		},
+		{
+			name: "Failure Case - Server Error",
+			from: accounts[1],
+			checkResponse: func(t *testing.T, fee int64, err error) {
+				require.ErrorIs(t, err, ErrUnknownOutcome)
+			},
+		},
+		{
+			name: "Failure Case - Connection Lost",
+			from: accounts[2],
+			checkResponse: func(t *testing.T, fee int64, err error) {
+				require.ErrorIs(t, err, ErrUnknownOutcome)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := target.Transfer(context.Background(), tc.from, to, 100)
+			tc.checkResponse(t, fee, err)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender : writes every email to a separate .eml file in a directory,
+// meant for the local development and the tests
+type FileSender struct {
+	from    string
+	dir     string
+	counter uint64
+}
+
+// NewFileSender : creates a new FileSender, the directory is created if it doesn't exist
+func NewFileSender(from string, dir string) (Sender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail directory is required for the file sender")
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the mail directory, err: %v", err)
+	}
+
+	return &FileSender{from: from, dir: dir}, nil
+}
+
+// Send : writes the message to a new file, the file names sort in the order the messages were sent
+func (sender *FileSender) Send(ctx context.Context, msg Message) error {
+	n := atomic.AddUint64(&sender.counter, 1)
+	name := fmt.Sprintf("%d-%06d.eml", time.Now().UnixNano(), n)
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		sender.from, msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body,
+	)
+
+	return os.WriteFile(filepath.Join(sender.dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	sender, err := NewSender(SenderFile, "bank@example.com", dir)
+	require.NoError(t, err)
+
+	for _, subject := range []string{"first", "second"} {
+		err = sender.Send(context.Background(), Message{
+			To:      "user@example.com",
+			Subject: subject,
+			Body:    "hello",
+		})
+		require.NoError(t, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "From: bank@example.com\r\n")
+	require.Contains(t, string(content), "To: user@example.com\r\n")
+	require.Contains(t, string(content), "Subject: first\r\n")
+	require.Contains(t, string(content), "\r\n\r\nhello\r\n")
+}
+
+func TestNewSender(t *testing.T) {
+	sender, err := NewSender(SenderLog, "bank@example.com", "")
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), Message{To: "user@example.com"}))
+
+	_, err = NewSender(SenderFile, "bank@example.com", "")
+	require.Error(t, err)
+
+	_, err = NewSender("smtp", "bank@example.com", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package mail
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender : writes the emails to the application log, meant for the local development
+type LogSender struct {
+	from string
+}
+
+// NewLogSender : creates a new LogSender
+func NewLogSender(from string) Sender {
+	return &LogSender{from: from}
+}
+
+// Send : logs the message
+func (sender *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("📧 From: %s, To: %s, Subject: %s\n%s\n", sender.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// SenderLog : the emails are written to the application log
+	SenderLog = "log"
+
+	// SenderFile : every email is written to a separate file in a directory
+	SenderFile = "file"
+)
+
+// Message : an email to be delivered
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender : is an interface for delivering emails
+type Sender interface {
+	// Send : delivers the message
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender : creates the Sender of the provided kind, dir is only used by the file sender
+func NewSender(kind string, from string, dir string) (Sender, error) {
+	switch kind {
+	case SenderLog:
+		return NewLogSender(from), nil
+	case SenderFile:
+		return NewFileSender(from, dir)
+	}
+	return nil, fmt.Errorf("unknown mail sender %q", kind)
+}
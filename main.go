@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
+	"time"
 
 	"github.com/skamranahmed/banking-system/api"
 	"github.com/skamranahmed/banking-system/config"
+	"github.com/skamranahmed/banking-system/db/migration"
 
 	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/stream"
 
 	_ "github.com/lib/pq"
 )
@@ -34,15 +39,73 @@ func main() {
 	// close the db connection
 	defer conn.Close()
 
+	// run the subcommand if one has been provided, e.g: `main migrate up`
+	if len(os.Args) > 1 {
+		err = runCommand(conn, os.Args[1], os.Args[2:])
+		if err != nil {
+			log.Fatalf("❌ %s command failed, error: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	if config.AutoMigrate {
+		migrator, err := migration.NewMigrator(conn)
+		if err != nil {
+			log.Fatalf("❌ unable to load the migrations, error: %v", err)
+		}
+
+		err = migrateUp(context.Background(), migrator)
+		if err != nil {
+			log.Fatalf("❌ unable to migrate the db, error: %v", err)
+		}
+	}
+
 	// instantiate dependencies
 	store := db.NewStore(conn)
-	server, err := api.NewServer(store)
+	accountEvents := stream.NewHub()
+	server, err := api.NewServer(store, accountEvents)
 	if err != nil {
 		log.Fatalf("unable to instantiate server, error: %v", err)
 	}
 
+	// the events are notified by the database on commit, whichever instance made the change
+	go func() {
+		err := stream.Listen(context.Background(), config.DbHost, accountEvents)
+		if err != nil {
+			log.Fatalf("unable to listen to the account events, error: %v", err)
+		}
+	}()
+
+	if config.WebhookDispatcherEnabled {
+		interval := time.Second * time.Duration(config.WebhookDispatchIntervalSeconds)
+		go newWebhookDispatcher(store).Run(context.Background(), interval)
+	}
+
+	// the gRPC API is served on its own port by the same server
+	go func() {
+		err := server.StartGRPC(config.GRPCServerPort)
+		if err != nil {
+			log.Fatalf("unable to start grpc server, error: %v", err)
+		}
+	}()
+
 	err = server.Start(config.ServerPort)
 	if err != nil {
 		log.Fatalf("unable to start server, error: %v", err)
 	}
 }
+
+// migrateUp : applies all the pending embedded migrations
+func migrateUp(ctx context.Context, migrator *migration.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		if err == migration.ErrNoChange {
+			log.Printf("✅ Database schema is up to date")
+			return nil
+		}
+		return err
+	}
+
+	log.Printf("✅ Applied %d migration(s)", applied)
+	return nil
+}
@@ -0,0 +1,141 @@
+package money
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// maxMinorUnits : the largest number of minor unit digits in ISO 4217
+const maxMinorUnits = 4
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Currency : the ISO 4217 metadata of a currency
+type Currency struct {
+	Code       string `mapstructure:"code"`        // alphabetic code, e.g: USD
+	Number     int    `mapstructure:"number"`      // numeric code, e.g: 840
+	MinorUnits int    `mapstructure:"minor_units"` // digits after the decimal separator, e.g: 2 for cents
+	Name       string `mapstructure:"name"`
+
+	// StepUpThreshold : the decimal amount from which the transfers in the currency require a one time
+	// password, e.g: "1000.00". Empty if they never do
+	StepUpThreshold string `mapstructure:"step_up_threshold"`
+}
+
+// defaultCurrencies : used when no currency registry file is configured
+var defaultCurrencies = []Currency{
+	{Code: "CAD", Number: 124, MinorUnits: 2, Name: "Canadian Dollar"},
+	{Code: "EUR", Number: 978, MinorUnits: 2, Name: "Euro"},
+	{Code: "INR", Number: 356, MinorUnits: 2, Name: "Indian Rupee"},
+	{Code: "USD", Number: 840, MinorUnits: 2, Name: "US Dollar"},
+}
+
+// Registry : the currencies supported by the application
+type Registry struct {
+	currencies map[string]Currency
+	thresholds map[string]Money // the parsed step up thresholds by currency code
+}
+
+// NewRegistry : creates a Registry of the provided currencies
+func NewRegistry(currencies []Currency) (*Registry, error) {
+	if len(currencies) == 0 {
+		return nil, fmt.Errorf("at least one currency is required")
+	}
+
+	registry := &Registry{
+		currencies: make(map[string]Currency, len(currencies)),
+		thresholds: make(map[string]Money),
+	}
+	for _, currency := range currencies {
+		if !currencyCodeRegex.MatchString(currency.Code) {
+			return nil, fmt.Errorf("invalid currency code %q: must be 3 uppercase letters", currency.Code)
+		}
+
+		if currency.MinorUnits < 0 || currency.MinorUnits > maxMinorUnits {
+			return nil, fmt.Errorf("invalid minor units of %s: must be between 0 and %d", currency.Code, maxMinorUnits)
+		}
+
+		_, exists := registry.currencies[currency.Code]
+		if exists {
+			return nil, fmt.Errorf("duplicate currency %s", currency.Code)
+		}
+
+		if currency.StepUpThreshold != "" {
+			threshold, err := Parse(currency.StepUpThreshold, currency)
+			if err != nil || !threshold.IsPositive() {
+				return nil, fmt.Errorf("invalid step up threshold %q of %s: must be a positive amount", currency.StepUpThreshold, currency.Code)
+			}
+			registry.thresholds[currency.Code] = threshold
+		}
+
+		registry.currencies[currency.Code] = currency
+	}
+
+	return registry, nil
+}
+
+// DefaultRegistry : returns the registry of the built-in currencies
+func DefaultRegistry() *Registry {
+	registry, _ := NewRegistry(defaultCurrencies)
+	return registry
+}
+
+// LoadRegistry : reads the currencies from a yaml file with a top level `currencies` list,
+// the default registry is returned if the path is empty
+func LoadRegistry(path string) (*Registry, error) {
+	if path == "" {
+		return DefaultRegistry(), nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the currency registry, err: %v", err)
+	}
+
+	var file struct {
+		Currencies []Currency `mapstructure:"currencies"`
+	}
+
+	err = v.Unmarshal(&file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the currency registry, err: %v", err)
+	}
+
+	return NewRegistry(file.Currencies)
+}
+
+// Lookup : returns the currency of the code, false if it is not supported
+func (registry *Registry) Lookup(code string) (Currency, bool) {
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// IsSupported : reports whether the currency code is in the registry
+func (registry *Registry) IsSupported(code string) bool {
+	_, ok := registry.currencies[code]
+	return ok
+}
+
+// RequiresStepUp : reports whether a transfer of the amount requires a one time password, i.e. its
+// currency has a step up threshold and the amount is at least the threshold
+func (registry *Registry) RequiresStepUp(amount Money) bool {
+	threshold, ok := registry.thresholds[amount.Currency.Code]
+	return ok && amount.Amount >= threshold.Amount
+}
+
+// Codes : returns the sorted codes of all the supported currencies
+func (registry *Registry) Codes() []string {
+	codes := make([]string, 0, len(registry.currencies))
+	for code := range registry.currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
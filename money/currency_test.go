@@ -0,0 +1,93 @@
+package money
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	registry := DefaultRegistry()
+	require.Equal(t, []string{"CAD", "EUR", "INR", "USD"}, registry.Codes())
+
+	currency, ok := registry.Lookup("INR")
+	require.True(t, ok)
+	require.Equal(t, 2, currency.MinorUnits)
+
+	require.False(t, registry.IsSupported("XYZ"))
+}
+
+func TestLoadRegistry(t *testing.T) {
+	content := `currencies:
+  - code: USD
+    number: 840
+    minor_units: 2
+    name: US Dollar
+  - code: JPY
+    number: 392
+    minor_units: 0
+    name: Yen
+`
+	path := filepath.Join(t.TempDir(), "currencies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	registry, err := LoadRegistry(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"JPY", "USD"}, registry.Codes())
+
+	currency, ok := registry.Lookup("JPY")
+	require.True(t, ok)
+	require.Equal(t, jpy, currency)
+
+	// the sample registry shipped with the config has to be valid
+	registry, err = LoadRegistry("../config/currencies.yaml")
+	require.NoError(t, err)
+	require.True(t, registry.IsSupported("USD"))
+
+	_, err = LoadRegistry(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+
+	registry, err = LoadRegistry("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultRegistry().Codes(), registry.Codes())
+}
+
+func TestNewRegistry(t *testing.T) {
+	_, err := NewRegistry(nil)
+	require.Error(t, err)
+
+	_, err = NewRegistry([]Currency{{Code: "usd", MinorUnits: 2}})
+	require.Error(t, err)
+
+	_, err = NewRegistry([]Currency{{Code: "USD", MinorUnits: 5}})
+	require.Error(t, err)
+
+	_, err = NewRegistry([]Currency{usd, usd})
+	require.Error(t, err)
+}
+
+func TestRequiresStepUp(t *testing.T) {
+	jpy := Currency{Code: "JPY", Number: 392, MinorUnits: 0, StepUpThreshold: "100000"}
+	kwd := Currency{Code: "KWD", Number: 414, MinorUnits: 3, StepUpThreshold: "300.000"}
+
+	registry, err := NewRegistry([]Currency{usd, jpy, kwd})
+	require.NoError(t, err)
+
+	// the thresholds are in the minor units of every currency
+	require.False(t, registry.RequiresStepUp(New(99999, jpy)))
+	require.True(t, registry.RequiresStepUp(New(100000, jpy)))
+	require.False(t, registry.RequiresStepUp(New(299999, kwd)))
+	require.True(t, registry.RequiresStepUp(New(300000, kwd)))
+
+	// no threshold
+	require.False(t, registry.RequiresStepUp(New(math.MaxInt64, usd)))
+
+	_, err = NewRegistry([]Currency{{Code: "USD", MinorUnits: 2, StepUpThreshold: "10.001"}})
+	require.Error(t, err)
+
+	_, err = NewRegistry([]Currency{{Code: "USD", MinorUnits: 2, StepUpThreshold: "0"}})
+	require.Error(t, err)
+}
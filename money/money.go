@@ -0,0 +1,196 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	ErrOverflow = errors.New("amount out of range")
+
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// Money : an amount in the minor units of its currency, e.g: 1234 USD is $12.34
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// New : creates Money from an amount in minor units
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse : creates Money from a decimal string, e.g: "12.34", it can't have more decimals than the minor units
+// of the currency and is rejected if it doesn't fit in int64 minor units
+func Parse(value string, currency Currency) (Money, error) {
+	s := value
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+		if fraction == "" {
+			return Money{}, ErrInvalidAmount
+		}
+	}
+
+	if whole == "" || len(fraction) > currency.MinorUnits {
+		return Money{}, fmt.Errorf("%w: %q, %s allows %d decimals", ErrInvalidAmount, value, currency.Code, currency.MinorUnits)
+	}
+
+	// pad the fraction to the minor units, so that the digits can be read as a single integer
+	digits := whole + fraction + strings.Repeat("0", currency.MinorUnits-len(fraction))
+
+	var amount int64
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+
+		digit := int64(r - '0')
+		if amount > (math.MaxInt64-digit)/10 {
+			return Money{}, ErrOverflow
+		}
+		amount = amount*10 + digit
+	}
+
+	if negative {
+		amount = -amount
+	}
+	return New(amount, currency), nil
+}
+
+// Add : returns m + other, both have to be of the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency.Code != other.Currency.Code {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrOverflow
+	}
+
+	return New(m.Amount+other.Amount, m.Currency), nil
+}
+
+// Sub : returns m - other, both have to be of the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	negated, err := other.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Add(negated)
+}
+
+// Neg : returns -m
+func (m Money) Neg() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return New(-m.Amount, m.Currency), nil
+}
+
+// Cmp : returns -1, 0 or +1 depending on whether m is less than, equal to or greater than other
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency.Code != other.Currency.Code {
+		return 0, ErrCurrencyMismatch
+	}
+
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// IsZero : reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive : reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative : reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Decimal : formats the amount as a decimal string with exactly the minor unit digits of the currency, e.g: "12.30"
+func (m Money) Decimal() string {
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		// math.MinInt64 can't be negated within int64
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if m.Currency.MinorUnits == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= m.Currency.MinorUnits {
+		digits = strings.Repeat("0", m.Currency.MinorUnits-len(digits)+1) + digits
+	}
+
+	point := len(digits) - m.Currency.MinorUnits
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// String : formats the money with its currency code, e.g: "12.30 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency.Code
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON : encodes the money as {"amount": "12.30", "currency": "USD"}, the amount is a decimal string
+// so that it isn't rounded by the clients parsing the numbers as floats
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency.Code})
+}
+
+// UnmarshalJSON : decodes the money encoded by MarshalJSON, the minor units are taken from the number
+// of decimals since the amount is always formatted with all of them
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	minorUnits := 0
+	if i := strings.IndexByte(v.Amount, '.'); i >= 0 {
+		minorUnits = len(v.Amount) - i - 1
+	}
+
+	parsed, err := Parse(v.Amount, Currency{Code: v.Currency, MinorUnits: minorUnits})
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
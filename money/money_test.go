@@ -0,0 +1,114 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usd = Currency{Code: "USD", Number: 840, MinorUnits: 2, Name: "US Dollar"}
+	jpy = Currency{Code: "JPY", Number: 392, MinorUnits: 0, Name: "Yen"}
+	kwd = Currency{Code: "KWD", Number: 414, MinorUnits: 3, Name: "Kuwaiti Dinar"}
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency Currency
+		amount   int64
+		err      error
+	}{
+		{value: "12.34", currency: usd, amount: 1234},
+		{value: "12.3", currency: usd, amount: 1230},
+		{value: "12", currency: usd, amount: 1200},
+		{value: "0.05", currency: usd, amount: 5},
+		{value: "-1.50", currency: usd, amount: -150},
+		{value: "1500", currency: jpy, amount: 1500},
+		{value: "1.234", currency: kwd, amount: 1234},
+		{value: "92233720368547758.07", currency: usd, amount: math.MaxInt64},
+		{value: "12.345", currency: usd, err: ErrInvalidAmount},
+		{value: "1.5", currency: jpy, err: ErrInvalidAmount},
+		{value: "12.", currency: usd, err: ErrInvalidAmount},
+		{value: ".5", currency: usd, err: ErrInvalidAmount},
+		{value: "1e3", currency: usd, err: ErrInvalidAmount},
+		{value: "+1", currency: usd, err: ErrInvalidAmount},
+		{value: "", currency: usd, err: ErrInvalidAmount},
+		{value: "92233720368547758.08", currency: usd, err: ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value+" "+tc.currency.Code, func(t *testing.T) {
+			m, err := Parse(tc.value, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.amount, m.Amount)
+			require.Equal(t, tc.currency, m.Currency)
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	require.Equal(t, "12.34", New(1234, usd).Decimal())
+	require.Equal(t, "0.05", New(5, usd).Decimal())
+	require.Equal(t, "0.00", New(0, usd).Decimal())
+	require.Equal(t, "-1.50", New(-150, usd).Decimal())
+	require.Equal(t, "1500", New(1500, jpy).Decimal())
+	require.Equal(t, "0.001", New(1, kwd).Decimal())
+	require.Equal(t, "-92233720368547758.08", New(math.MinInt64, usd).Decimal())
+	require.Equal(t, "12.34 USD", New(1234, usd).String())
+}
+
+func TestArithmetic(t *testing.T) {
+	sum, err := New(1234, usd).Add(New(66, usd))
+	require.NoError(t, err)
+	require.Equal(t, New(1300, usd), sum)
+
+	difference, err := New(100, usd).Sub(New(250, usd))
+	require.NoError(t, err)
+	require.Equal(t, New(-150, usd), difference)
+	require.True(t, difference.IsNegative())
+
+	_, err = New(100, usd).Add(New(100, jpy))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(math.MaxInt64, usd).Add(New(1, usd))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, usd).Sub(New(1, usd))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(0, usd).Sub(New(math.MinInt64, usd))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	cmp, err := New(100, usd).Cmp(New(200, usd))
+	require.NoError(t, err)
+	require.Equal(t, -1, cmp)
+
+	_, err = New(100, usd).Cmp(New(100, kwd))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	require.True(t, New(0, usd).IsZero())
+	require.True(t, New(1, usd).IsPositive())
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(1230, usd))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.30","currency":"USD"}`, string(data))
+
+	var m Money
+	err = json.Unmarshal(data, &m)
+	require.NoError(t, err)
+	require.Equal(t, int64(1230), m.Amount)
+	require.Equal(t, "USD", m.Currency.Code)
+	require.Equal(t, 2, m.Currency.MinorUnits)
+
+	err = json.Unmarshal([]byte(`{"amount":"1,5","currency":"USD"}`), &m)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
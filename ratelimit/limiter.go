@@ -0,0 +1,10 @@
+package ratelimit
+
+import "time"
+
+// Limiter is an interface for limiting the number of hits on a key within a time window
+type Limiter interface {
+	// Allow : records a hit for the key and reports whether it is within the limit,
+	// if it isn't, the duration after which the key can be retried is also returned
+	Allow(key string) (bool, time.Duration)
+}
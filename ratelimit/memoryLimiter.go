@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// the expired windows are purged once every `cleanupInterval` hits, so that keys which
+// are never seen again don't stay in memory forever
+const cleanupInterval = 1000
+
+type window struct {
+	hits    int
+	resetAt time.Time
+}
+
+// MemoryLimiter : implements the Limiter interface using a fixed window counter kept in memory,
+// the counters are local to the process so every server instance enforces its own limit
+type MemoryLimiter struct {
+	limit  int
+	period time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+	calls   int
+	now     func() time.Time
+}
+
+// NewMemoryLimiter : returns a new MemoryLimiter which allows `limit` hits per key every `period`
+func NewMemoryLimiter(limit int, period time.Duration) (Limiter, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("invalid limit: must be atleast 1")
+	}
+
+	if period <= 0 {
+		return nil, fmt.Errorf("invalid period: must be positive")
+	}
+
+	return &MemoryLimiter{
+		limit:   limit,
+		period:  period,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}, nil
+}
+
+// Allow : records a hit for the key and reports whether it is within the limit
+func (limiter *MemoryLimiter) Allow(key string) (bool, time.Duration) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+
+	limiter.calls++
+	if limiter.calls%cleanupInterval == 0 {
+		for k, w := range limiter.windows {
+			if !now.Before(w.resetAt) {
+				delete(limiter.windows, k)
+			}
+		}
+	}
+
+	w, ok := limiter.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(limiter.period)}
+		limiter.windows[key] = w
+	}
+
+	if w.hits >= limiter.limit {
+		return false, w.resetAt.Sub(now)
+	}
+
+	w.hits++
+	return true, 0
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter, err := NewMemoryLimiter(3, time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	limiter.(*MemoryLimiter).now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, retryAfter := limiter.Allow("key1")
+		require.True(t, allowed)
+		require.Zero(t, retryAfter)
+	}
+
+	allowed, retryAfter := limiter.Allow("key1")
+	require.False(t, allowed)
+	require.Equal(t, time.Minute, retryAfter)
+
+	// every key has its own window
+	allowed, _ = limiter.Allow("key2")
+	require.True(t, allowed)
+
+	// the hits are allowed again once the window is over
+	now = now.Add(time.Minute)
+	allowed, _ = limiter.Allow("key1")
+	require.True(t, allowed)
+}
+
+func TestInvalidMemoryLimiter(t *testing.T) {
+	limiter, err := NewMemoryLimiter(0, time.Minute)
+	require.Error(t, err)
+	require.Nil(t, limiter)
+
+	limiter, err = NewMemoryLimiter(1, 0)
+	require.Error(t, err)
+	require.Nil(t, limiter)
+}
@@ -0,0 +1,284 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/skamranahmed/banking-system/accountnumber"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/skamranahmed/banking-system/utils"
+)
+
+const (
+	// zipfExponent : how much busier the popular accounts are, a handful of accounts send and
+	// receive most of the transfers like the merchants and the payroll accounts of a real bank
+	zipfExponent = 1.2
+
+	// the amounts follow a log-normal distribution around the median, in major units. Most of the
+	// transfers are small and a few of them are large
+	depositMedian  = 1000
+	depositSigma   = 1.0
+	transferMedian = 25
+	transferSigma  = 1.2
+)
+
+var (
+	firstNames = []string{
+		"aarav", "aisha", "alice", "amir", "ana", "arjun", "bob", "carlos", "chen", "diya",
+		"elena", "farah", "grace", "hana", "ivan", "jamal", "kavya", "liam", "maya", "mohammed",
+		"nina", "omar", "priya", "rahul", "sara", "sofia", "tariq", "wei", "yusuf", "zara",
+	}
+	lastNames = []string{
+		"ahmed", "brown", "chopra", "costa", "das", "garcia", "gupta", "hassan", "ito", "khan",
+		"kim", "lopez", "martin", "miller", "nguyen", "novak", "patel", "rossi", "sato", "sharma",
+		"silva", "singh", "smith", "wang", "wilson",
+	}
+	accountTypes = []string{utils.AccountTypeChecking, utils.AccountTypeSavings, utils.AccountTypeWallet}
+)
+
+// ErrInvalidConfig : the config can't produce any data
+var ErrInvalidConfig = errors.New("the users, the currencies and the accounts per currency must be positive")
+
+// Config : what to seed, the same config always produces the same data
+type Config struct {
+	Seed                int64
+	Users               int
+	Currencies          []money.Currency
+	AccountsPerCurrency int // accounts opened by every user in every currency
+	Transfers           int
+	PasswordHash        string // shared by all the users, so that any of them can log in for a demo
+}
+
+// Report : the outcome of a seed run
+type Report struct {
+	Users     int
+	Accounts  int
+	Deposits  int              // opening deposits, one per account
+	Transfers int              // transfers between the accounts of the users
+	Skipped   int              // transfers not made because the sender had run out of money
+	Volume    map[string]int64 // the transferred minor units per currency, the deposits excluded
+}
+
+// Seeder : populates a database with users, accounts and transfers for demos and benchmarks
+type Seeder struct {
+	store  db.Store
+	config Config
+	rng    *rand.Rand
+
+	// the balances are tracked locally so that the transfers never overdraw an account,
+	// the accounts are grouped by currency since the transfers can't convert
+	balances map[int64]int64
+	accounts map[string][]int64
+}
+
+// NewSeeder : creates a new Seeder
+func NewSeeder(store db.Store, config Config) *Seeder {
+	return &Seeder{
+		store:    store,
+		config:   config,
+		rng:      rand.New(rand.NewSource(config.Seed)),
+		balances: make(map[int64]int64),
+		accounts: make(map[string][]int64),
+	}
+}
+
+// Run : creates the users and their accounts, funds every account with an opening deposit from the
+// opening balance system account of its currency and then makes the transfers between the accounts.
+// Every deposit and transfer goes through TransferTxn so that the entries add up to the balances.
+// The usernames and the account numbers are derived from the seed, seeding the same database twice
+// with the same seed fails on the first username
+func (seeder *Seeder) Run(ctx context.Context) (Report, error) {
+	report := Report{Volume: make(map[string]int64)}
+
+	if seeder.config.Users <= 0 || len(seeder.config.Currencies) == 0 || seeder.config.AccountsPerCurrency <= 0 {
+		return report, ErrInvalidConfig
+	}
+
+	openingAccounts := make(map[string]int64)
+	for _, currency := range seeder.config.Currencies {
+		accountNumber, err := accountnumber.GenerateFrom(seeder.rng)
+		if err != nil {
+			return report, err
+		}
+
+		account, err := seeder.store.SystemAccountTxn(ctx, db.SystemAccountTxnParams{
+			Purpose:       db.SystemAccountOpeningBalance,
+			Currency:      currency.Code,
+			AccountNumber: accountNumber,
+		})
+		if err != nil {
+			return report, fmt.Errorf("opening balance account of %s: %w", currency.Code, err)
+		}
+		openingAccounts[currency.Code] = account.ID
+	}
+
+	for i := 1; i <= seeder.config.Users; i++ {
+		user, err := seeder.store.CreateUser(ctx, seeder.userParams(i))
+		if err != nil {
+			return report, fmt.Errorf("user %d: %w", i, err)
+		}
+		report.Users++
+
+		for _, currency := range seeder.config.Currencies {
+			for j := 0; j < seeder.config.AccountsPerCurrency; j++ {
+				account, err := seeder.openAccount(ctx, user, currency, j)
+				if err != nil {
+					return report, fmt.Errorf("account of user %s: %w", user.Username, err)
+				}
+				report.Accounts++
+
+				deposit := seeder.amount(currency, depositMedian, depositSigma)
+				_, err = seeder.store.TransferTxn(ctx, db.TransferTxnParams{
+					FromAccountID: openingAccounts[currency.Code],
+					ToAccountID:   account.ID,
+					Amount:        deposit,
+				})
+				if err != nil {
+					return report, fmt.Errorf("deposit to account %d: %w", account.ID, err)
+				}
+				seeder.balances[account.ID] = deposit
+				report.Deposits++
+			}
+		}
+	}
+
+	// the popular accounts are picked at random, otherwise the first users would be the busiest
+	pickers := make(map[string]*accountPicker)
+	for _, currency := range seeder.config.Currencies {
+		pickers[currency.Code] = seeder.newAccountPicker(seeder.accounts[currency.Code])
+	}
+
+	for i := 0; i < seeder.config.Transfers; i++ {
+		currency := seeder.config.Currencies[seeder.rng.Intn(len(seeder.config.Currencies))]
+		picker := pickers[currency.Code]
+
+		from, to, ok := picker.pair()
+		if !ok {
+			report.Skipped++
+			continue
+		}
+
+		amount := seeder.amount(currency, transferMedian, transferSigma)
+		if amount > seeder.balances[from] {
+			amount = seeder.balances[from]
+		}
+		if amount <= 0 {
+			report.Skipped++
+			continue
+		}
+
+		_, err := seeder.store.TransferTxn(ctx, db.TransferTxnParams{
+			FromAccountID: from,
+			ToAccountID:   to,
+			Amount:        amount,
+		})
+		if err != nil {
+			return report, fmt.Errorf("transfer from account %d to %d: %w", from, to, err)
+		}
+
+		seeder.balances[from] -= amount
+		seeder.balances[to] += amount
+		report.Transfers++
+		report.Volume[currency.Code] += amount
+	}
+
+	return report, nil
+}
+
+// userParams : the user number i, e.g: mayapatel7. The usernames are alphanumeric like the ones of the API
+func (seeder *Seeder) userParams(i int) db.CreateUserParams {
+	first := firstNames[seeder.rng.Intn(len(firstNames))]
+	last := lastNames[seeder.rng.Intn(len(lastNames))]
+	username := fmt.Sprintf("%s%s%d", first, last, i)
+
+	return db.CreateUserParams{
+		Username: username,
+		Password: seeder.config.PasswordHash,
+		FullName: fmt.Sprintf("%s %s", capitalize(first), capitalize(last)),
+		Email:    fmt.Sprintf("%s@example.com", username),
+	}
+}
+
+// capitalize : uppercases the first letter of the name
+func capitalize(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// openAccount : opens the account number j of the user in the currency, the first account of a
+// currency is always a checking account
+func (seeder *Seeder) openAccount(ctx context.Context, user db.User, currency money.Currency, j int) (db.Account, error) {
+	accountType := utils.AccountTypeChecking
+	if j > 0 {
+		accountType = accountTypes[seeder.rng.Intn(len(accountTypes))]
+	}
+
+	accountNumber, err := accountnumber.GenerateFrom(seeder.rng)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	account, err := seeder.store.CreateAccount(ctx, db.CreateAccountParams{
+		UserID:        user.ID,
+		Balance:       0,
+		Currency:      currency.Code,
+		Type:          accountType,
+		Nickname:      fmt.Sprintf("%s %d", accountType, j+1),
+		AccountNumber: accountNumber,
+	})
+	if err != nil {
+		return account, err
+	}
+
+	seeder.accounts[currency.Code] = append(seeder.accounts[currency.Code], account.ID)
+	return account, nil
+}
+
+// amount : returns a log-normally distributed amount around the median, in the minor units of the currency
+func (seeder *Seeder) amount(currency money.Currency, median float64, sigma float64) int64 {
+	major := median * math.Exp(sigma*seeder.rng.NormFloat64())
+	minor := int64(math.Round(major * math.Pow10(currency.MinorUnits)))
+	if minor < 1 {
+		return 1
+	}
+	return minor
+}
+
+// accountPicker : picks the accounts of a currency following a Zipf distribution
+type accountPicker struct {
+	accounts []int64
+	zipf     *rand.Zipf
+}
+
+func (seeder *Seeder) newAccountPicker(accounts []int64) *accountPicker {
+	picker := &accountPicker{}
+	if len(accounts) < 2 {
+		return picker
+	}
+
+	picker.accounts = make([]int64, len(accounts))
+	for i, j := range seeder.rng.Perm(len(accounts)) {
+		picker.accounts[i] = accounts[j]
+	}
+	picker.zipf = rand.NewZipf(seeder.rng, zipfExponent, 1, uint64(len(accounts)-1))
+	return picker
+}
+
+// pair : returns two different accounts, false if the currency has less than two accounts
+func (picker *accountPicker) pair() (int64, int64, bool) {
+	if picker.zipf == nil {
+		return 0, 0, false
+	}
+
+	from := picker.accounts[picker.zipf.Uint64()]
+	for {
+		to := picker.accounts[picker.zipf.Uint64()]
+		if to != from {
+			return from, to, true
+		}
+	}
+}
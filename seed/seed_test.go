@@ -0,0 +1,183 @@
+package seed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/skamranahmed/banking-system/money"
+	"github.com/stretchr/testify/require"
+)
+
+var testCurrencies = []money.Currency{
+	{Code: "INR", MinorUnits: 2},
+	{Code: "JPY", MinorUnits: 0},
+}
+
+// recordedRun : the calls made to the store by a seed run
+type recordedRun struct {
+	users     []db.CreateUserParams
+	accounts  []db.CreateAccountParams
+	transfers []db.TransferTxnParams
+}
+
+// runSeeder : runs the seeder against a mock store which records the calls and assigns the ids
+func runSeeder(t *testing.T, config Config) (recordedRun, Report, error) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	var run recordedRun
+	var lastID int64
+
+	store.EXPECT().
+		SystemAccountTxn(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.SystemAccountTxnParams) (db.Account, error) {
+			require.Equal(t, db.SystemAccountOpeningBalance, arg.Purpose)
+			lastID++
+			return db.Account{ID: lastID, Currency: arg.Currency, AccountNumber: arg.AccountNumber}, nil
+		})
+	store.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.CreateUserParams) (db.User, error) {
+			run.users = append(run.users, arg)
+			lastID++
+			return db.User{ID: lastID, Username: arg.Username}, nil
+		})
+	store.EXPECT().
+		CreateAccount(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.CreateAccountParams) (db.Account, error) {
+			run.accounts = append(run.accounts, arg)
+			lastID++
+			return db.Account{ID: lastID, UserID: arg.UserID, Currency: arg.Currency}, nil
+		})
+	store.EXPECT().
+		TransferTxn(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.TransferTxnParams) (db.TransferTxnResult, error) {
+			run.transfers = append(run.transfers, arg)
+			return db.TransferTxnResult{}, nil
+		})
+
+	report, err := NewSeeder(store, config).Run(context.Background())
+	return run, report, err
+}
+
+func TestRun(t *testing.T) {
+	config := Config{
+		Seed:                42,
+		Users:               20,
+		Currencies:          testCurrencies,
+		AccountsPerCurrency: 2,
+		Transfers:           500,
+		PasswordHash:        "hash",
+	}
+
+	run, report, err := runSeeder(t, config)
+	require.NoError(t, err)
+
+	require.Equal(t, 20, report.Users)
+	require.Equal(t, 80, report.Accounts)
+	require.Equal(t, 80, report.Deposits)
+	require.Equal(t, 500, report.Transfers+report.Skipped)
+	require.Len(t, run.users, 20)
+	require.Len(t, run.accounts, 80)
+	require.Len(t, run.transfers, report.Deposits+report.Transfers)
+
+	usernames := make(map[string]bool)
+	for _, user := range run.users {
+		require.Regexp(t, "^[a-z]+[0-9]+$", user.Username)
+		require.False(t, usernames[user.Username])
+		usernames[user.Username] = true
+		require.Equal(t, "hash", user.Password)
+	}
+
+	// the opening balance accounts have the ids 1 and 2, the balances of the other accounts can't go negative
+	balances := make(map[int64]int64)
+	transferred := make(map[int64]bool)
+	for i, transfer := range run.transfers {
+		require.Positive(t, transfer.Amount)
+		require.NotEqual(t, transfer.FromAccountID, transfer.ToAccountID)
+		require.Zero(t, transfer.Fee)
+
+		if i < report.Deposits {
+			require.LessOrEqual(t, transfer.FromAccountID, int64(2))
+		} else {
+			require.Greater(t, transfer.FromAccountID, int64(2))
+			transferred[transfer.FromAccountID] = true
+			transferred[transfer.ToAccountID] = true
+		}
+
+		balances[transfer.FromAccountID] -= transfer.Amount
+		balances[transfer.ToAccountID] += transfer.Amount
+		if transfer.FromAccountID > 2 {
+			require.GreaterOrEqual(t, balances[transfer.FromAccountID], int64(0))
+		}
+	}
+	require.Greater(t, len(transferred), 10)
+
+	var total int64
+	for _, amount := range report.Volume {
+		total += amount
+	}
+	require.Positive(t, total)
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	config := Config{
+		Seed:                7,
+		Users:               10,
+		Currencies:          testCurrencies,
+		AccountsPerCurrency: 1,
+		Transfers:           100,
+	}
+
+	first, firstReport, err := runSeeder(t, config)
+	require.NoError(t, err)
+
+	second, secondReport, err := runSeeder(t, config)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, firstReport, secondReport)
+
+	config.Seed = 8
+	other, _, err := runSeeder(t, config)
+	require.NoError(t, err)
+	require.NotEqual(t, first.users, other.users)
+	require.NotEqual(t, first.transfers, other.transfers)
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	testCases := []Config{
+		{Users: 0, Currencies: testCurrencies, AccountsPerCurrency: 1},
+		{Users: 1, AccountsPerCurrency: 1},
+		{Users: 1, Currencies: testCurrencies, AccountsPerCurrency: 0},
+	}
+
+	for _, config := range testCases {
+		_, _, err := runSeeder(t, config)
+		require.ErrorIs(t, err, ErrInvalidConfig)
+	}
+}
+
+func TestRunSingleAccount(t *testing.T) {
+	// a currency with a single account can't have transfers
+	run, report, err := runSeeder(t, Config{
+		Seed:                1,
+		Users:               1,
+		Currencies:          testCurrencies[:1],
+		AccountsPerCurrency: 1,
+		Transfers:           10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Deposits)
+	require.Zero(t, report.Transfers)
+	require.Equal(t, 10, report.Skipped)
+	require.Len(t, run.transfers, 1)
+}
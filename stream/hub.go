@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// types of the account events
+const (
+	EventEntry   = "entry"   // an entry has been recorded on the account
+	EventBalance = "balance" // the ledger or the available balance of the account has changed
+)
+
+// subscriberBuffer : events buffered per subscriber, a subscriber which falls further behind is dropped
+const subscriberBuffer = 64
+
+// Event : a change of an account, as notified by the database
+type Event struct {
+	Type      string    `json:"type"`
+	AccountID int64     `json:"account_id"`
+	EntryID   int64     `json:"entry_id"`   // only set for the entry events
+	Amount    int64     `json:"amount"`     // only set for the entry events
+	CreatedAt time.Time `json:"created_at"` // only set for the entry events
+}
+
+// Hub : fans the events of the accounts out to their subscribers within the process
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan Event]struct{}
+}
+
+// NewHub : creates a new Hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[int64]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe : returns a channel which receives the events of the account, and the function which ends the
+// subscription. The channel is closed when the subscription is ended or dropped, the subscriber is expected
+// to catch up from the database in that case, e.g: an SSE client reconnects
+func (h *Hub) Subscribe(accountID int64) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[accountID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.remove(accountID, ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish : sends the event to the subscribers of its account without blocking, the subscribers
+// which can't keep up are dropped instead of missing events silently
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.AccountID] {
+		select {
+		case ch <- event:
+		default:
+			h.remove(event.AccountID, ch)
+		}
+	}
+}
+
+// Reset : drops every subscriber, e.g: when the events may have been missed while the
+// connection to the database was lost
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for accountID, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			h.remove(accountID, ch)
+		}
+	}
+}
+
+// Subscribers : returns the number of subscribers of the account
+func (h *Hub) Subscribers(accountID int64) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[accountID])
+}
+
+// remove : closes the channel of the subscriber, must be called with the lock held
+func (h *Hub) remove(accountID int64, ch chan Event) {
+	subscribers := h.subscribers[accountID]
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, accountID)
+	}
+}
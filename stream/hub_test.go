@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+
+	events1, unsubscribe1 := hub.Subscribe(1)
+	defer unsubscribe1()
+	events2, unsubscribe2 := hub.Subscribe(2)
+	defer unsubscribe2()
+
+	hub.Publish(Event{Type: EventEntry, AccountID: 1, EntryID: 10, Amount: -100})
+	hub.Publish(Event{Type: EventBalance, AccountID: 1})
+
+	require.Equal(t, Event{Type: EventEntry, AccountID: 1, EntryID: 10, Amount: -100}, <-events1)
+	require.Equal(t, Event{Type: EventBalance, AccountID: 1}, <-events1)
+
+	// the subscribers only get the events of their account
+	require.Empty(t, events2)
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	hub := NewHub()
+
+	events, unsubscribe := hub.Subscribe(1)
+	require.Equal(t, 1, hub.Subscribers(1))
+
+	unsubscribe()
+	unsubscribe()
+	require.Zero(t, hub.Subscribers(1))
+
+	_, ok := <-events
+	require.False(t, ok)
+
+	// publishing without any subscriber is a no-op
+	hub.Publish(Event{Type: EventBalance, AccountID: 1})
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+
+	slow, unsubscribeSlow := hub.Subscribe(1)
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := hub.Subscribe(1)
+	defer unsubscribeFast()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(Event{Type: EventEntry, AccountID: 1, EntryID: int64(i)})
+		<-fast
+	}
+
+	// the buffered events are still delivered before the channel is closed
+	for i := 0; i < subscriberBuffer; i++ {
+		event, ok := <-slow
+		require.True(t, ok)
+		require.Equal(t, int64(i), event.EntryID)
+	}
+
+	_, ok := <-slow
+	require.False(t, ok)
+	require.Equal(t, 1, hub.Subscribers(1))
+}
+
+func TestHubReset(t *testing.T) {
+	hub := NewHub()
+
+	events1, _ := hub.Subscribe(1)
+	events2, _ := hub.Subscribe(2)
+
+	hub.Reset()
+	require.Zero(t, hub.Subscribers(1))
+	require.Zero(t, hub.Subscribers(2))
+
+	_, ok := <-events1
+	require.False(t, ok)
+	_, ok = <-events2
+	require.False(t, ok)
+}
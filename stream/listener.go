@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel : the postgres channel the account events are notified on, see the
+// `13_add_account_event_notifications` migration
+const Channel = "account_events"
+
+const (
+	minReconnectInterval = time.Second * 10
+	maxReconnectInterval = time.Minute
+	pingInterval         = time.Second * 90
+)
+
+// Listen : listens to the account events of the database and publishes them to the hub until the context is
+// cancelled. Every server instance listens on its own connection, so the events of a transfer committed by
+// any instance reach the subscribers of all of them
+func Listen(ctx context.Context, dsn string, hub *Hub) error {
+	listener := pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("❌ account events listener, error: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(Channel)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case notification := <-listener.Notify:
+			// a nil notification is sent once the connection has been re-established, the
+			// notifications sent in between are lost
+			if notification == nil {
+				hub.Reset()
+				continue
+			}
+
+			var event Event
+			err := json.Unmarshal([]byte(notification.Extra), &event)
+			if err != nil {
+				log.Printf("❌ invalid account event %q, error: %v", notification.Extra, err)
+				continue
+			}
+			hub.Publish(event)
+
+		case <-ticker.C:
+			// detects a dead connection which would otherwise go unnoticed while it is idle
+			go listener.Ping()
+		}
+	}
+}
@@ -26,7 +26,12 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 
 // CreateToken : creates a new JWT token for the provided userID and duration
 func (maker *JWTMaker) CreateToken(userID uint, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, duration)
+	return maker.CreateScopedToken(userID, ScopeAccess, duration)
+}
+
+// CreateScopedToken : creates a new JWT token for the provided userID, scope and duration
+func (maker *JWTMaker) CreateScopedToken(userID uint, scope string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(userID, scope, duration)
 	if err != nil {
 		return "", nil, err
 	}
@@ -7,6 +7,9 @@ type Maker interface {
 	// CreateToken : creates a new token for the provided userID and duration
 	CreateToken(userID uint, duration time.Duration) (string, *Payload, error)
 
+	// CreateScopedToken : creates a new token for the provided userID, scope and duration
+	CreateScopedToken(userID uint, scope string, duration time.Duration) (string, *Payload, error)
+
 	// VerifyToken : checks whether the token is valid or not
 	VerifyToken(token string) (*Payload, error)
 }
@@ -0,0 +1,28 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// opaqueTokenSize : number of random bytes in an opaque token
+const opaqueTokenSize = 32
+
+// GenerateOpaqueToken : returns a random url safe token, e.g: for the password reset links.
+// Unlike the access tokens it carries no data and has to be looked up by its hash
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, opaqueTokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken : returns the hex encoded sha256 of the token, only the hash gets stored
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,22 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaqueToken(t *testing.T) {
+	token1, err := GenerateOpaqueToken()
+	require.NoError(t, err)
+	require.Len(t, token1, 43)
+
+	token2, err := GenerateOpaqueToken()
+	require.NoError(t, err)
+	require.NotEqual(t, token1, token2)
+
+	hash := HashOpaqueToken(token1)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashOpaqueToken(token1))
+	require.NotEqual(t, hash, HashOpaqueToken(token2))
+}
@@ -30,7 +30,12 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 
 // CreateToken : creates a new paseto token for the provided userID and duration
 func (maker *PasetoMaker) CreateToken(userID uint, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, duration)
+	return maker.CreateScopedToken(userID, ScopeAccess, duration)
+}
+
+// CreateScopedToken : creates a new paseto token for the provided userID, scope and duration
+func (maker *PasetoMaker) CreateScopedToken(userID uint, scope string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(userID, scope, duration)
 	if err != nil {
 		return "", nil, err
 	}
@@ -49,3 +49,28 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.Equal(t, err.Error(), ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestPasetoMakerScopedToken(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32))
+	require.NoError(t, err)
+
+	userID := uint(utils.RandomInt(1, 1000))
+
+	token, payload, err := maker.CreateScopedToken(userID, ScopeLoginChallenge, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.Equal(t, ScopeLoginChallenge, payload.Scope)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, userID, payload.UserID)
+	require.Equal(t, ScopeLoginChallenge, payload.Scope)
+
+	// the tokens created without a scope are access tokens
+	token, _, err = maker.CreateToken(userID, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, ScopeAccess, payload.Scope)
+}
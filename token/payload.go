@@ -7,6 +7,14 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// ScopeAccess : the token grants access to the authenticated routes
+	ScopeAccess = "access"
+
+	// ScopeLoginChallenge : the token can only be exchanged for an access token along with a one time password
+	ScopeLoginChallenge = "login_challenge"
+)
+
 var (
 	ErrExpiredToken = errors.New("token has expired")
 
@@ -17,12 +25,18 @@ var (
 type Payload struct {
 	ID        uuid.UUID `json:"id"` // this is for invalidating a token in case if it is leaked
 	UserID    uint      `json:"user_id"`
+	Scope     string    `json:"scope"` // what the token can be used for
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// NewPayload : creates a new token payload with the provided userID and duration
+// NewPayload : creates a new access token payload with the provided userID and duration
 func NewPayload(userID uint, duration time.Duration) (*Payload, error) {
+	return NewScopedPayload(userID, ScopeAccess, duration)
+}
+
+// NewScopedPayload : creates a new token payload with the provided userID, scope and duration
+func NewScopedPayload(userID uint, scope string, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -31,6 +45,7 @@ func NewPayload(userID uint, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:        tokenID,
 		UserID:    userID,
+		Scope:     scope,
 		IssuedAt:  time.Now(),
 		ExpiresAt: time.Now().Add(duration),
 	}
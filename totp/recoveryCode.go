@@ -0,0 +1,46 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+const (
+	// RecoveryCodeCount : number of recovery codes issued on enrollment
+	RecoveryCodeCount = 10
+
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // without the characters that are easy to confuse
+	recoveryCodeLength   = 10
+)
+
+// GenerateRecoveryCodes : returns n new random recovery codes formatted as `xxxxx-xxxxx`
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		var sb strings.Builder
+		for j := 0; j < recoveryCodeLength; j++ {
+			if j == recoveryCodeLength/2 {
+				sb.WriteByte('-')
+			}
+
+			index, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteByte(recoveryCodeAlphabet[index.Int64()])
+		}
+		codes = append(codes, sb.String())
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode : returns the hex encoded sha256 of the normalized recovery code,
+// the codes are random enough that a slow password hash isn't required
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
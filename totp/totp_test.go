@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCodeRFC6238(t *testing.T) {
+	// test vectors of RFC 6238 (appendix B) for SHA1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := GenerateCode(secret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step, err := Validate(code, secret, now)
+	require.NoError(t, err)
+	require.Equal(t, Step(now), step)
+
+	// the code of the previous period is still accepted to allow for clock drift
+	step, err = Validate(code, secret, now.Add(period*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, Step(now), step)
+
+	_, err = Validate(code, secret, now.Add(5*period*time.Second))
+	require.Equal(t, ErrInvalidCode, err)
+
+	_, err = Validate("12345", secret, now)
+	require.Equal(t, ErrInvalidCode, err)
+
+	_, err = Validate(code, "not-base32!", now)
+	require.Equal(t, ErrInvalidSecret, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Banking System", "john", "JBSWY3DPEHPK3PXP")
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Banking%20System:john?"))
+	require.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	require.Contains(t, uri, "issuer=Banking+System")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, recoveryCodeLength+1)
+		require.Equal(t, byte('-'), code[recoveryCodeLength/2])
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+
+	// the hash doesn't depend on the formatting of the code
+	code := codes[0]
+	require.Equal(t, HashRecoveryCode(code), HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))))
+	require.NotEqual(t, HashRecoveryCode(codes[0]), HashRecoveryCode(codes[1]))
+}
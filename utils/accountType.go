@@ -0,0 +1,16 @@
+package utils
+
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings  = "savings"
+	AccountTypeWallet   = "wallet"
+)
+
+// returns true if account type is supported, the types are also enforced by the accounts table
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case AccountTypeChecking, AccountTypeSavings, AccountTypeWallet:
+		return true
+	}
+	return false
+}
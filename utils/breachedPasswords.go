@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// breachedLineReadSize : bytes read at once while looking up the list, a line of the "ordered by hash"
+// downloads is a hash and a count, well below it
+const breachedLineReadSize = 128
+
+// BreachedPasswordList : an offline list of the SHA-1 hashes of the passwords that appeared in data breaches.
+// The file is binary searched on every lookup rather than loaded, so that a list of hundreds of millions
+// of hashes takes no memory
+type BreachedPasswordList struct {
+	file  *os.File
+	start int64 // offset of the first hash, after the comments at the top
+	size  int64
+}
+
+// LoadBreachedPasswordList : opens a file with one uppercase or lowercase hex SHA-1 hash per line, optionally
+// followed by `:count`, ordered by hash, e.g: the "ordered by hash" downloads. Empty lines and lines starting
+// with # are only allowed at the top. Only the first hash is checked, the file is never read as a whole
+func LoadBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the breached password list, err: %v", err)
+	}
+
+	list, err := newBreachedPasswordList(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return list, nil
+}
+
+func newBreachedPasswordList(file *os.File) (*BreachedPasswordList, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the breached password list, err: %v", err)
+	}
+
+	list := &BreachedPasswordList{file: file, size: info.Size()}
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("unable to read the breached password list, err: %v", err)
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if !isSHA1Hex(breachedHash(trimmed)) {
+				return nil, fmt.Errorf("invalid SHA-1 hash on line %d of the breached password list", lineNumber)
+			}
+			return list, nil
+		}
+
+		list.start += int64(len(line))
+		if err == io.EOF {
+			return list, nil
+		}
+	}
+}
+
+// Contains : reports whether the password is in the list. The list is only read, a failed read is logged
+// and the password isn't reported as breached
+func (list *BreachedPasswordList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	found, err := list.search(hash)
+	if err != nil {
+		log.Printf("unable to search the breached password list, err: %v", err)
+		return false
+	}
+	return found
+}
+
+// search : binary searches the offsets of the file. The lines starting before lo have a lower hash and the
+// ones starting at hi or later a higher one, lo is always the start of a line
+func (list *BreachedPasswordList) search(hash string) (bool, error) {
+	lo, hi := list.start, list.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		lineStart := mid
+		if mid > lo {
+			var err error
+			lineStart, err = list.nextLineStart(mid - 1)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if lineStart >= hi {
+			hi = mid
+			continue
+		}
+
+		line, next, err := list.readLine(lineStart)
+		if err != nil {
+			return false, err
+		}
+
+		switch lineHash := breachedHash(line); {
+		case lineHash == hash:
+			return true, nil
+		case lineHash < hash:
+			lo = next
+		default:
+			hi = lineStart
+		}
+	}
+	return false, nil
+}
+
+// nextLineStart : returns the offset following the first newline at or after the offset, the size of the
+// file if there is none
+func (list *BreachedPasswordList) nextLineStart(offset int64) (int64, error) {
+	buf := make([]byte, breachedLineReadSize)
+	for offset < list.size {
+		n, err := list.file.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		i := bytes.IndexByte(buf[:n], '\n')
+		if i >= 0 {
+			return offset + int64(i) + 1, nil
+		}
+		offset += int64(n)
+	}
+	return list.size, nil
+}
+
+// readLine : returns the line starting at the offset and the offset of the following line
+func (list *BreachedPasswordList) readLine(offset int64) (string, int64, error) {
+	next, err := list.nextLineStart(offset)
+	if err != nil {
+		return "", 0, err
+	}
+
+	length := next - offset
+	if length > breachedLineReadSize {
+		// only the hash at the start of the line is compared
+		length = breachedLineReadSize
+	}
+
+	buf := make([]byte, length)
+	n, err := list.file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return string(buf[:n]), next, nil
+}
+
+// breachedHash : returns the uppercase hash of a line of the list, without the count
+func breachedHash(line string) string {
+	hash := strings.SplitN(strings.TrimSpace(line), ":", 2)[0]
+	return strings.ToUpper(hash)
+}
+
+func isSHA1Hex(hash string) bool {
+	_, err := hex.DecodeString(hash)
+	return err == nil && len(hash) == sha1.Size*2
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreachedPasswordList(t *testing.T) {
+	// SHA-1 of "password" with a count, and of "123456" in lowercase without a count
+	content := "# breached passwords\n\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := LoadBreachedPasswordList(path)
+	require.NoError(t, err)
+
+	require.True(t, list.Contains("password"))
+	require.True(t, list.Contains("123456"))
+	require.False(t, list.Contains("Password"))
+	require.False(t, list.Contains(RandomString(12)))
+
+	violations := PasswordPolicy{Breached: list}.Validate("password")
+	require.Len(t, violations, 1)
+	require.Equal(t, PasswordBreached, violations[0].Code)
+}
+
+func TestBreachedPasswordListSearch(t *testing.T) {
+	passwords := make([]string, 1000)
+	lines := make([]string, len(passwords))
+	for i := range passwords {
+		passwords[i] = RandomString(10)
+		sum := sha1.Sum([]byte(passwords[i]))
+		lines[i] = fmt.Sprintf("%X:%d\r\n", sum, i+1)
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# ordered by hash\n"+strings.Join(lines, "")), 0o600))
+
+	list, err := LoadBreachedPasswordList(path)
+	require.NoError(t, err)
+
+	// every hash is found wherever it is in the file, the first and the last ones included
+	for _, password := range passwords {
+		require.True(t, list.Contains(password), password)
+	}
+	for i := 0; i < 100; i++ {
+		require.False(t, list.Contains(RandomString(11)))
+	}
+}
+
+func TestEmptyBreachedPasswordList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# no hashes yet\n"), 0o600))
+
+	list, err := LoadBreachedPasswordList(path)
+	require.NoError(t, err)
+	require.False(t, list.Contains("password"))
+}
+
+func TestLoadBreachedPasswordListErrors(t *testing.T) {
+	_, err := LoadBreachedPasswordList(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "invalid.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+
+	_, err = LoadBreachedPasswordList(path)
+	require.Error(t, err)
+}
+
+func TestSampleBreachedPasswordList(t *testing.T) {
+	list, err := LoadBreachedPasswordList("../config/breachedPasswords.txt")
+	require.NoError(t, err)
+	require.True(t, list.Contains("password"))
+	require.True(t, list.Contains("qwerty"))
+}
@@ -1,21 +1,23 @@
 package utils
 
 import (
-	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword : returns the bcrypt hash of the plainTextPassword
+// HashPassword : returns the bcrypt hash of the plainTextPassword with the default cost,
+// the server hashes with the PasswordHasher built from the config instead
 func HashPassword(plainTextPassword string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(plainTextPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("falied to hash password, error:%s", err)
-	}
-	return string(hashedPassword), nil
+	hasher := &BcryptHasher{cost: bcrypt.DefaultCost}
+	return hasher.Hash(plainTextPassword)
 }
 
-// CheckPassword : checks if the plainTextPassword is correct when matched with the hashedPassword
+// CheckPassword : checks if the plainTextPassword is correct when matched with the hashedPassword,
+// the algorithm is detected from the hash so that every supported algorithm can be checked
 func CheckPassword(plainTextPassword, hashedPassword string) error {
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return checkArgon2idPassword(plainTextPassword, hashedPassword)
+	}
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainTextPassword))
 }
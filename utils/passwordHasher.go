@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordHashBcrypt : bcrypt hashes in the modular crypt format, e.g: $2a$10$...
+	PasswordHashBcrypt = "bcrypt"
+
+	// PasswordHashArgon2id : argon2id hashes in the PHC string format, e.g: $argon2id$v=19$m=65536,t=3,p=2$salt$hash
+	PasswordHashArgon2id = "argon2id"
+
+	argon2idPrefix     = "$argon2id$"
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+var (
+	// ErrMismatchedPassword : the password doesn't match the hash, whatever the algorithm of the hash is
+	ErrMismatchedPassword = bcrypt.ErrMismatchedHashAndPassword
+
+	// ErrInvalidPasswordHash : the hash is not in a supported format
+	ErrInvalidPasswordHash = errors.New("invalid password hash")
+)
+
+// PasswordHasher : is an interface for hashing the passwords with a specific algorithm and cost
+type PasswordHasher interface {
+	// Hash : returns the hash of the plainTextPassword
+	Hash(plainTextPassword string) (string, error)
+
+	// NeedsRehash : reports whether the hash uses another algorithm or cost than the hasher
+	NeedsRehash(hashedPassword string) bool
+}
+
+// NewPasswordHasher : creates the PasswordHasher for the provided algorithm
+func NewPasswordHasher(algorithm string, bcryptCost int, argon2idParams Argon2idParams) (PasswordHasher, error) {
+	switch algorithm {
+	case PasswordHashBcrypt:
+		return NewBcryptHasher(bcryptCost)
+	case PasswordHashArgon2id:
+		return NewArgon2idHasher(argon2idParams)
+	}
+	return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+}
+
+// BcryptMaxPasswordBytes : bcrypt only hashes the first 72 bytes of a password
+const BcryptMaxPasswordBytes = 72
+
+// BcryptHasher : hashes the passwords with bcrypt
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher : creates a new BcryptHasher
+func NewBcryptHasher(cost int) (PasswordHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("invalid bcrypt cost: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{cost: cost}, nil
+}
+
+// Hash : returns the bcrypt hash of the plainTextPassword
+func (hasher *BcryptHasher) Hash(plainTextPassword string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(plainTextPassword), hasher.cost)
+	if err != nil {
+		return "", fmt.Errorf("falied to hash password, error:%s", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// NeedsRehash : reports whether the hash isn't a bcrypt hash of the same cost
+func (hasher *BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return true
+	}
+	return cost != hasher.cost
+}
+
+// Argon2idParams : the cost parameters of argon2id
+type Argon2idParams struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// Argon2idHasher : hashes the passwords with argon2id
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher : creates a new Argon2idHasher
+func NewArgon2idHasher(params Argon2idParams) (PasswordHasher, error) {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, fmt.Errorf("invalid argon2id params: iterations and parallelism must be at least 1 and memory at least 8 KiB per thread")
+	}
+	return &Argon2idHasher{params: params}, nil
+}
+
+// Hash : returns the argon2id hash of the plainTextPassword in the PHC string format
+func (hasher *Argon2idHasher) Hash(plainTextPassword string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("falied to hash password, error:%s", err)
+	}
+
+	key := argon2.IDKey([]byte(plainTextPassword), salt, hasher.params.Iterations, hasher.params.Memory, hasher.params.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version,
+		hasher.params.Memory, hasher.params.Iterations, hasher.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash : reports whether the hash isn't an argon2id hash of the same parameters
+func (hasher *Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params != hasher.params || len(key) != argon2idKeyLength
+}
+
+// decodeArgon2idHash : parses a hash in the PHC string format
+func decodeArgon2idHash(hashedPassword string) (params Argon2idParams, salt []byte, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	return params, salt, key, nil
+}
+
+// checkArgon2idPassword : checks the plainTextPassword against an argon2id hash in constant time
+func checkArgon2idPassword(plainTextPassword, hashedPassword string) error {
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(plainTextPassword), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams : cheap params so that the tests stay fast
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(PasswordHashArgon2id, bcrypt.DefaultCost, testArgon2idParams)
+	require.NoError(t, err)
+
+	plainTextPassword := RandomString(6)
+
+	hashedPassword1, err := hasher.Hash(plainTextPassword)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword1, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	err = CheckPassword(plainTextPassword, hashedPassword1)
+	require.NoError(t, err)
+
+	err = CheckPassword(RandomString(7), hashedPassword1)
+	require.ErrorIs(t, err, ErrMismatchedPassword)
+
+	// the salt makes every hash of the same password different
+	hashedPassword2, err := hasher.Hash(plainTextPassword)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+
+	err = CheckPassword(plainTextPassword, "$argon2id$v=19$m=64,t=1,p=1$invalid")
+	require.ErrorIs(t, err, ErrInvalidPasswordHash)
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(PasswordHashBcrypt, bcrypt.MinCost, testArgon2idParams)
+	require.NoError(t, err)
+
+	plainTextPassword := RandomString(6)
+
+	hashedPassword, err := hasher.Hash(plainTextPassword)
+	require.NoError(t, err)
+
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.MinCost, cost)
+
+	require.NoError(t, CheckPassword(plainTextPassword, hashedPassword))
+	require.ErrorIs(t, CheckPassword(RandomString(7), hashedPassword), ErrMismatchedPassword)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHasher, err := NewBcryptHasher(bcrypt.MinCost)
+	require.NoError(t, err)
+
+	argon2idHasher, err := NewArgon2idHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	strongerArgon2idHasher, err := NewArgon2idHasher(Argon2idParams{Memory: 128, Iterations: 2, Parallelism: 1})
+	require.NoError(t, err)
+
+	bcryptHash, err := bcryptHasher.Hash(RandomString(6))
+	require.NoError(t, err)
+
+	defaultCostBcryptHash, err := HashPassword(RandomString(6))
+	require.NoError(t, err)
+
+	argon2idHash, err := argon2idHasher.Hash(RandomString(6))
+	require.NoError(t, err)
+
+	require.False(t, bcryptHasher.NeedsRehash(bcryptHash))
+	require.True(t, bcryptHasher.NeedsRehash(defaultCostBcryptHash))
+	require.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+
+	require.False(t, argon2idHasher.NeedsRehash(argon2idHash))
+	require.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+	require.True(t, strongerArgon2idHasher.NeedsRehash(argon2idHash))
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	_, err := NewPasswordHasher("md5", bcrypt.DefaultCost, testArgon2idParams)
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(PasswordHashBcrypt, bcrypt.MaxCost+1, testArgon2idParams)
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(PasswordHashArgon2id, bcrypt.DefaultCost, Argon2idParams{Memory: 64, Iterations: 0, Parallelism: 1})
+	require.Error(t, err)
+}
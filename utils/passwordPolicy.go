@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minSimilarityLength : the user inputs shorter than this are not checked for similarity
+const minSimilarityLength = 3
+
+// codes of the password policy violations
+const (
+	PasswordTooShort          = "too_short"
+	PasswordTooLong           = "too_long"
+	PasswordMissingUppercase  = "missing_uppercase"
+	PasswordMissingLowercase  = "missing_lowercase"
+	PasswordMissingDigit      = "missing_digit"
+	PasswordMissingSymbol     = "missing_symbol"
+	PasswordSimilarToUserInfo = "similar_to_user_info"
+	PasswordBreached          = "breached"
+)
+
+// PasswordPolicy : the rules a new password has to follow
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int // 0 means no limit
+	MaxBytes         int // of the UTF-8 encoding, 0 means no limit, e.g: BcryptMaxPasswordBytes when hashing with bcrypt
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowUserInfo bool // rejects the passwords which contain the username or email of the user, or the other way round
+
+	// Breached : rejects the passwords that appeared in the known data breaches, nil disables the check
+	Breached *BreachedPasswordList
+}
+
+// PasswordViolation : a rule of the policy that the password doesn't follow
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Validate : returns the rules of the policy that the password doesn't follow, the userInputs
+// are the username, email etc. of the user which the password must not resemble
+func (policy PasswordPolicy) Validate(password string, userInputs ...string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters long", policy.MinLength),
+		})
+	}
+
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("password must be at most %d characters long", policy.MaxLength),
+		})
+	} else if policy.MaxBytes > 0 && len(password) > policy.MaxBytes {
+		// the multi-byte characters count more than once, a hasher which truncates the password would ignore the rest
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("password must be at most %d bytes long, the accented and other non-ASCII characters take more than one", policy.MaxBytes),
+		})
+	}
+
+	var hasUppercase, hasLowercase, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUppercase = true
+		case unicode.IsLower(r):
+			hasLowercase = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireUppercase && !hasUppercase {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMissingUppercase,
+			Message: "password must contain an uppercase letter",
+		})
+	}
+
+	if policy.RequireLowercase && !hasLowercase {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMissingLowercase,
+			Message: "password must contain a lowercase letter",
+		})
+	}
+
+	if policy.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMissingDigit,
+			Message: "password must contain a digit",
+		})
+	}
+
+	if policy.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMissingSymbol,
+			Message: "password must contain a symbol",
+		})
+	}
+
+	if policy.DisallowUserInfo && isSimilarToUserInfo(password, userInputs) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordSimilarToUserInfo,
+			Message: "password must not contain the username or email",
+		})
+	}
+
+	if policy.Breached != nil && policy.Breached.Contains(password) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordBreached,
+			Message: "password has appeared in a data breach, choose another one",
+		})
+	}
+
+	return violations
+}
+
+// isSimilarToUserInfo : reports whether the password contains one of the user inputs or the other way round,
+// for the emails only the part before the @ is compared
+func isSimilarToUserInfo(password string, userInputs []string) bool {
+	password = strings.ToLower(password)
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if at := strings.LastIndex(input, "@"); at >= 0 {
+			input = input[:at]
+		}
+
+		if utf8.RuneCountInString(input) < minSimilarityLength {
+			continue
+		}
+
+		if strings.Contains(password, input) || strings.Contains(input, password) {
+			return true
+		}
+	}
+	return false
+}
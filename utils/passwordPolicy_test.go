@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        16,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUserInfo: true,
+	}
+
+	testCases := []struct {
+		name          string
+		password      string
+		userInputs    []string
+		expectedCodes []string
+	}{
+		{
+			name:          "Happy Case - All OK",
+			password:      "Str0ng!Pass",
+			userInputs:    []string{"johndoe", "john@example.com"},
+			expectedCodes: nil,
+		},
+		{
+			name:          "Failure Case - Too Short",
+			password:      "Sh0rt!",
+			expectedCodes: []string{PasswordTooShort},
+		},
+		{
+			name:          "Failure Case - Too Long",
+			password:      "Th1s!Is!Way!Too!Long",
+			expectedCodes: []string{PasswordTooLong},
+		},
+		{
+			name:          "Failure Case - Missing Character Classes",
+			password:      "alllowercase",
+			expectedCodes: []string{PasswordMissingUppercase, PasswordMissingDigit, PasswordMissingSymbol},
+		},
+		{
+			name:          "Failure Case - Missing Lowercase",
+			password:      "ALLUPPER1!",
+			expectedCodes: []string{PasswordMissingLowercase},
+		},
+		{
+			name:          "Failure Case - Contains The Username",
+			password:      "JohnDoe#2022",
+			userInputs:    []string{"johndoe", "someone@example.com"},
+			expectedCodes: []string{PasswordSimilarToUserInfo},
+		},
+		{
+			name:          "Failure Case - Contains The Email",
+			password:      "X1!Janedoe",
+			userInputs:    []string{"someone", "janedoe@example.com"},
+			expectedCodes: []string{PasswordSimilarToUserInfo},
+		},
+		{
+			name:          "Happy Case - Short User Inputs Are Ignored",
+			password:      "Ab1!Ab1!Ab",
+			userInputs:    []string{"ab", "a@example.com"},
+			expectedCodes: nil,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			violations := policy.Validate(tc.password, tc.userInputs...)
+
+			var codes []string
+			for _, violation := range violations {
+				require.NotEmpty(t, violation.Message)
+				codes = append(codes, violation.Code)
+			}
+			require.Equal(t, tc.expectedCodes, codes)
+		})
+	}
+}
+
+func TestPasswordPolicyMaxBytes(t *testing.T) {
+	policy := PasswordPolicy{MaxLength: 72, MaxBytes: BcryptMaxPasswordBytes}
+
+	// 36 two-byte characters fill the bytes bcrypt hashes
+	require.Empty(t, policy.Validate(strings.Repeat("é", 36)))
+
+	// 40 characters are within the length but not within the bytes, the last ones wouldn't be hashed
+	violations := policy.Validate(strings.Repeat("é", 40))
+	require.Len(t, violations, 1)
+	require.Equal(t, PasswordTooLong, violations[0].Code)
+	require.Contains(t, violations[0].Message, "72 bytes")
+
+	// a password over both limits is too long only once
+	violations = policy.Validate(strings.Repeat("a", 73))
+	require.Len(t, violations, 1)
+	require.Equal(t, PasswordTooLong, violations[0].Code)
+}
@@ -54,6 +54,12 @@ func RandomCurrency() string {
 
 }
 
+// RandomAccountType : returns a random account type
+func RandomAccountType() string {
+	accountTypes := []string{AccountTypeChecking, AccountTypeSavings, AccountTypeWallet}
+	return accountTypes[rand.Intn(len(accountTypes))]
+}
+
 // RandomEmail : returns a random email
 func RandomEmail() string {
 	return fmt.Sprintf("%s@email.com", RandomString(6))
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	key := []byte(RandomString(32))
+	message := RandomString(20)
+
+	signature := Sign(key, message)
+	require.Len(t, signature, 64)
+	require.True(t, VerifySignature(key, message, signature))
+
+	// the signature only matches the same message signed with the same key
+	require.False(t, VerifySignature(key, RandomString(21), signature))
+	require.False(t, VerifySignature([]byte(RandomString(32)), message, signature))
+	require.False(t, VerifySignature(key, message, "not-hex"))
+	require.False(t, VerifySignature(key, message, ""))
+}
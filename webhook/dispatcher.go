@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+)
+
+// maxDrainLength : the part of the body of a response read so that the connection can be reused
+const maxDrainLength = 4 << 10
+
+// Config : the settings of a Dispatcher
+type Config struct {
+	BatchSize   int32         // events fanned out and deliveries attempted per run
+	MaxAttempts int32         // a delivery is dead-lettered once it has failed this many times
+	BaseBackoff time.Duration // wait after the first failed attempt, doubled on every further failure
+	MaxBackoff  time.Duration // longest wait between two attempts
+	Timeout     time.Duration // of a single attempt
+}
+
+// Event : the body of a delivery
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Report : the outcome of a dispatch run
+type Report struct {
+	Events     int // outbox events fanned out to the webhooks
+	Deliveries int // deliveries created for these events
+	Succeeded  int
+	Retried    int // failed attempts which will be retried
+	Dead       int // failed attempts which exhausted the delivery
+}
+
+// Dispatcher : fans the outbox events out to the subscribed webhooks and delivers them
+type Dispatcher struct {
+	store  db.Store
+	client *http.Client
+	config Config
+	now    func() time.Time
+}
+
+// NewDispatcher : creates a new Dispatcher
+func NewDispatcher(store db.Store, client *http.Client, config Config) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: client,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// NewHTTPClient : returns the client the deliveries are sent with. The redirects are not followed so that
+// a webhook can't bounce the deliveries to another host, and unless allowPrivateNetworks is set the
+// connections are only made to public addresses, checked once the host has been resolved
+func NewHTTPClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = dialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would connect to the webhook on our behalf, past the check of the dialer
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Backoff : returns the wait before the next attempt of a delivery which has failed `attempts` times
+func Backoff(attempts int32, base time.Duration, max time.Duration) time.Duration {
+	backoff := base
+	for i := int32(1); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+// Run : dispatches every interval until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := d.RunOnce(ctx)
+		if err != nil {
+			log.Printf("❌ webhook dispatch failed, error: %v", err)
+		} else if report.Deliveries > 0 || report.Succeeded > 0 || report.Retried > 0 || report.Dead > 0 {
+			log.Printf("📨 webhooks: %+v", report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce : fans out a batch of outbox events and attempts a batch of the due deliveries. The claimed
+// deliveries are leased for twice the timeout, so a dispatcher which crashes mid-attempt only delays them
+func (d *Dispatcher) RunOnce(ctx context.Context) (Report, error) {
+	var report Report
+
+	fanOut, err := d.store.FanOutOutboxTxn(ctx, d.config.BatchSize)
+	if err != nil {
+		return report, err
+	}
+	report.Events = fanOut.Events
+	report.Deliveries = fanOut.Deliveries
+
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+		Limit:        d.config.BatchSize,
+		LeaseSeconds: int32(2*d.config.Timeout/time.Second) + 1,
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for _, delivery := range deliveries {
+		status, err := d.attempt(ctx, delivery)
+		if err != nil {
+			return report, err
+		}
+
+		switch status {
+		case db.DeliveryStatusSucceeded:
+			report.Succeeded++
+		case db.DeliveryStatusPending:
+			report.Retried++
+		case db.DeliveryStatusDead:
+			report.Dead++
+		}
+	}
+
+	return report, nil
+}
+
+// attempt : sends the delivery once and records the outcome, returns the new status of the delivery
+func (d *Dispatcher) attempt(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) (string, error) {
+	statusCode, sendErr := d.send(ctx, delivery)
+
+	now := d.now()
+	arg := db.RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Attempts:       delivery.Attempts + 1,
+		LastStatusCode: int32(statusCode),
+	}
+
+	switch {
+	case sendErr == nil:
+		arg.Status = db.DeliveryStatusSucceeded
+		arg.NextAttemptAt = now
+		arg.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+	case arg.Attempts >= d.config.MaxAttempts:
+		arg.Status = db.DeliveryStatusDead
+		arg.NextAttemptAt = now
+		arg.LastError = sendErr.Error()
+	default:
+		arg.Status = db.DeliveryStatusPending
+		arg.NextAttemptAt = now.Add(Backoff(arg.Attempts, d.config.BaseBackoff, d.config.MaxBackoff))
+		arg.LastError = sendErr.Error()
+	}
+
+	_, err := d.store.RecordWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+
+	return arg.Status, nil
+}
+
+// send : posts the signed event to the webhook, any response other than a 2xx is a failure
+func (d *Dispatcher) send(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) (int, error) {
+	body, err := json.Marshal(Event{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.EventCreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, fmt.Sprint(delivery.ID))
+	req.Header.Set(SignatureHeader, SignatureHeaderValue(delivery.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain the body so that the connection can be reused, it is never stored: the last error of the
+	// delivery is shown to the user, who would otherwise read the responses of any host
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
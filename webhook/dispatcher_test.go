@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/skamranahmed/banking-system/db/mock"
+	db "github.com/skamranahmed/banking-system/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = Config{
+	BatchSize:   10,
+	MaxAttempts: 3,
+	BaseBackoff: time.Second * 30,
+	MaxBackoff:  time.Minute * 5,
+	Timeout:     time.Second * 5,
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int32
+		backoff  time.Duration
+	}{
+		{attempts: 1, backoff: time.Second * 30},
+		{attempts: 2, backoff: time.Minute},
+		{attempts: 3, backoff: time.Minute * 2},
+		{attempts: 4, backoff: time.Minute * 4},
+		{attempts: 5, backoff: time.Minute * 5},
+		{attempts: 100, backoff: time.Minute * 5},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.backoff, Backoff(tc.attempts, testConfig.BaseBackoff, testConfig.MaxBackoff), "attempts %d", tc.attempts)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	now := time.Date(2022, time.June, 15, 18, 30, 0, 0, time.UTC)
+	payload := json.RawMessage(`{"transfer_id":7,"amount":1000}`)
+
+	testCases := []struct {
+		name          string
+		attempts      int32
+		handler       http.HandlerFunc
+		checkAttempt  func(t *testing.T, arg db.RecordWebhookDeliveryAttemptParams)
+		checkResponse func(t *testing.T, report Report)
+	}{
+		{
+			name:     "Happy Case - Delivered",
+			attempts: 0,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			checkAttempt: func(t *testing.T, arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, db.DeliveryStatusSucceeded, arg.Status)
+				require.Equal(t, int32(1), arg.Attempts)
+				require.Equal(t, int32(http.StatusNoContent), arg.LastStatusCode)
+				require.Equal(t, sql.NullTime{Time: now, Valid: true}, arg.DeliveredAt)
+				require.Empty(t, arg.LastError)
+			},
+			checkResponse: func(t *testing.T, report Report) {
+				require.Equal(t, Report{Events: 2, Deliveries: 1, Succeeded: 1}, report)
+			},
+		},
+		{
+			name:     "Failure Case - Retried With Backoff",
+			attempts: 1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			},
+			checkAttempt: func(t *testing.T, arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, db.DeliveryStatusPending, arg.Status)
+				require.Equal(t, int32(2), arg.Attempts)
+				require.Equal(t, int32(http.StatusServiceUnavailable), arg.LastStatusCode)
+				require.Equal(t, now.Add(time.Minute), arg.NextAttemptAt)
+				require.Equal(t, "unexpected response status 503", arg.LastError)
+				require.False(t, arg.DeliveredAt.Valid)
+			},
+			checkResponse: func(t *testing.T, report Report) {
+				require.Equal(t, 1, report.Retried)
+			},
+		},
+		{
+			name:     "Failure Case - Dead Lettered",
+			attempts: 2,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			checkAttempt: func(t *testing.T, arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, db.DeliveryStatusDead, arg.Status)
+				require.Equal(t, int32(3), arg.Attempts)
+				require.NotEmpty(t, arg.LastError)
+			},
+			checkResponse: func(t *testing.T, report Report) {
+				require.Equal(t, 1, report.Dead)
+			},
+		},
+		{
+			name:     "Failure Case - Redirect Is Not Followed",
+			attempts: 0,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+			},
+			checkAttempt: func(t *testing.T, arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, db.DeliveryStatusPending, arg.Status)
+				require.Equal(t, int32(http.StatusFound), arg.LastStatusCode)
+			},
+			checkResponse: func(t *testing.T, report Report) {
+				require.Equal(t, 1, report.Retried)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			delivery := db.ClaimWebhookDeliveriesRow{
+				ID:             11,
+				WebhookID:      3,
+				EventID:        7,
+				Attempts:       tc.attempts,
+				Secret:         "secret",
+				EventType:      db.EventTransferCredited,
+				Payload:        payload,
+				EventCreatedAt: now.Add(-time.Minute),
+			}
+
+			received := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				require.Equal(t, db.EventTransferCredited, r.Header.Get(EventHeader))
+				require.Equal(t, "11", r.Header.Get(DeliveryHeader))
+				require.NoError(t, Verify(delivery.Secret, r.Header.Get(SignatureHeader), body, time.Minute, now))
+
+				var event Event
+				require.NoError(t, json.Unmarshal(body, &event))
+				require.Equal(t, delivery.EventID, event.ID)
+				require.Equal(t, delivery.EventType, event.Type)
+				require.JSONEq(t, string(payload), string(event.Data))
+
+				tc.handler(w, r)
+			}))
+			defer server.Close()
+			delivery.Url = server.URL
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				FanOutOutboxTxn(gomock.Any(), gomock.Eq(testConfig.BatchSize)).
+				Times(1).
+				Return(db.FanOutOutboxTxnResult{Events: 2, Deliveries: 1}, nil)
+			store.EXPECT().
+				ClaimWebhookDeliveries(gomock.Any(), gomock.Eq(db.ClaimWebhookDeliveriesParams{Limit: testConfig.BatchSize, LeaseSeconds: 11})).
+				Times(1).
+				Return([]db.ClaimWebhookDeliveriesRow{delivery}, nil)
+			store.EXPECT().
+				RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					tc.checkAttempt(t, arg)
+					return db.WebhookDelivery{ID: arg.ID, Status: arg.Status}, nil
+				})
+
+			dispatcher := NewDispatcher(store, NewHTTPClient(testConfig.Timeout, true), testConfig)
+			dispatcher.now = func() time.Time { return now }
+
+			report, err := dispatcher.RunOnce(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, received)
+			tc.checkResponse(t, report)
+		})
+	}
+}
+
+func TestRunOnceUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// nothing listens on the address of a closed server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().FanOutOutboxTxn(gomock.Any(), gomock.Any()).Times(1).Return(db.FanOutOutboxTxnResult{}, nil)
+	store.EXPECT().
+		ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ClaimWebhookDeliveriesRow{{ID: 1, Url: server.URL, Payload: json.RawMessage(`{}`)}}, nil)
+	store.EXPECT().
+		RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, db.DeliveryStatusPending, arg.Status)
+			require.Zero(t, arg.LastStatusCode)
+			require.NotEmpty(t, arg.LastError)
+			return db.WebhookDelivery{}, nil
+		})
+
+	report, err := NewDispatcher(store, NewHTTPClient(testConfig.Timeout, true), testConfig).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Retried)
+}
+
+func TestRunOnceForbiddenAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the server listens on the loopback address, which the deliveries must not reach
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().FanOutOutboxTxn(gomock.Any(), gomock.Any()).Times(1).Return(db.FanOutOutboxTxnResult{}, nil)
+	store.EXPECT().
+		ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ClaimWebhookDeliveriesRow{{ID: 1, Url: server.URL, Payload: json.RawMessage(`{}`)}}, nil)
+	store.EXPECT().
+		RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, db.DeliveryStatusPending, arg.Status)
+			require.Zero(t, arg.LastStatusCode)
+			require.Contains(t, arg.LastError, ErrForbiddenAddress.Error())
+			return db.WebhookDelivery{}, nil
+		})
+
+	report, err := NewDispatcher(store, NewHTTPClient(testConfig.Timeout, false), testConfig).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Retried)
+	require.Zero(t, received)
+}
+
+func TestRunOnceFanOutError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().FanOutOutboxTxn(gomock.Any(), gomock.Any()).Times(1).Return(db.FanOutOutboxTxnResult{}, sql.ErrConnDone)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := NewDispatcher(store, NewHTTPClient(testConfig.Timeout, true), testConfig).RunOnce(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
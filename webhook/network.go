@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrForbiddenAddress : the webhook resolves to an address the deliveries must not be sent to
+var ErrForbiddenAddress = errors.New("webhook address is not a public address")
+
+// nonPublicNetworks : the special purpose ranges which aren't covered by the methods of net.IP
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // this network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"198.18.0.0/15",   // benchmarking
+	"240.0.0.0/4",     // reserved, along with the broadcast address
+	"64:ff9b:1::/48",  // local-use IPv4/IPv6 translation
+	"2001:db8::/32",   // documentation
+	"fec0::/10",       // deprecated site-local
+	"::ffff:0:0:0/96", // IPv4-translated
+	"100::/64",        // discard-only
+	"2002::/16",       // 6to4, embeds any IPv4 address
+	"2001::/32",       // Teredo, embeds any IPv4 address
+	"192.88.99.0/24",  // 6to4 relay anycast
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublicIP : reports whether the deliveries can be sent to the IP, the loopback, private, link-local
+// (the cloud metadata endpoints included), multicast and other special purpose addresses are rejected
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dialControl : rejects the connections to the addresses which aren't public. It runs once the host has
+// been resolved, for every address tried, so a webhook can't pass a check and then resolve elsewhere
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "127.0.0.1", public: false},
+		{ip: "::1", public: false},
+		{ip: "::ffff:127.0.0.1", public: false},
+		{ip: "10.1.2.3", public: false},
+		{ip: "172.16.0.1", public: false},
+		{ip: "192.168.1.1", public: false},
+		{ip: "169.254.169.254", public: false}, // cloud metadata
+		{ip: "fd00:ec2::254", public: false},   // cloud metadata over IPv6
+		{ip: "fe80::1", public: false},
+		{ip: "100.64.0.1", public: false},
+		{ip: "0.0.0.0", public: false},
+		{ip: "255.255.255.255", public: false},
+		{ip: "224.0.0.1", public: false},
+		{ip: "2002:7f00:1::", public: false}, // 6to4 of 127.0.0.1
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.public, IsPublicIP(net.ParseIP(tc.ip)), tc.ip)
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	require.NoError(t, dialControl("tcp4", "93.184.216.34:443", nil))
+	require.ErrorIs(t, dialControl("tcp4", "127.0.0.1:8080", nil), ErrForbiddenAddress)
+	require.ErrorIs(t, dialControl("tcp6", "[::1]:8080", nil), ErrForbiddenAddress)
+}
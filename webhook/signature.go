@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers of a delivery
+const (
+	SignatureHeader = "X-Webhook-Signature" // t=<unix timestamp>,v1=<hex encoded HMAC-SHA256>
+	EventHeader     = "X-Webhook-Event"     // type of the event, e.g: transfer.debited
+	DeliveryHeader  = "X-Webhook-Delivery"  // ID of the delivery, the same on every retry
+)
+
+var (
+	// ErrInvalidSignature : the signature header is malformed or doesn't match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrSignatureExpired : the timestamp of the signature is outside of the tolerance
+	ErrSignatureExpired = errors.New("webhook signature has expired")
+)
+
+// Sign : returns the hex encoded HMAC-SHA256 of `<timestamp>.<body>` keyed with the secret of the webhook,
+// the timestamp is signed along with the body so that a captured delivery can't be replayed later on
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaderValue : returns the value of the signature header of the body signed at the provided time
+func SignatureHeaderValue(secret string, at time.Time, body []byte) string {
+	timestamp := at.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body))
+}
+
+// Verify : checks the signature header of a received delivery, meant for the receivers. A delivery signed
+// more than `tolerance` away from now is rejected
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+
+		switch key {
+		case "t":
+			var err error
+			timestamp, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+		case "v1":
+			signature = value
+		}
+	}
+
+	if timestamp == 0 || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
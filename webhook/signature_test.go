@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1656000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "7940c091d4016cea452eb5f65f34117af3d4b5f9b3d819ad2848c8d9c3eb3c3c", Sign("secret", 1656000000, []byte(`{"id":1}`)))
+}
+
+func TestVerify(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"id":1,"type":"transfer.credited"}`)
+	signedAt := time.Unix(1656000000, 0)
+	header := SignatureHeaderValue(secret, signedAt, body)
+	tolerance := time.Minute * 5
+
+	testCases := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		now       time.Time
+		expectErr error
+	}{
+		{
+			name:   "Happy Case - Valid Signature",
+			secret: secret,
+			header: header,
+			body:   body,
+			now:    signedAt.Add(time.Minute),
+		},
+		{
+			name:      "Failure Case - Tampered Body",
+			secret:    secret,
+			header:    header,
+			body:      []byte(`{"id":2,"type":"transfer.credited"}`),
+			now:       signedAt,
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name:      "Failure Case - Wrong Secret",
+			secret:    "another secret",
+			header:    header,
+			body:      body,
+			now:       signedAt,
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name:      "Failure Case - Malformed Header",
+			secret:    secret,
+			header:    "v1",
+			body:      body,
+			now:       signedAt,
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name:      "Failure Case - Missing Timestamp",
+			secret:    secret,
+			header:    "v1=" + Sign(secret, signedAt.Unix(), body),
+			body:      body,
+			now:       signedAt,
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name:      "Failure Case - Replayed Later",
+			secret:    secret,
+			header:    header,
+			body:      body,
+			now:       signedAt.Add(time.Hour),
+			expectErr: ErrSignatureExpired,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, tolerance, tc.now)
+			require.Equal(t, tc.expectErr, err)
+		})
+	}
+}